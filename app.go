@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/edit4i/editor/internal/db"
 	"github.com/edit4i/editor/internal/service"
@@ -15,10 +18,19 @@ import (
 type App struct {
 	ctx             context.Context
 	projects        *service.ProjectsService
+	workspaces      *service.WorkspaceService
 	files           *service.FileService
+	watcher         *service.FileWatcher
+	access          *service.AccessGuard
 	config          *service.ConfigService
 	terminalService *service.TerminalService
 	git             *service.GitService
+	diff            *service.DiffService
+
+	// Cancel functions of the running searches, a new search replaces the previous one
+	searchLock          sync.Mutex
+	fileSearchCancel    context.CancelFunc
+	contentSearchCancel context.CancelFunc
 }
 
 // NewApp creates a new App application struct
@@ -39,8 +51,26 @@ func (a *App) startup(ctx context.Context) {
 
 	// Initialize services
 	a.projects = service.NewProjectsService(dbConn)
-	a.files = service.NewFileService()
+	a.workspaces = service.NewWorkspaceService(dbConn)
+
+	access, err := service.NewAccessGuard(filepath.Join(db.DefaultConfig().Directory, "audit.log"))
+	if err != nil {
+		panic(fmt.Errorf("Failed to initialize AccessGuard: %v", err))
+	}
+	a.access = access
+
+	a.files = service.NewFileService(dbConn, db.DefaultConfig().Directory)
 	a.git = service.NewGitService()
+	a.diff = service.NewDiffService(a.files)
+
+	watcher, err := service.NewFileWatcher(a.files, func(projectPath string, events []service.FileEvent) {
+		// Emit file changes to frontend
+		runtime.EventsEmit(a.ctx, "files:changed", events)
+	})
+	if err != nil {
+		panic(fmt.Errorf("Failed to initialize FileWatcher: %v", err))
+	}
+	a.watcher = watcher
 
 	config, err := service.NewConfigService()
 	if err != nil {
@@ -48,6 +78,21 @@ func (a *App) startup(ctx context.Context) {
 	}
 	a.config = config
 
+	// The config file can be opened in the editor from anywhere
+	if err := a.access.Allow(config.OpenConfigFile()); err != nil {
+		log.Printf("[App] Failed to allow access to config file: %v", err)
+	}
+
+	if err := a.files.ApplySettings(config.GetConfig().FileSettings()); err != nil {
+		log.Printf("[App] Invalid file settings in config: %v", err)
+	}
+	if err := a.files.CleanTrash(); err != nil {
+		log.Printf("[App] Failed to clean trash: %v", err)
+	}
+	if err := a.files.CleanHistory(); err != nil {
+		log.Printf("[App] Failed to clean local history: %v", err)
+	}
+
 	// Initialize terminal service with event handler
 	a.terminalService = service.NewTerminalService(func(id string, event *terminal.Event) {
 		// Emit terminal events to frontend
@@ -55,6 +100,16 @@ func (a *App) startup(ctx context.Context) {
 	})
 }
 
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	if a.watcher != nil {
+		a.watcher.Close()
+	}
+	if a.access != nil {
+		a.access.Close()
+	}
+}
+
 // GetRecentProjects returns the list of recent projects
 func (a *App) GetRecentProjects() ([]db.Project, error) {
 	return a.projects.GetRecentProjects(4)
@@ -77,43 +132,405 @@ func (a *App) OpenProjectFolder() (string, error) {
 		return "", fmt.Errorf("error opening directory dialog: %v", err)
 	}
 
+	// A folder picked by the user becomes accessible
+	if path != "" {
+		if err := a.access.AddRoot(path); err != nil {
+			return "", err
+		}
+	}
+
 	return path, nil
 }
 
 // AddProject adds a new project or updates existing one
 func (a *App) AddProject(name, path string) (*db.Project, error) {
+	if err := a.openRoot("AddProject", path); err != nil {
+		return nil, err
+	}
 	return a.projects.AddProject(name, path)
 }
 
+// openRoot grants access to a project or workspace root opened before and checks that path is accessible.
+// New projects become accessible when they are picked in the folder dialog.
+func (a *App) openRoot(op, path string) error {
+	if a.projects.HasProject(path) || a.workspaces.HasRoot(path) {
+		if err := a.access.AddRoot(path); err != nil {
+			return err
+		}
+	}
+	return a.access.Check(op, path)
+}
+
+// closeRoot revokes access to a removed workspace root, unless a project or another workspace still uses it
+func (a *App) closeRoot(path string) {
+	if !a.projects.HasProject(path) && !a.workspaces.HasRoot(path) {
+		a.access.RemoveRoot(path)
+	}
+}
+
 // GetProjectFiles returns the file tree for a project
 func (a *App) GetProjectFiles(projectPath string) (*service.FileNode, error) {
-	return a.files.GetProjectFiles(projectPath)
+	if err := a.openRoot("GetProjectFiles", projectPath); err != nil {
+		return nil, err
+	}
+
+	root, err := a.files.GetProjectFiles(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// A missing watcher only means the tree won't refresh on its own
+	if err := a.watcher.WatchProject(projectPath); err != nil {
+		log.Printf("[App] Failed to watch project %s: %v", projectPath, err)
+	}
+
+	return root, nil
+}
+
+// ListWorkspaces returns every workspace, most recently opened first
+func (a *App) ListWorkspaces() ([]service.Workspace, error) {
+	return a.workspaces.ListWorkspaces()
+}
+
+// CreateWorkspace creates a workspace from a list of folders
+func (a *App) CreateWorkspace(name string, paths []string) (*service.Workspace, error) {
+	for _, path := range paths {
+		if err := a.openRoot("CreateWorkspace", path); err != nil {
+			return nil, err
+		}
+	}
+	return a.workspaces.CreateWorkspace(name, paths)
+}
+
+// AddWorkspaceRoot adds a folder to a workspace, an empty name defaults to the folder name
+func (a *App) AddWorkspaceRoot(id int64, path, name string) (*service.Workspace, error) {
+	if err := a.openRoot("AddWorkspaceRoot", path); err != nil {
+		return nil, err
+	}
+	return a.workspaces.AddWorkspaceRoot(id, path, name)
+}
+
+// RemoveWorkspaceRoot removes a folder from a workspace
+func (a *App) RemoveWorkspaceRoot(id int64, path string) (*service.Workspace, error) {
+	ws, err := a.workspaces.RemoveWorkspaceRoot(id, path)
+	if err != nil {
+		return nil, err
+	}
+	a.closeRoot(path)
+	return ws, nil
+}
+
+// RenameWorkspace changes the name of a workspace
+func (a *App) RenameWorkspace(id int64, name string) error {
+	return a.workspaces.RenameWorkspace(id, name)
+}
+
+// DeleteWorkspace deletes a workspace without touching its folders
+func (a *App) DeleteWorkspace(id int64) error {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return err
+	}
+	if err := a.workspaces.DeleteWorkspace(id); err != nil {
+		return err
+	}
+	for _, root := range ws.Roots {
+		a.closeRoot(root.Path)
+	}
+	return nil
+}
+
+// OpenWorkspace marks a workspace as opened and grants access to its roots
+func (a *App) OpenWorkspace(id int64) (*service.Workspace, error) {
+	ws, err := a.workspaces.OpenWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("OpenWorkspace", ws); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// GetWorkspaceFiles returns the merged file tree of every root of a workspace
+func (a *App) GetWorkspaceFiles(id int64) (*service.FileNode, error) {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("GetWorkspaceFiles", ws); err != nil {
+		return nil, err
+	}
+
+	root, err := a.files.GetWorkspaceFiles(ws.Name, ws.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range ws.Roots {
+		if err := a.watcher.WatchProject(r.Path); err != nil {
+			log.Printf("[App] Failed to watch workspace root %s: %v", r.Path, err)
+		}
+	}
+
+	return root, nil
+}
+
+// openWorkspaceRoots grants access to every root of a workspace
+func (a *App) openWorkspaceRoots(op string, ws *service.Workspace) error {
+	for _, root := range ws.Roots {
+		if err := a.openRoot(op, root.Path); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // LoadDirectoryContents loads the contents of a specific directory
 func (a *App) LoadDirectoryContents(dirPath string) (*service.FileNode, error) {
-	return a.files.LoadDirectoryContents(dirPath)
+	if err := a.access.Check("LoadDirectoryContents", dirPath); err != nil {
+		return nil, err
+	}
+
+	node, err := a.files.LoadDirectoryContents(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.watcher.WatchDirectory(dirPath); err != nil {
+		log.Printf("[App] Failed to watch directory %s: %v", dirPath, err)
+	}
+
+	return node, nil
 }
 
-// GetFileContent returns the content of a file
-func (a *App) GetFileContent(path string) (string, error) {
-	return a.files.GetFileContent(path)
+// ListDirectory returns a page of the entries of a directory, for directories too big to load at once
+func (a *App) ListDirectory(dirPath string, opts service.DirectoryListOptions) (*service.DirectoryPage, error) {
+	if err := a.access.Check("ListDirectory", dirPath); err != nil {
+		return nil, err
+	}
+
+	page, err := a.files.ListDirectory(dirPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.watcher.WatchDirectory(dirPath); err != nil {
+		log.Printf("[App] Failed to watch directory %s: %v", dirPath, err)
+	}
+
+	return page, nil
 }
 
-// SaveFile saves content to a file
-func (a *App) SaveFile(path, content string) error {
-	return a.files.SaveFile(path, content)
+// GetFileContent returns the content of a file, its version and its format
+func (a *App) GetFileContent(path string) (*service.FileContent, error) {
+	if err := a.access.Check("GetFileContent", path); err != nil {
+		return nil, err
+	}
+
+	content, err := a.files.GetFileContent(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Opened files rank higher in the file search
+	a.files.RecordFileOpen(path)
+	return content, nil
+}
+
+// SaveFile saves content to a file if it is still at the given version, returning the new version
+func (a *App) SaveFile(path, content string, opts service.SaveOptions) (string, error) {
+	if err := a.access.Check("SaveFile", path); err != nil {
+		return "", err
+	}
+	return a.files.SaveFile(path, content, opts)
+}
+
+// OpenLargeFile opens a file in large file mode and starts indexing its lines
+func (a *App) OpenLargeFile(path string) (*service.LargeFileInfo, error) {
+	if err := a.access.Check("OpenLargeFile", path); err != nil {
+		return nil, err
+	}
+	return a.files.OpenLargeFile(path)
+}
+
+// GetLargeFileInfo returns the indexing progress of a large file
+func (a *App) GetLargeFileInfo(path string) (*service.LargeFileInfo, error) {
+	if err := a.access.Check("GetLargeFileInfo", path); err != nil {
+		return nil, err
+	}
+	return a.files.GetLargeFileInfo(path)
+}
+
+// ReadLines returns a range of lines of a large file
+func (a *App) ReadLines(path string, start int, count int) (*service.LineRange, error) {
+	if err := a.access.Check("ReadLines", path); err != nil {
+		return nil, err
+	}
+	return a.files.ReadLines(path, start, count)
+}
+
+// ReadBytes returns a window of raw bytes of a large file
+func (a *App) ReadBytes(path string, offset int64, length int) (*service.ByteWindow, error) {
+	if err := a.access.Check("ReadBytes", path); err != nil {
+		return nil, err
+	}
+	return a.files.ReadBytes(path, offset, length)
+}
+
+// TailLargeFile follows a growing large file, emitting "largefile:changed" events
+func (a *App) TailLargeFile(path string) error {
+	if err := a.access.Check("TailLargeFile", path); err != nil {
+		return err
+	}
+	return a.files.TailLargeFile(path, func(info service.LargeFileInfo) {
+		runtime.EventsEmit(a.ctx, "largefile:changed", info)
+	})
+}
+
+// CloseLargeFile stops indexing and tailing a large file
+func (a *App) CloseLargeFile(path string) {
+	a.files.CloseLargeFile(path)
 }
 
 // SearchFiles performs a fuzzy search on files in a directory
 func (a *App) SearchFiles(dirPath, query string) ([]*service.FileNode, error) {
+	if err := a.access.Check("SearchFiles", dirPath); err != nil {
+		return nil, err
+	}
+
 	// Create a new context that will be cancelled when a new search starts
-	ctx, cancel := context.WithCancel(a.ctx)
+	ctx, cancel := a.startSearch(&a.fileSearchCancel)
 	defer cancel()
 
 	return a.files.SearchFiles(ctx, dirPath, query)
 }
 
+// SearchContent searches the contents of the files in a directory.
+// Matches are streamed through "search:results" events while the search runs.
+func (a *App) SearchContent(dirPath string, opts service.SearchOptions) (*service.SearchSummary, error) {
+	if err := a.access.Check("SearchContent", dirPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.contentSearchCancel)
+	defer cancel()
+
+	return a.files.SearchContent(ctx, dirPath, opts, func(batch service.SearchBatch) {
+		runtime.EventsEmit(a.ctx, "search:results", batch)
+	})
+}
+
+// FindFiles returns a page of the fuzzy file search over the persistent index of a directory
+func (a *App) FindFiles(dirPath string, opts service.FileSearchOptions) (*service.FileSearchPage, error) {
+	if err := a.access.Check("FindFiles", dirPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.fileSearchCancel)
+	defer cancel()
+
+	return a.files.FindFiles(ctx, []service.WorkspaceRoot{{Path: dirPath}}, opts)
+}
+
+// FindWorkspaceFiles returns a page of the fuzzy file search over every root of a workspace
+func (a *App) FindWorkspaceFiles(id int64, opts service.FileSearchOptions) (*service.FileSearchPage, error) {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("FindWorkspaceFiles", ws); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.fileSearchCancel)
+	defer cancel()
+
+	return a.files.FindFiles(ctx, ws.Roots, opts)
+}
+
+// SearchWorkspaceFiles performs a fuzzy search on the files of every root of a workspace
+func (a *App) SearchWorkspaceFiles(id int64, query string) ([]*service.FileNode, error) {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("SearchWorkspaceFiles", ws); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.fileSearchCancel)
+	defer cancel()
+
+	return a.files.SearchWorkspaceFiles(ctx, ws.Roots, query)
+}
+
+// SearchWorkspaceContent searches the contents of the files in every root of a workspace.
+// Matches are streamed through "search:results" events while the search runs.
+func (a *App) SearchWorkspaceContent(id int64, opts service.SearchOptions) (*service.SearchSummary, error) {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("SearchWorkspaceContent", ws); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.contentSearchCancel)
+	defer cancel()
+
+	return a.files.SearchWorkspaceContent(ctx, ws.Roots, opts, func(batch service.SearchBatch) {
+		runtime.EventsEmit(a.ctx, "search:results", batch)
+	})
+}
+
+// CancelContentSearch stops the running content search, if any
+func (a *App) CancelContentSearch() {
+	a.searchLock.Lock()
+	defer a.searchLock.Unlock()
+
+	if a.contentSearchCancel != nil {
+		a.contentSearchCancel()
+		a.contentSearchCancel = nil
+	}
+}
+
+// startSearch cancels the previous search stored in slot and returns the context of the new one
+func (a *App) startSearch(slot *context.CancelFunc) (context.Context, context.CancelFunc) {
+	a.searchLock.Lock()
+	defer a.searchLock.Unlock()
+
+	if *slot != nil {
+		(*slot)()
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	*slot = cancel
+	return ctx, cancel
+}
+
+// PreviewReplace computes the substitutions of a project-wide search and replace
+func (a *App) PreviewReplace(dirPath string, opts service.ReplaceOptions) (*service.ReplacePreview, error) {
+	if err := a.access.Check("PreviewReplace", dirPath); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := a.startSearch(&a.contentSearchCancel)
+	defer cancel()
+
+	return a.files.PreviewReplace(ctx, dirPath, opts)
+}
+
+// ApplyReplace applies a previewed search and replace to the selected files as one batch
+func (a *App) ApplyReplace(opts service.ReplaceOptions, files []service.ReplaceFileSelection) (*service.ReplaceResult, error) {
+	for _, file := range files {
+		if err := a.access.Check("ApplyReplace", file.Path); err != nil {
+			return nil, err
+		}
+	}
+	return a.files.ApplyReplace(opts, files)
+}
+
 // Greet returns a greeting for the given name
 func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's show time!", name)
@@ -132,29 +549,238 @@ func (a *App) OpenConfigFile() (string, error) {
 
 // CreateFile creates a new empty file
 func (a *App) CreateFile(path string) error {
+	if err := a.access.Check("CreateFile", path); err != nil {
+		return err
+	}
 	return a.files.CreateFile(path)
 }
 
 // CreateDirectory creates a new directory
 func (a *App) CreateDirectory(path string) error {
+	if err := a.access.Check("CreateDirectory", path); err != nil {
+		return err
+	}
 	return a.files.CreateDirectory(path)
 }
 
 // RenameFile renames a file or directory
 func (a *App) RenameFile(oldPath, newPath string) error {
+	if err := a.access.Check("RenameFile", oldPath, newPath); err != nil {
+		return err
+	}
 	return a.files.RenameFile(oldPath, newPath)
 }
 
-// DeleteFile deletes a file or directory
+// DeleteFile moves a file or directory to the trash
 func (a *App) DeleteFile(path string) error {
+	if err := a.access.Check("DeleteFile", path); err != nil {
+		return err
+	}
 	return a.files.DeleteFile(path)
 }
 
+// ListFileHistory returns the local history snapshots of a file
+func (a *App) ListFileHistory(path string) ([]service.FileSnapshot, error) {
+	if err := a.access.Check("ListFileHistory", path); err != nil {
+		return nil, err
+	}
+	return a.files.ListFileHistory(path)
+}
+
+// GetSnapshotContent returns the content of a local history snapshot
+func (a *App) GetSnapshotContent(id int64) (*service.FileContent, error) {
+	snapshot, err := a.files.GetFileSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.access.Check("GetSnapshotContent", snapshot.Path); err != nil {
+		return nil, err
+	}
+
+	return a.files.GetSnapshotContent(id)
+}
+
+// DiffSnapshot compares a local history snapshot with the current file content
+func (a *App) DiffSnapshot(id int64, opts service.DiffOptions) (*service.FileDiff, error) {
+	snapshot, err := a.files.GetFileSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.access.Check("DiffSnapshot", snapshot.Path); err != nil {
+		return nil, err
+	}
+
+	return a.files.DiffSnapshot(id, opts)
+}
+
+// RestoreSnapshot restores a file from a local history snapshot and returns the new file version
+func (a *App) RestoreSnapshot(id int64) (string, error) {
+	snapshot, err := a.files.GetFileSnapshot(id)
+	if err != nil {
+		return "", err
+	}
+	if err := a.access.Check("RestoreSnapshot", snapshot.Path); err != nil {
+		return "", err
+	}
+
+	return a.files.RestoreSnapshot(id)
+}
+
+// DiffFiles compares two files on disk
+func (a *App) DiffFiles(oldPath, newPath string, opts service.DiffOptions) (*service.FileDiff, error) {
+	if err := a.access.Check("DiffFiles", oldPath, newPath); err != nil {
+		return nil, err
+	}
+	return a.diff.DiffFiles(oldPath, newPath, opts)
+}
+
+// DiffBuffer compares the saved copy of a file with the unsaved content of its editor buffer
+func (a *App) DiffBuffer(path, content string, opts service.DiffOptions) (*service.FileDiff, error) {
+	if err := a.access.Check("DiffBuffer", path); err != nil {
+		return nil, err
+	}
+	return a.diff.DiffBuffer(path, content, opts)
+}
+
+// DiffDirectories lists the files added, removed and changed between two directories
+func (a *App) DiffDirectories(oldDir, newDir string, opts service.DiffOptions) (*service.DirectoryDiff, error) {
+	if err := a.access.Check("DiffDirectories", oldDir, newDir); err != nil {
+		return nil, err
+	}
+	return a.diff.DiffDirectories(oldDir, newDir, opts)
+}
+
+// CopyFiles copies files and directories into a directory and emits progress events
+func (a *App) CopyFiles(opts service.TransferOptions) (*service.TransferResult, error) {
+	if err := a.access.Check("CopyFiles", append([]string{opts.Destination}, opts.Sources...)...); err != nil {
+		return nil, err
+	}
+	return a.files.CopyFiles(opts, a.emitTransferProgress)
+}
+
+// MoveFiles moves files and directories into a directory and emits progress events
+func (a *App) MoveFiles(opts service.TransferOptions) (*service.TransferResult, error) {
+	if err := a.access.Check("MoveFiles", append([]string{opts.Destination}, opts.Sources...)...); err != nil {
+		return nil, err
+	}
+	return a.files.MoveFiles(opts, a.emitTransferProgress)
+}
+
+// DuplicateFile copies a file or directory next to itself and returns the new path
+func (a *App) DuplicateFile(path string) (string, error) {
+	if err := a.access.Check("DuplicateFile", path); err != nil {
+		return "", err
+	}
+	return a.files.DuplicateFile(path)
+}
+
+// emitTransferProgress sends the progress of a copy or move to the frontend
+func (a *App) emitTransferProgress(progress service.TransferProgress) {
+	runtime.EventsEmit(a.ctx, "files:progress", progress)
+}
+
+// UndoFileOperation reverts the last create, rename or delete in a project
+func (a *App) UndoFileOperation(projectPath string) (*service.FileOperation, error) {
+	if err := a.access.Check("UndoFileOperation", projectPath); err != nil {
+		return nil, err
+	}
+	return a.files.UndoFileOperation(projectPath)
+}
+
+// RedoFileOperation applies the last undone file operation in a project again
+func (a *App) RedoFileOperation(projectPath string) (*service.FileOperation, error) {
+	if err := a.access.Check("RedoFileOperation", projectPath); err != nil {
+		return nil, err
+	}
+	return a.files.RedoFileOperation(projectPath)
+}
+
+// ListFileOperations returns the file operation history of a project
+func (a *App) ListFileOperations(projectPath string) ([]service.FileOperation, error) {
+	if err := a.access.Check("ListFileOperations", projectPath); err != nil {
+		return nil, err
+	}
+	return a.files.ListFileOperations(projectPath)
+}
+
+// ListTrash returns the deleted files of a project that can be restored
+func (a *App) ListTrash(projectPath string) ([]service.TrashItem, error) {
+	if err := a.access.Check("ListTrash", projectPath); err != nil {
+		return nil, err
+	}
+	return a.files.ListTrash(projectPath)
+}
+
+// RestoreTrash restores a deleted file to its original path
+func (a *App) RestoreTrash(id int64) error {
+	item, err := a.files.GetTrashItem(id)
+	if err != nil {
+		return err
+	}
+	if err := a.access.Check("RestoreTrash", item.OriginalPath); err != nil {
+		return err
+	}
+
+	return a.files.RestoreTrash(id)
+}
+
+// PurgeTrash permanently deletes files from the trash
+func (a *App) PurgeTrash(ids []int64) error {
+	// Every item is checked before anything is purged
+	for _, id := range ids {
+		item, err := a.files.GetTrashItem(id)
+		if err != nil {
+			return err
+		}
+		if err := a.access.Check("PurgeTrash", item.OriginalPath); err != nil {
+			return err
+		}
+	}
+
+	return a.files.PurgeTrash(ids)
+}
+
+// EmptyTrash permanently deletes every file in the trash of a project
+func (a *App) EmptyTrash(projectPath string) error {
+	if err := a.access.Check("EmptyTrash", projectPath); err != nil {
+		return err
+	}
+	return a.files.EmptyTrash(projectPath)
+}
+
 // CreateTerminal creates a new terminal instance
 func (a *App) CreateTerminal(id string, shell string, cwd string) error {
+	if cwd != "" {
+		if err := a.access.Check("CreateTerminal", cwd); err != nil {
+			return err
+		}
+	}
+
 	return a.terminalService.CreateTerminal(id, shell, cwd)
 }
 
+// CreateWorkspaceTerminal creates a terminal in the workspace root containing activePath,
+// or in the first root if no path is active. It returns the directory the terminal was started in.
+func (a *App) CreateWorkspaceTerminal(id string, shell string, workspaceID int64, activePath string) (string, error) {
+	ws, err := a.workspaces.GetWorkspace(workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	cwd := ws.RootFor(activePath)
+	if cwd == "" {
+		return "", fmt.Errorf("workspace has no folders: %s", ws.Name)
+	}
+	if err := a.openRoot("CreateWorkspaceTerminal", cwd); err != nil {
+		return "", err
+	}
+
+	if err := a.terminalService.CreateTerminal(id, shell, cwd); err != nil {
+		return "", err
+	}
+	return cwd, nil
+}
+
 // DestroyTerminal destroys a terminal instance
 func (a *App) DestroyTerminal(id string) error {
 	return a.terminalService.DestroyTerminal(id)
@@ -177,80 +803,409 @@ func (a *App) GetAvailableShells() ([]string, error) {
 
 // IsGitRepository checks if the given directory is a Git repository
 func (a *App) IsGitRepository(projectPath string) (bool, error) {
+	if err := a.access.Check("IsGitRepository", projectPath); err != nil {
+		return false, err
+	}
 	return a.git.IsGitRepository(projectPath)
 }
 
 // InitGitRepository initializes a new Git repository in the given directory
 func (a *App) InitGitRepository(projectPath string) error {
+	if err := a.access.Check("InitGitRepository", projectPath); err != nil {
+		return err
+	}
 	return a.git.InitRepository(projectPath)
 }
 
 // GetGitStatus returns the current Git status of the repository
 func (a *App) GetGitStatus(projectPath string) ([]service.FileStatus, error) {
+	if err := a.access.Check("GetGitStatus", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.GetStatus(projectPath)
 }
 
+// GetWorkspaceGitStatus returns the Git status of every root of a workspace
+func (a *App) GetWorkspaceGitStatus(id int64) ([]service.RootStatus, error) {
+	ws, err := a.workspaces.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.openWorkspaceRoots("GetWorkspaceGitStatus", ws); err != nil {
+		return nil, err
+	}
+
+	roots := make([]string, 0, len(ws.Roots))
+	for _, root := range ws.Roots {
+		roots = append(roots, root.Path)
+	}
+	return a.git.GetRootsStatus(roots)
+}
+
 // StageFile adds a file to the staging area
 func (a *App) StageFile(projectPath string, file string) error {
+	if err := a.access.Check("StageFile", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
 	return a.git.StageFile(projectPath, file)
 }
 
-// UnstageFile removes a file from the staging area
+// UnstageFile removes the staged changes of a file, keeping its working copy
 func (a *App) UnstageFile(projectPath string, file string) error {
+	if err := a.access.Check("UnstageFile", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
 	return a.git.UnstageFile(projectPath, file)
 }
 
 // DiscardChanges discards changes in a file, reverting it to the last commit
 func (a *App) DiscardChanges(projectPath string, file string) error {
+	if err := a.access.Check("DiscardChanges", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
 	return a.git.DiscardChanges(projectPath, file)
 }
 
-// Commit creates a new commit with the staged changes
-func (a *App) Commit(projectPath string, message string) error {
+// StageLines adds the selected hunks or lines of the unstaged diff of a file to the staging area
+func (a *App) StageLines(projectPath string, file string, sel service.DiffSelection) error {
+	if err := a.access.Check("StageLines", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
+	return a.git.StageLines(projectPath, file, sel)
+}
+
+// UnstageLines removes the selected hunks or lines of the staged diff of a file from the staging area
+func (a *App) UnstageLines(projectPath string, file string, sel service.DiffSelection) error {
+	if err := a.access.Check("UnstageLines", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
+	return a.git.UnstageLines(projectPath, file, sel)
+}
+
+// DiscardLines reverts the selected hunks or lines of the unstaged diff of a file
+func (a *App) DiscardLines(projectPath string, file string, sel service.DiffSelection) error {
+	if err := a.access.Check("DiscardLines", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
+	return a.git.DiscardLines(projectPath, file, sel)
+}
+
+// Commit creates a new commit with the staged changes and returns it
+func (a *App) Commit(projectPath string, message string) (*service.CommitInfo, error) {
+	if err := a.access.Check("Commit", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.Commit(projectPath, message)
 }
 
+// CommitWithOptions creates a commit, optionally amending, signing off, signing or overriding the author
+func (a *App) CommitWithOptions(projectPath string, message string, opts service.CommitOptions) (*service.CommitInfo, error) {
+	if err := a.access.Check("CommitWithOptions", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.CommitWithOptions(projectPath, message, opts)
+}
+
 // ListBranches returns a list of all branches in the repository
 func (a *App) ListBranches(projectPath string) ([]service.BranchInfo, error) {
+	if err := a.access.Check("ListBranches", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.ListBranches(projectPath)
 }
 
 // GetCurrentBranch returns the name of the current branch
 func (a *App) GetCurrentBranch(projectPath string) (string, error) {
+	if err := a.access.Check("GetCurrentBranch", projectPath); err != nil {
+		return "", err
+	}
 	return a.git.GetCurrentBranch(projectPath)
 }
 
+// CreateBranch creates a branch at a commit, branch or tag, HEAD if startPoint is empty
+func (a *App) CreateBranch(projectPath string, name string, startPoint string) (*service.BranchInfo, error) {
+	if err := a.access.Check("CreateBranch", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.CreateBranch(projectPath, name, startPoint)
+}
+
+// CheckoutBranch switches to a local or remote branch, refusing, stashing or discarding local changes
+func (a *App) CheckoutBranch(projectPath string, name string, opts service.CheckoutOptions) error {
+	if err := a.access.Check("CheckoutBranch", projectPath); err != nil {
+		return err
+	}
+	return a.git.CheckoutBranch(projectPath, name, opts)
+}
+
+// RenameBranch renames a local branch
+func (a *App) RenameBranch(projectPath string, oldName string, newName string) error {
+	if err := a.access.Check("RenameBranch", projectPath); err != nil {
+		return err
+	}
+	return a.git.RenameBranch(projectPath, oldName, newName)
+}
+
+// DeleteBranch deletes a local branch, force also deletes unmerged work
+func (a *App) DeleteBranch(projectPath string, name string, force bool) error {
+	if err := a.access.Check("DeleteBranch", projectPath); err != nil {
+		return err
+	}
+	return a.git.DeleteBranch(projectPath, name, force)
+}
+
+// SetUpstream sets or removes the branch a local branch tracks
+func (a *App) SetUpstream(projectPath string, branch string, upstream string) error {
+	if err := a.access.Check("SetUpstream", projectPath); err != nil {
+		return err
+	}
+	return a.git.SetUpstream(projectPath, branch, upstream)
+}
+
+// PushStash stashes local changes, optionally with untracked files or only the staged changes
+func (a *App) PushStash(projectPath string, opts service.StashOptions) (*service.StashInfo, error) {
+	if err := a.access.Check("PushStash", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.PushStash(projectPath, opts)
+}
+
+// ListStashes returns the stashes of a repository with their messages and file stats, latest first
+func (a *App) ListStashes(projectPath string) ([]service.StashInfo, error) {
+	if err := a.access.Check("ListStashes", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.ListStashes(projectPath)
+}
+
+// GetStashDiff returns the diff of every file of stash@{index}
+func (a *App) GetStashDiff(projectPath string, index int, opts service.DiffOptions) ([]service.FileDiff, error) {
+	if err := a.access.Check("GetStashDiff", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.GetStashDiff(projectPath, index, opts)
+}
+
+// ApplyStash applies stash@{index} and keeps it, conflicts are listed in the result
+func (a *App) ApplyStash(projectPath string, index int) (*service.MergeResult, error) {
+	if err := a.access.Check("ApplyStash", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.ApplyStash(projectPath, index)
+}
+
+// PopStash applies stash@{index} and drops it unless it conflicts
+func (a *App) PopStash(projectPath string, index int) (*service.MergeResult, error) {
+	if err := a.access.Check("PopStash", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.PopStash(projectPath, index)
+}
+
+// DropStash deletes stash@{index}
+func (a *App) DropStash(projectPath string, index int) error {
+	if err := a.access.Check("DropStash", projectPath); err != nil {
+		return err
+	}
+	return a.git.DropStash(projectPath, index)
+}
+
+// MergeBranch merges a branch into the current one, reporting a fast-forward, a clean merge or conflicts
+func (a *App) MergeBranch(projectPath string, branch string, opts service.MergeOptions) (*service.MergeResult, error) {
+	if err := a.access.Check("MergeBranch", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.MergeBranch(projectPath, branch, opts)
+}
+
+// GetMergeState returns the merge, cherry-pick or revert in progress and the unresolved conflicts
+func (a *App) GetMergeState(projectPath string) (*service.MergeState, error) {
+	if err := a.access.Check("GetMergeState", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.GetMergeState(projectPath)
+}
+
+// CommitMerge finishes a merge once its conflicts are resolved
+func (a *App) CommitMerge(projectPath string, message string) (*service.CommitInfo, error) {
+	if err := a.access.Check("CommitMerge", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.CommitMerge(projectPath, message)
+}
+
+// AbortMerge cancels the merge in progress
+func (a *App) AbortMerge(projectPath string) error {
+	if err := a.access.Check("AbortMerge", projectPath); err != nil {
+		return err
+	}
+	return a.git.AbortMerge(projectPath)
+}
+
+// GetConflict returns the versions and conflict regions of a conflicted file
+func (a *App) GetConflict(projectPath string, file string) (*service.ConflictDetails, error) {
+	if err := a.access.Check("GetConflict", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return nil, err
+	}
+	return a.git.GetConflict(projectPath, file)
+}
+
+// ResolveConflictRegion resolves a conflict region of a file with ours, theirs, both or the base
+func (a *App) ResolveConflictRegion(projectPath string, file string, region int, side string) (*service.ConflictDetails, error) {
+	if err := a.access.Check("ResolveConflictRegion", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return nil, err
+	}
+	return a.git.ResolveConflictRegion(projectPath, file, region, side)
+}
+
+// MarkResolved stages the working copy of a conflicted file as resolved
+func (a *App) MarkResolved(projectPath string, file string) error {
+	if err := a.access.Check("MarkResolved", projectPath, filepath.Join(projectPath, file)); err != nil {
+		return err
+	}
+	return a.git.MarkResolved(projectPath, file)
+}
+
+// CherryPick applies commits onto the current branch, keeping their authors
+func (a *App) CherryPick(projectPath string, hashes []string, opts service.PickOptions) (*service.MergeResult, error) {
+	if err := a.access.Check("CherryPick", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.CherryPick(projectPath, hashes, opts)
+}
+
+// Revert creates commits undoing commits
+func (a *App) Revert(projectPath string, hashes []string, opts service.PickOptions) (*service.MergeResult, error) {
+	if err := a.access.Check("Revert", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.Revert(projectPath, hashes, opts)
+}
+
+// ContinuePick resumes a cherry-pick or revert once its conflicts are resolved
+func (a *App) ContinuePick(projectPath string) (*service.MergeResult, error) {
+	if err := a.access.Check("ContinuePick", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.ContinuePick(projectPath)
+}
+
+// AbortPick cancels a cherry-pick or revert stopped on conflicts
+func (a *App) AbortPick(projectPath string) error {
+	if err := a.access.Check("AbortPick", projectPath); err != nil {
+		return err
+	}
+	return a.git.AbortPick(projectPath)
+}
+
+// GetRebasePlan returns the default interactive rebase plan of the current branch onto upstream
+func (a *App) GetRebasePlan(projectPath string, upstream string) (*service.RebasePlan, error) {
+	if err := a.access.Check("GetRebasePlan", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.GetRebasePlan(projectPath, upstream)
+}
+
+// StartRebase runs an edited rebase plan until it finishes or pauses
+func (a *App) StartRebase(projectPath string, plan service.RebasePlan) (*service.RebaseState, error) {
+	if err := a.access.Check("StartRebase", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.StartRebase(projectPath, plan)
+}
+
+// ContinueRebase resumes a rebase paused on conflicts or an edit step
+func (a *App) ContinueRebase(projectPath string) (*service.RebaseState, error) {
+	if err := a.access.Check("ContinueRebase", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.ContinueRebase(projectPath)
+}
+
+// SkipRebaseStep drops the step a rebase paused on and resumes it
+func (a *App) SkipRebaseStep(projectPath string) (*service.RebaseState, error) {
+	if err := a.access.Check("SkipRebaseStep", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.SkipRebaseStep(projectPath)
+}
+
+// AbortRebase cancels a rebase and restores the branch
+func (a *App) AbortRebase(projectPath string) error {
+	if err := a.access.Check("AbortRebase", projectPath); err != nil {
+		return err
+	}
+	return a.git.AbortRebase(projectPath)
+}
+
+// GetRebaseState returns the rebase in progress, for instance after a restart
+func (a *App) GetRebaseState(projectPath string) (*service.RebaseState, error) {
+	if err := a.access.Check("GetRebaseState", projectPath); err != nil {
+		return nil, err
+	}
+	return a.git.GetRebaseState(projectPath)
+}
+
 // ListCommits returns a list of commits based on the provided filters
 func (a *App) ListCommits(projectPath string, filter service.CommitFilter) ([]service.CommitInfo, error) {
+	if err := a.access.Check("ListCommits", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.ListCommits(projectPath, filter)
 }
 
 // ListCommitsAfter returns commits after a specific commit hash
 func (a *App) ListCommitsAfter(projectPath string, offsetHash string, limit int) ([]service.CommitInfo, error) {
+	if err := a.access.Check("ListCommitsAfter", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.ListCommitsAfter(projectPath, offsetHash, limit)
 }
 
 // ListCommitsByBranch returns commits from a specific branch
 func (a *App) ListCommitsByBranch(projectPath string, branch string, limit int) ([]service.CommitInfo, error) {
+	if err := a.access.Check("ListCommitsByBranch", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.ListCommitsByBranch(projectPath, branch, limit)
 }
 
 // ListCommitsByAuthor returns commits by a specific author
 func (a *App) ListCommitsByAuthor(projectPath string, author string, limit int) ([]service.CommitInfo, error) {
+	if err := a.access.Check("ListCommitsByAuthor", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.ListCommitsByAuthor(projectPath, author, limit)
 }
 
 // SearchCommits searches for commits by message
 func (a *App) SearchCommits(projectPath string, query string, limit int) ([]service.CommitInfo, error) {
+	if err := a.access.Check("SearchCommits", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.SearchCommits(projectPath, query, limit)
 }
 
 // GetHeadCommit returns the head commit of the repository
 func (a *App) GetHeadCommit(projectPath string) (*service.CommitInfo, error) {
+	if err := a.access.Check("GetHeadCommit", projectPath); err != nil {
+		return nil, err
+	}
 	return a.git.GetHeadCommit(projectPath)
 }
 
 // GetFileDiff returns the diff for a specific file
 func (a *App) GetFileDiff(projectPath string, filePath string, staged bool) (*service.FileDiff, error) {
+	if err := a.access.Check("GetFileDiff", projectPath, filepath.Join(projectPath, filePath)); err != nil {
+		return nil, err
+	}
 	return a.git.GetFileDiff(projectPath, filePath, staged)
 }
+
+// GetFileDiffWithOptions returns the diff for a specific file with a custom context and whitespace mode
+func (a *App) GetFileDiffWithOptions(projectPath string, filePath string, staged bool, opts service.DiffOptions) (*service.FileDiff, error) {
+	if err := a.access.Check("GetFileDiffWithOptions", projectPath, filepath.Join(projectPath, filePath)); err != nil {
+		return nil, err
+	}
+	return a.git.GetFileDiffWithOptions(projectPath, filePath, staged, opts)
+}
@@ -0,0 +1,17 @@
+-- migrate:up
+
+CREATE TABLE trash_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_path TEXT NOT NULL,
+    original_path TEXT NOT NULL,
+    trash_path TEXT NOT NULL UNIQUE,
+    is_dir BOOLEAN NOT NULL DEFAULT 0,
+    size INTEGER NOT NULL DEFAULT 0,
+    deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_trash_items_project_path ON trash_items(project_path);
+
+-- migrate:down
+
+DROP TABLE trash_items;
\ No newline at end of file
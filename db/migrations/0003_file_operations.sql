@@ -0,0 +1,21 @@
+-- migrate:up
+
+CREATE TABLE file_operations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_path TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    path TEXT NOT NULL,
+    target_path TEXT NOT NULL DEFAULT '',
+    is_dir BOOLEAN NOT NULL DEFAULT 0,
+    trash_item_id INTEGER,
+    overwrite BOOLEAN NOT NULL DEFAULT 0,
+    replaced_trash_item_id INTEGER,
+    undone BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_file_operations_project_path ON file_operations(project_path);
+
+-- migrate:down
+
+DROP TABLE file_operations;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- migrate:up
+
+CREATE TABLE file_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_path TEXT NOT NULL,
+    path TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    size INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_file_snapshots_project_path ON file_snapshots(project_path, path);
+CREATE INDEX idx_file_snapshots_path ON file_snapshots(path);
+CREATE INDEX idx_file_snapshots_hash ON file_snapshots(hash);
+
+-- migrate:down
+
+DROP TABLE file_snapshots;
\ No newline at end of file
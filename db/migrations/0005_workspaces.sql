@@ -0,0 +1,25 @@
+-- migrate:up
+
+CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    last_opened DATETIME DEFAULT CURRENT_TIMESTAMP,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE workspace_roots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(workspace_id, path)
+);
+
+CREATE INDEX idx_workspace_roots_path ON workspace_roots(path);
+
+-- migrate:down
+
+DROP TABLE workspace_roots;
+DROP TABLE workspaces;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- migrate:up
+
+CREATE TABLE file_opens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL UNIQUE,
+    open_count INTEGER NOT NULL DEFAULT 1,
+    last_opened DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_file_opens_last_opened ON file_opens(last_opened);
+
+-- migrate:down
+
+DROP TABLE file_opens;
\ No newline at end of file
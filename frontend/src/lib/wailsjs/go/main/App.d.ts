@@ -3,9 +3,41 @@
 import {db} from '../models';
 import {service} from '../models';
 
+export function AbortMerge(arg1:string):Promise<void>;
+
+export function AbortPick(arg1:string):Promise<void>;
+
+export function AbortRebase(arg1:string):Promise<void>;
+
 export function AddProject(arg1:string,arg2:string):Promise<db.Project>;
 
-export function Commit(arg1:string,arg2:string):Promise<void>;
+export function AddWorkspaceRoot(arg1:number,arg2:string,arg3:string):Promise<service.Workspace>;
+
+export function ApplyReplace(arg1:service.ReplaceOptions,arg2:Array<service.ReplaceFileSelection>):Promise<service.ReplaceResult>;
+
+export function ApplyStash(arg1:string,arg2:number):Promise<service.MergeResult>;
+
+export function CancelContentSearch():Promise<void>;
+
+export function CheckoutBranch(arg1:string,arg2:string,arg3:service.CheckoutOptions):Promise<void>;
+
+export function CherryPick(arg1:string,arg2:Array<string>,arg3:service.PickOptions):Promise<service.MergeResult>;
+
+export function CloseLargeFile(arg1:string):Promise<void>;
+
+export function Commit(arg1:string,arg2:string):Promise<service.CommitInfo>;
+
+export function CommitMerge(arg1:string,arg2:string):Promise<service.CommitInfo>;
+
+export function CommitWithOptions(arg1:string,arg2:string,arg3:service.CommitOptions):Promise<service.CommitInfo>;
+
+export function ContinuePick(arg1:string):Promise<service.MergeResult>;
+
+export function ContinueRebase(arg1:string):Promise<service.RebaseState>;
+
+export function CopyFiles(arg1:service.TransferOptions):Promise<service.TransferResult>;
+
+export function CreateBranch(arg1:string,arg2:string,arg3:string):Promise<service.BranchInfo>;
 
 export function CreateDirectory(arg1:string):Promise<void>;
 
@@ -13,30 +45,78 @@ export function CreateFile(arg1:string):Promise<void>;
 
 export function CreateTerminal(arg1:string,arg2:string,arg3:string):Promise<void>;
 
+export function CreateWorkspace(arg1:string,arg2:Array<string>):Promise<service.Workspace>;
+
+export function CreateWorkspaceTerminal(arg1:string,arg2:string,arg3:number,arg4:string):Promise<string>;
+
+export function DeleteBranch(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
 export function DeleteFile(arg1:string):Promise<void>;
 
+export function DeleteWorkspace(arg1:number):Promise<void>;
+
 export function DestroyTerminal(arg1:string):Promise<void>;
 
+export function DiffBuffer(arg1:string,arg2:string,arg3:service.DiffOptions):Promise<service.FileDiff>;
+
+export function DiffDirectories(arg1:string,arg2:string,arg3:service.DiffOptions):Promise<service.DirectoryDiff>;
+
+export function DiffFiles(arg1:string,arg2:string,arg3:service.DiffOptions):Promise<service.FileDiff>;
+
+export function DiffSnapshot(arg1:number,arg2:service.DiffOptions):Promise<service.FileDiff>;
+
 export function DiscardChanges(arg1:string,arg2:string):Promise<void>;
 
+export function DiscardLines(arg1:string,arg2:string,arg3:service.DiffSelection):Promise<void>;
+
+export function DropStash(arg1:string,arg2:number):Promise<void>;
+
+export function DuplicateFile(arg1:string):Promise<string>;
+
+export function EmptyTrash(arg1:string):Promise<void>;
+
+export function FindFiles(arg1:string,arg2:service.FileSearchOptions):Promise<service.FileSearchPage>;
+
+export function FindWorkspaceFiles(arg1:number,arg2:service.FileSearchOptions):Promise<service.FileSearchPage>;
+
 export function GetAvailableShells():Promise<Array<string>>;
 
+export function GetConflict(arg1:string,arg2:string):Promise<service.ConflictDetails>;
+
 export function GetCurrentBranch(arg1:string):Promise<string>;
 
 export function GetEditorConfig():Promise<service.EditorConfig>;
 
-export function GetFileContent(arg1:string):Promise<string>;
+export function GetFileContent(arg1:string):Promise<service.FileContent>;
 
 export function GetFileDiff(arg1:string,arg2:string,arg3:boolean):Promise<service.FileDiff>;
 
+export function GetFileDiffWithOptions(arg1:string,arg2:string,arg3:boolean,arg4:service.DiffOptions):Promise<service.FileDiff>;
+
 export function GetGitStatus(arg1:string):Promise<Array<service.FileStatus>>;
 
 export function GetHeadCommit(arg1:string):Promise<service.CommitInfo>;
 
+export function GetLargeFileInfo(arg1:string):Promise<service.LargeFileInfo>;
+
+export function GetMergeState(arg1:string):Promise<service.MergeState>;
+
 export function GetProjectFiles(arg1:string):Promise<service.FileNode>;
 
+export function GetRebasePlan(arg1:string,arg2:string):Promise<service.RebasePlan>;
+
+export function GetRebaseState(arg1:string):Promise<service.RebaseState>;
+
 export function GetRecentProjects():Promise<Array<db.Project>>;
 
+export function GetSnapshotContent(arg1:number):Promise<service.FileContent>;
+
+export function GetStashDiff(arg1:string,arg2:number,arg3:service.DiffOptions):Promise<Array<service.FileDiff>>;
+
+export function GetWorkspaceFiles(arg1:number):Promise<service.FileNode>;
+
+export function GetWorkspaceGitStatus(arg1:number):Promise<Array<service.RootStatus>>;
+
 export function Greet(arg1:string):Promise<string>;
 
 export function HandleInput(arg1:string,arg2:Array<number>):Promise<void>;
@@ -55,22 +135,92 @@ export function ListCommitsByAuthor(arg1:string,arg2:string,arg3:number):Promise
 
 export function ListCommitsByBranch(arg1:string,arg2:string,arg3:number):Promise<Array<service.CommitInfo>>;
 
+export function ListDirectory(arg1:string,arg2:service.DirectoryListOptions):Promise<service.DirectoryPage>;
+
+export function ListFileHistory(arg1:string):Promise<Array<service.FileSnapshot>>;
+
+export function ListFileOperations(arg1:string):Promise<Array<service.FileOperation>>;
+
+export function ListStashes(arg1:string):Promise<Array<service.StashInfo>>;
+
+export function ListTrash(arg1:string):Promise<Array<service.TrashItem>>;
+
+export function ListWorkspaces():Promise<Array<service.Workspace>>;
+
 export function LoadDirectoryContents(arg1:string):Promise<service.FileNode>;
 
+export function MarkResolved(arg1:string,arg2:string):Promise<void>;
+
+export function MergeBranch(arg1:string,arg2:string,arg3:service.MergeOptions):Promise<service.MergeResult>;
+
+export function MoveFiles(arg1:service.TransferOptions):Promise<service.TransferResult>;
+
 export function OpenConfigFile():Promise<string>;
 
+export function OpenLargeFile(arg1:string):Promise<service.LargeFileInfo>;
+
 export function OpenProjectFolder():Promise<string>;
 
+export function OpenWorkspace(arg1:number):Promise<service.Workspace>;
+
+export function PopStash(arg1:string,arg2:number):Promise<service.MergeResult>;
+
+export function PreviewReplace(arg1:string,arg2:service.ReplaceOptions):Promise<service.ReplacePreview>;
+
+export function PurgeTrash(arg1:Array<number>):Promise<void>;
+
+export function PushStash(arg1:string,arg2:service.StashOptions):Promise<service.StashInfo>;
+
+export function ReadBytes(arg1:string,arg2:number,arg3:number):Promise<service.ByteWindow>;
+
+export function ReadLines(arg1:string,arg2:number,arg3:number):Promise<service.LineRange>;
+
+export function RedoFileOperation(arg1:string):Promise<service.FileOperation>;
+
+export function RemoveWorkspaceRoot(arg1:number,arg2:string):Promise<service.Workspace>;
+
+export function RenameBranch(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function RenameFile(arg1:string,arg2:string):Promise<void>;
 
+export function RenameWorkspace(arg1:number,arg2:string):Promise<void>;
+
 export function ResizeTerminal(arg1:string,arg2:number,arg3:number):Promise<void>;
 
-export function SaveFile(arg1:string,arg2:string):Promise<void>;
+export function ResolveConflictRegion(arg1:string,arg2:string,arg3:number,arg4:string):Promise<service.ConflictDetails>;
+
+export function RestoreSnapshot(arg1:number):Promise<string>;
+
+export function RestoreTrash(arg1:number):Promise<void>;
+
+export function Revert(arg1:string,arg2:Array<string>,arg3:service.PickOptions):Promise<service.MergeResult>;
+
+export function SaveFile(arg1:string,arg2:string,arg3:service.SaveOptions):Promise<string>;
 
 export function SearchCommits(arg1:string,arg2:string,arg3:number):Promise<Array<service.CommitInfo>>;
 
+export function SearchContent(arg1:string,arg2:service.SearchOptions):Promise<service.SearchSummary>;
+
 export function SearchFiles(arg1:string,arg2:string):Promise<Array<service.FileNode>>;
 
+export function SearchWorkspaceContent(arg1:number,arg2:service.SearchOptions):Promise<service.SearchSummary>;
+
+export function SearchWorkspaceFiles(arg1:number,arg2:string):Promise<Array<service.FileNode>>;
+
+export function SetUpstream(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SkipRebaseStep(arg1:string):Promise<service.RebaseState>;
+
 export function StageFile(arg1:string,arg2:string):Promise<void>;
 
+export function StageLines(arg1:string,arg2:string,arg3:service.DiffSelection):Promise<void>;
+
+export function StartRebase(arg1:string,arg2:service.RebasePlan):Promise<service.RebaseState>;
+
+export function TailLargeFile(arg1:string):Promise<void>;
+
+export function UndoFileOperation(arg1:string):Promise<service.FileOperation>;
+
 export function UnstageFile(arg1:string,arg2:string):Promise<void>;
+
+export function UnstageLines(arg1:string,arg2:string,arg3:service.DiffSelection):Promise<void>;
@@ -2,14 +2,78 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AbortMerge(arg1) {
+  return window['go']['main']['App']['AbortMerge'](arg1);
+}
+
+export function AbortPick(arg1) {
+  return window['go']['main']['App']['AbortPick'](arg1);
+}
+
+export function AbortRebase(arg1) {
+  return window['go']['main']['App']['AbortRebase'](arg1);
+}
+
 export function AddProject(arg1, arg2) {
   return window['go']['main']['App']['AddProject'](arg1, arg2);
 }
 
+export function AddWorkspaceRoot(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddWorkspaceRoot'](arg1, arg2, arg3);
+}
+
+export function ApplyReplace(arg1, arg2) {
+  return window['go']['main']['App']['ApplyReplace'](arg1, arg2);
+}
+
+export function ApplyStash(arg1, arg2) {
+  return window['go']['main']['App']['ApplyStash'](arg1, arg2);
+}
+
+export function CancelContentSearch() {
+  return window['go']['main']['App']['CancelContentSearch']();
+}
+
+export function CheckoutBranch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CheckoutBranch'](arg1, arg2, arg3);
+}
+
+export function CherryPick(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CherryPick'](arg1, arg2, arg3);
+}
+
+export function CloseLargeFile(arg1) {
+  return window['go']['main']['App']['CloseLargeFile'](arg1);
+}
+
 export function Commit(arg1, arg2) {
   return window['go']['main']['App']['Commit'](arg1, arg2);
 }
 
+export function CommitMerge(arg1, arg2) {
+  return window['go']['main']['App']['CommitMerge'](arg1, arg2);
+}
+
+export function CommitWithOptions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CommitWithOptions'](arg1, arg2, arg3);
+}
+
+export function ContinuePick(arg1) {
+  return window['go']['main']['App']['ContinuePick'](arg1);
+}
+
+export function ContinueRebase(arg1) {
+  return window['go']['main']['App']['ContinueRebase'](arg1);
+}
+
+export function CopyFiles(arg1) {
+  return window['go']['main']['App']['CopyFiles'](arg1);
+}
+
+export function CreateBranch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateBranch'](arg1, arg2, arg3);
+}
+
 export function CreateDirectory(arg1) {
   return window['go']['main']['App']['CreateDirectory'](arg1);
 }
@@ -22,22 +86,82 @@ export function CreateTerminal(arg1, arg2, arg3) {
   return window['go']['main']['App']['CreateTerminal'](arg1, arg2, arg3);
 }
 
+export function CreateWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['CreateWorkspace'](arg1, arg2);
+}
+
+export function CreateWorkspaceTerminal(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CreateWorkspaceTerminal'](arg1, arg2, arg3, arg4);
+}
+
+export function DeleteBranch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DeleteBranch'](arg1, arg2, arg3);
+}
+
 export function DeleteFile(arg1) {
   return window['go']['main']['App']['DeleteFile'](arg1);
 }
 
+export function DeleteWorkspace(arg1) {
+  return window['go']['main']['App']['DeleteWorkspace'](arg1);
+}
+
 export function DestroyTerminal(arg1) {
   return window['go']['main']['App']['DestroyTerminal'](arg1);
 }
 
+export function DiffBuffer(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffBuffer'](arg1, arg2, arg3);
+}
+
+export function DiffDirectories(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffDirectories'](arg1, arg2, arg3);
+}
+
+export function DiffFiles(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffFiles'](arg1, arg2, arg3);
+}
+
+export function DiffSnapshot(arg1, arg2) {
+  return window['go']['main']['App']['DiffSnapshot'](arg1, arg2);
+}
+
 export function DiscardChanges(arg1, arg2) {
   return window['go']['main']['App']['DiscardChanges'](arg1, arg2);
 }
 
+export function DiscardLines(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiscardLines'](arg1, arg2, arg3);
+}
+
+export function DropStash(arg1, arg2) {
+  return window['go']['main']['App']['DropStash'](arg1, arg2);
+}
+
+export function DuplicateFile(arg1) {
+  return window['go']['main']['App']['DuplicateFile'](arg1);
+}
+
+export function EmptyTrash(arg1) {
+  return window['go']['main']['App']['EmptyTrash'](arg1);
+}
+
+export function FindFiles(arg1, arg2) {
+  return window['go']['main']['App']['FindFiles'](arg1, arg2);
+}
+
+export function FindWorkspaceFiles(arg1, arg2) {
+  return window['go']['main']['App']['FindWorkspaceFiles'](arg1, arg2);
+}
+
 export function GetAvailableShells() {
   return window['go']['main']['App']['GetAvailableShells']();
 }
 
+export function GetConflict(arg1, arg2) {
+  return window['go']['main']['App']['GetConflict'](arg1, arg2);
+}
+
 export function GetCurrentBranch(arg1) {
   return window['go']['main']['App']['GetCurrentBranch'](arg1);
 }
@@ -54,6 +178,10 @@ export function GetFileDiff(arg1, arg2, arg3) {
   return window['go']['main']['App']['GetFileDiff'](arg1, arg2, arg3);
 }
 
+export function GetFileDiffWithOptions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetFileDiffWithOptions'](arg1, arg2, arg3, arg4);
+}
+
 export function GetGitStatus(arg1) {
   return window['go']['main']['App']['GetGitStatus'](arg1);
 }
@@ -62,14 +190,46 @@ export function GetHeadCommit(arg1) {
   return window['go']['main']['App']['GetHeadCommit'](arg1);
 }
 
+export function GetLargeFileInfo(arg1) {
+  return window['go']['main']['App']['GetLargeFileInfo'](arg1);
+}
+
+export function GetMergeState(arg1) {
+  return window['go']['main']['App']['GetMergeState'](arg1);
+}
+
 export function GetProjectFiles(arg1) {
   return window['go']['main']['App']['GetProjectFiles'](arg1);
 }
 
+export function GetRebasePlan(arg1, arg2) {
+  return window['go']['main']['App']['GetRebasePlan'](arg1, arg2);
+}
+
+export function GetRebaseState(arg1) {
+  return window['go']['main']['App']['GetRebaseState'](arg1);
+}
+
 export function GetRecentProjects() {
   return window['go']['main']['App']['GetRecentProjects']();
 }
 
+export function GetSnapshotContent(arg1) {
+  return window['go']['main']['App']['GetSnapshotContent'](arg1);
+}
+
+export function GetStashDiff(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetStashDiff'](arg1, arg2, arg3);
+}
+
+export function GetWorkspaceFiles(arg1) {
+  return window['go']['main']['App']['GetWorkspaceFiles'](arg1);
+}
+
+export function GetWorkspaceGitStatus(arg1) {
+  return window['go']['main']['App']['GetWorkspaceGitStatus'](arg1);
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
@@ -106,42 +266,182 @@ export function ListCommitsByBranch(arg1, arg2, arg3) {
   return window['go']['main']['App']['ListCommitsByBranch'](arg1, arg2, arg3);
 }
 
+export function ListDirectory(arg1, arg2) {
+  return window['go']['main']['App']['ListDirectory'](arg1, arg2);
+}
+
+export function ListFileHistory(arg1) {
+  return window['go']['main']['App']['ListFileHistory'](arg1);
+}
+
+export function ListFileOperations(arg1) {
+  return window['go']['main']['App']['ListFileOperations'](arg1);
+}
+
+export function ListStashes(arg1) {
+  return window['go']['main']['App']['ListStashes'](arg1);
+}
+
+export function ListTrash(arg1) {
+  return window['go']['main']['App']['ListTrash'](arg1);
+}
+
+export function ListWorkspaces() {
+  return window['go']['main']['App']['ListWorkspaces']();
+}
+
 export function LoadDirectoryContents(arg1) {
   return window['go']['main']['App']['LoadDirectoryContents'](arg1);
 }
 
+export function MarkResolved(arg1, arg2) {
+  return window['go']['main']['App']['MarkResolved'](arg1, arg2);
+}
+
+export function MergeBranch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MergeBranch'](arg1, arg2, arg3);
+}
+
+export function MoveFiles(arg1) {
+  return window['go']['main']['App']['MoveFiles'](arg1);
+}
+
 export function OpenConfigFile() {
   return window['go']['main']['App']['OpenConfigFile']();
 }
 
+export function OpenLargeFile(arg1) {
+  return window['go']['main']['App']['OpenLargeFile'](arg1);
+}
+
 export function OpenProjectFolder() {
   return window['go']['main']['App']['OpenProjectFolder']();
 }
 
+export function OpenWorkspace(arg1) {
+  return window['go']['main']['App']['OpenWorkspace'](arg1);
+}
+
+export function PopStash(arg1, arg2) {
+  return window['go']['main']['App']['PopStash'](arg1, arg2);
+}
+
+export function PreviewReplace(arg1, arg2) {
+  return window['go']['main']['App']['PreviewReplace'](arg1, arg2);
+}
+
+export function PurgeTrash(arg1) {
+  return window['go']['main']['App']['PurgeTrash'](arg1);
+}
+
+export function PushStash(arg1, arg2) {
+  return window['go']['main']['App']['PushStash'](arg1, arg2);
+}
+
+export function ReadBytes(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ReadBytes'](arg1, arg2, arg3);
+}
+
+export function ReadLines(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ReadLines'](arg1, arg2, arg3);
+}
+
+export function RedoFileOperation(arg1) {
+  return window['go']['main']['App']['RedoFileOperation'](arg1);
+}
+
+export function RemoveWorkspaceRoot(arg1, arg2) {
+  return window['go']['main']['App']['RemoveWorkspaceRoot'](arg1, arg2);
+}
+
+export function RenameBranch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RenameBranch'](arg1, arg2, arg3);
+}
+
 export function RenameFile(arg1, arg2) {
   return window['go']['main']['App']['RenameFile'](arg1, arg2);
 }
 
+export function RenameWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['RenameWorkspace'](arg1, arg2);
+}
+
 export function ResizeTerminal(arg1, arg2, arg3) {
   return window['go']['main']['App']['ResizeTerminal'](arg1, arg2, arg3);
 }
 
-export function SaveFile(arg1, arg2) {
-  return window['go']['main']['App']['SaveFile'](arg1, arg2);
+export function ResolveConflictRegion(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ResolveConflictRegion'](arg1, arg2, arg3, arg4);
+}
+
+export function RestoreSnapshot(arg1) {
+  return window['go']['main']['App']['RestoreSnapshot'](arg1);
+}
+
+export function RestoreTrash(arg1) {
+  return window['go']['main']['App']['RestoreTrash'](arg1);
+}
+
+export function Revert(arg1, arg2, arg3) {
+  return window['go']['main']['App']['Revert'](arg1, arg2, arg3);
+}
+
+export function SaveFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveFile'](arg1, arg2, arg3);
 }
 
 export function SearchCommits(arg1, arg2, arg3) {
   return window['go']['main']['App']['SearchCommits'](arg1, arg2, arg3);
 }
 
+export function SearchContent(arg1, arg2) {
+  return window['go']['main']['App']['SearchContent'](arg1, arg2);
+}
+
 export function SearchFiles(arg1, arg2) {
   return window['go']['main']['App']['SearchFiles'](arg1, arg2);
 }
 
+export function SearchWorkspaceContent(arg1, arg2) {
+  return window['go']['main']['App']['SearchWorkspaceContent'](arg1, arg2);
+}
+
+export function SearchWorkspaceFiles(arg1, arg2) {
+  return window['go']['main']['App']['SearchWorkspaceFiles'](arg1, arg2);
+}
+
+export function SetUpstream(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetUpstream'](arg1, arg2, arg3);
+}
+
+export function SkipRebaseStep(arg1) {
+  return window['go']['main']['App']['SkipRebaseStep'](arg1);
+}
+
 export function StageFile(arg1, arg2) {
   return window['go']['main']['App']['StageFile'](arg1, arg2);
 }
 
+export function StageLines(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StageLines'](arg1, arg2, arg3);
+}
+
+export function StartRebase(arg1, arg2) {
+  return window['go']['main']['App']['StartRebase'](arg1, arg2);
+}
+
+export function TailLargeFile(arg1) {
+  return window['go']['main']['App']['TailLargeFile'](arg1);
+}
+
+export function UndoFileOperation(arg1) {
+  return window['go']['main']['App']['UndoFileOperation'](arg1);
+}
+
 export function UnstageFile(arg1, arg2) {
   return window['go']['main']['App']['UnstageFile'](arg1, arg2);
 }
+
+export function UnstageLines(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UnstageLines'](arg1, arg2, arg3);
+}
@@ -45,10 +45,57 @@ export namespace db {
 
 export namespace service {
 	
+	export class CommitInfo {
+	    hash: string;
+	    message: string;
+	    author: string;
+	    authorEmail: string;
+	    // Go type: time
+	    date: any;
+	    parentHashes: string[];
+	    hasMore: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommitInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hash = source["hash"];
+	        this.message = source["message"];
+	        this.author = source["author"];
+	        this.authorEmail = source["authorEmail"];
+	        this.date = this.convertValues(source["date"], null);
+	        this.parentHashes = source["parentHashes"];
+	        this.hasMore = source["hasMore"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class BranchInfo {
 	    name: string;
 	    isRemote: boolean;
 	    isHead: boolean;
+	    upstream: string;
+	    ahead: number;
+	    behind: number;
+	    lastCommit?: CommitInfo;
 	
 	    static createFrom(source: any = {}) {
 	        return new BranchInfo(source);
@@ -59,6 +106,60 @@ export namespace service {
 	        this.name = source["name"];
 	        this.isRemote = source["isRemote"];
 	        this.isHead = source["isHead"];
+	        this.upstream = source["upstream"];
+	        this.ahead = source["ahead"];
+	        this.behind = source["behind"];
+	        this.lastCommit = this.convertValues(source["lastCommit"], CommitInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ByteWindow {
+	    offset: number;
+	    length: number;
+	    content: string;
+	    eof: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ByteWindow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.offset = source["offset"];
+	        this.length = source["length"];
+	        this.content = source["content"];
+	        this.eof = source["eof"];
+	    }
+	}
+	export class CheckoutOptions {
+	    stash: boolean;
+	    force: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CheckoutOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.stash = source["stash"];
+	        this.force = source["force"];
 	    }
 	}
 	export class CommitFilter {
@@ -109,29 +210,41 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class CommitInfo {
-	    hash: string;
-	    message: string;
-	    author: string;
-	    authorEmail: string;
-	    // Go type: time
-	    date: any;
-	    parentHashes: string[];
-	    hasMore: boolean;
+	export class CommitIdentity {
+	    name: string;
+	    email: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new CommitInfo(source);
+	        return new CommitIdentity(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.hash = source["hash"];
-	        this.message = source["message"];
-	        this.author = source["author"];
-	        this.authorEmail = source["authorEmail"];
-	        this.date = this.convertValues(source["date"], null);
-	        this.parentHashes = source["parentHashes"];
-	        this.hasMore = source["hasMore"];
+	        this.name = source["name"];
+	        this.email = source["email"];
+	    }
+	}
+	
+	export class CommitOptions {
+	    amend: boolean;
+	    signOff: boolean;
+	    allowEmpty: boolean;
+	    author?: CommitIdentity;
+	    committer?: CommitIdentity;
+	    sign: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommitOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.amend = source["amend"];
+	        this.signOff = source["signOff"];
+	        this.allowEmpty = source["allowEmpty"];
+	        this.author = this.convertValues(source["author"], CommitIdentity);
+	        this.committer = this.convertValues(source["committer"], CommitIdentity);
+	        this.sign = source["sign"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -152,38 +265,72 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class DiffStats {
-	    added: number;
-	    deleted: number;
-	    modified: number;
+	export class ConflictRegion {
+	    index: number;
+	    startLine: number;
+	    endLine: number;
+	    oursLabel: string;
+	    theirsLabel: string;
+	    ours: string;
+	    base: string;
+	    hasBase: boolean;
+	    theirs: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DiffStats(source);
+	        return new ConflictRegion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.added = source["added"];
-	        this.deleted = source["deleted"];
-	        this.modified = source["modified"];
+	        this.index = source["index"];
+	        this.startLine = source["startLine"];
+	        this.endLine = source["endLine"];
+	        this.oursLabel = source["oursLabel"];
+	        this.theirsLabel = source["theirsLabel"];
+	        this.ours = source["ours"];
+	        this.base = source["base"];
+	        this.hasBase = source["hasBase"];
+	        this.theirs = source["theirs"];
 	    }
 	}
-	export class EditorConfig {
-	    // Go type: struct { Theme string "json:\"theme\" mapstructure:\"theme\""; FontSize int "json:\"fontSize\" mapstructure:\"fontSize\""; TabSize int "json:\"tabSize\" mapstructure:\"tabSize\""; WordWrap bool "json:\"wordWrap\" mapstructure:\"wordWrap\""; LineNumbers bool "json:\"lineNumbers\" mapstructure:\"lineNumbers\""; RelativeLines bool "json:\"relativeLines\" mapstructure:\"relativeLines\""; Minimap bool "json:\"minimap\" mapstructure:\"minimap\""; StickyScroll bool "json:\"stickyScroll\" mapstructure:\"stickyScroll\""; Vim struct { Enabled bool "json:\"enabled\" mapstructure:\"enabled\""; DefaultMode string "json:\"defaultMode\" mapstructure:\"defaultMode\"" } "json:\"vim\" mapstructure:\"vim\"" }
-	    editor: any;
-	    // Go type: struct { DefaultShell string "json:\"defaultShell\" mapstructure:\"defaultShell\""; FontSize int "json:\"fontSize\" mapstructure:\"fontSize\""; FontFamily string "json:\"fontFamily\" mapstructure:\"fontFamily\""; Theme struct { Background string "json:\"background\" mapstructure:\"background\""; Foreground string "json:\"foreground\" mapstructure:\"foreground\""; Cursor string "json:\"cursor\" mapstructure:\"cursor\""; SelectionBackground string "json:\"selectionBackground\" mapstructure:\"selectionBackground\""; SelectionForeground string "json:\"selectionForeground\" mapstructure:\"selectionForeground\"" } "json:\"theme\" mapstructure:\"theme\"" }
-	    terminal: any;
-	    keyboard: struct { CustomBindings map[string]service.;
+	export class ConflictVersion {
+	    hash: string;
+	    content: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new EditorConfig(source);
+	        return new ConflictVersion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.editor = this.convertValues(source["editor"], Object);
-	        this.terminal = this.convertValues(source["terminal"], Object);
-	        this.keyboard = this.convertValues(source["keyboard"], Object);
+	        this.hash = source["hash"];
+	        this.content = source["content"];
+	    }
+	}
+	export class ConflictDetails {
+	    path: string;
+	    type: string;
+	    isBinary: boolean;
+	    base?: ConflictVersion;
+	    ours?: ConflictVersion;
+	    theirs?: ConflictVersion;
+	    content: string;
+	    regions: ConflictRegion[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ConflictDetails(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.type = source["type"];
+	        this.isBinary = source["isBinary"];
+	        this.base = this.convertValues(source["base"], ConflictVersion);
+	        this.ours = this.convertValues(source["ours"], ConflictVersion);
+	        this.theirs = this.convertValues(source["theirs"], ConflictVersion);
+	        this.content = source["content"];
+	        this.regions = this.convertValues(source["regions"], ConflictRegion);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -204,23 +351,59 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class FileDiff {
+	export class ConflictFile {
 	    path: string;
-	    content: string;
-	    stats: DiffStats;
+	    type: string;
 	    isBinary: boolean;
 	
 	    static createFrom(source: any = {}) {
-	        return new FileDiff(source);
+	        return new ConflictFile(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.path = source["path"];
-	        this.content = source["content"];
-	        this.stats = this.convertValues(source["stats"], DiffStats);
+	        this.type = source["type"];
 	        this.isBinary = source["isBinary"];
 	    }
+	}
+	
+	
+	export class DiffRange {
+	    start: number;
+	    end: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = source["start"];
+	        this.end = source["end"];
+	    }
+	}
+	export class DiffLine {
+	    type: string;
+	    content: string;
+	    oldLine?: number;
+	    newLine?: number;
+	    noNewline?: boolean;
+	    changes?: DiffRange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.content = source["content"];
+	        this.oldLine = source["oldLine"];
+	        this.newLine = source["newLine"];
+	        this.noNewline = source["noNewline"];
+	        this.changes = this.convertValues(source["changes"], DiffRange);
+	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
 		    if (!a) {
@@ -240,29 +423,26 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class FileNode {
-	    name: string;
-	    path: string;
-	    type: string;
-	    size?: number;
-	    // Go type: time
-	    lastModified: any;
-	    children?: FileNode[];
-	    isLoaded: boolean;
+	export class DiffHunk {
+	    oldStart: number;
+	    oldLines: number;
+	    newStart: number;
+	    newLines: number;
+	    header: string;
+	    lines: DiffLine[];
 	
 	    static createFrom(source: any = {}) {
-	        return new FileNode(source);
+	        return new DiffHunk(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.name = source["name"];
-	        this.path = source["path"];
-	        this.type = source["type"];
-	        this.size = source["size"];
-	        this.lastModified = this.convertValues(source["lastModified"], null);
-	        this.children = this.convertValues(source["children"], FileNode);
-	        this.isLoaded = source["isLoaded"];
+	        this.oldStart = source["oldStart"];
+	        this.oldLines = source["oldLines"];
+	        this.newStart = source["newStart"];
+	        this.newLines = source["newLines"];
+	        this.header = source["header"];
+	        this.lines = this.convertValues(source["lines"], DiffLine);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -283,36 +463,1257 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class FileStatus {
-	    file: string;
-	    status: string;
-	    staged: boolean;
+	
+	export class DiffLineRange {
+	    hunk: number;
+	    start: number;
+	    end: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new FileStatus(source);
+	        return new DiffLineRange(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.file = source["file"];
-	        this.status = source["status"];
-	        this.staged = source["staged"];
+	        this.hunk = source["hunk"];
+	        this.start = source["start"];
+	        this.end = source["end"];
 	    }
 	}
-	export class KeyBinding {
-	    key: string;
-	    modifiers: string[];
+	export class DiffOptions {
+	    context: number;
+	    whitespace: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new KeyBinding(source);
+	        return new DiffOptions(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.key = source["key"];
-	        this.modifiers = source["modifiers"];
+	        this.context = source["context"];
+	        this.whitespace = source["whitespace"];
+	    }
+	}
+	
+	export class DiffSelection {
+	    hash: string;
+	    options: DiffOptions;
+	    hunks: number[];
+	    lines: DiffLineRange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffSelection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hash = source["hash"];
+	        this.options = this.convertValues(source["options"], DiffOptions);
+	        this.hunks = source["hunks"];
+	        this.lines = this.convertValues(source["lines"], DiffLineRange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiffStats {
+	    added: number;
+	    deleted: number;
+	    modified: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = source["added"];
+	        this.deleted = source["deleted"];
+	        this.modified = source["modified"];
 	    }
 	}
+	export class DirectoryDiffEntry {
+	    path: string;
+	    status: string;
+	    isBinary: boolean;
+	    stats: DiffStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryDiffEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.status = source["status"];
+	        this.isBinary = source["isBinary"];
+	        this.stats = this.convertValues(source["stats"], DiffStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DirectoryDiff {
+	    oldPath: string;
+	    newPath: string;
+	    files: DirectoryDiffEntry[];
+	    stats: DiffStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oldPath = source["oldPath"];
+	        this.newPath = source["newPath"];
+	        this.files = this.convertValues(source["files"], DirectoryDiffEntry);
+	        this.stats = this.convertValues(source["stats"], DiffStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class DirectoryListOptions {
+	    cursor: string;
+	    limit: number;
+	    filter: string;
+	    sortBy: string;
+	    descending: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryListOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.cursor = source["cursor"];
+	        this.limit = source["limit"];
+	        this.filter = source["filter"];
+	        this.sortBy = source["sortBy"];
+	        this.descending = source["descending"];
+	    }
+	}
+	export class FileNode {
+	    name: string;
+	    path: string;
+	    type: string;
+	    size?: number;
+	    // Go type: time
+	    lastModified: any;
+	    children?: FileNode[];
+	    isLoaded: boolean;
+	    hidden?: boolean;
+	    gitIgnored?: boolean;
+	    symlink?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileNode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.type = source["type"];
+	        this.size = source["size"];
+	        this.lastModified = this.convertValues(source["lastModified"], null);
+	        this.children = this.convertValues(source["children"], FileNode);
+	        this.isLoaded = source["isLoaded"];
+	        this.hidden = source["hidden"];
+	        this.gitIgnored = source["gitIgnored"];
+	        this.symlink = source["symlink"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DirectoryPage {
+	    path: string;
+	    entries: FileNode[];
+	    total: number;
+	    nextCursor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DirectoryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.entries = this.convertValues(source["entries"], FileNode);
+	        this.total = source["total"];
+	        this.nextCursor = source["nextCursor"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EditorConfig {
+	    // Go type: struct { Theme string "json:\"theme\" mapstructure:\"theme\""; FontSize int "json:\"fontSize\" mapstructure:\"fontSize\""; TabSize int "json:\"tabSize\" mapstructure:\"tabSize\""; WordWrap bool "json:\"wordWrap\" mapstructure:\"wordWrap\""; LineNumbers bool "json:\"lineNumbers\" mapstructure:\"lineNumbers\""; RelativeLines bool "json:\"relativeLines\" mapstructure:\"relativeLines\""; Minimap bool "json:\"minimap\" mapstructure:\"minimap\""; StickyScroll bool "json:\"stickyScroll\" mapstructure:\"stickyScroll\""; Vim struct { Enabled bool "json:\"enabled\" mapstructure:\"enabled\""; DefaultMode string "json:\"defaultMode\" mapstructure:\"defaultMode\"" } "json:\"vim\" mapstructure:\"vim\"" }
+	    editor: any;
+	    // Go type: struct { DefaultShell string "json:\"defaultShell\" mapstructure:\"defaultShell\""; FontSize int "json:\"fontSize\" mapstructure:\"fontSize\""; FontFamily string "json:\"fontFamily\" mapstructure:\"fontFamily\""; Theme struct { Background string "json:\"background\" mapstructure:\"background\""; Foreground string "json:\"foreground\" mapstructure:\"foreground\""; Cursor string "json:\"cursor\" mapstructure:\"cursor\""; SelectionBackground string "json:\"selectionBackground\" mapstructure:\"selectionBackground\""; SelectionForeground string "json:\"selectionForeground\" mapstructure:\"selectionForeground\"" } "json:\"theme\" mapstructure:\"theme\"" }
+	    terminal: any;
+	    // Go type: struct { Encoding string "json:\"encoding\" mapstructure:\"encoding\""; LineEnding string "json:\"lineEnding\" mapstructure:\"lineEnding\""; BOM bool "json:\"bom\" mapstructure:\"bom\""; LargeFileSizeMB int "json:\"largeFileSizeMB\" mapstructure:\"largeFileSizeMB\""; TrashMaxAgeDays int "json:\"trashMaxAgeDays\" mapstructure:\"trashMaxAgeDays\""; TrashMaxSizeMB int "json:\"trashMaxSizeMB\" mapstructure:\"trashMaxSizeMB\""; HistoryMaxAgeDays int "json:\"historyMaxAgeDays\" mapstructure:\"historyMaxAgeDays\""; HistoryMaxEntries int "json:\"historyMaxEntries\" mapstructure:\"historyMaxEntries\""; Exclude []string "json:\"exclude\" mapstructure:\"exclude\""; ShowHidden bool "json:\"showHidden\" mapstructure:\"showHidden\"" }
+	    files: any;
+	    keyboard: struct { CustomBindings map[string]service.;
+	
+	    static createFrom(source: any = {}) {
+	        return new EditorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.editor = this.convertValues(source["editor"], Object);
+	        this.terminal = this.convertValues(source["terminal"], Object);
+	        this.files = this.convertValues(source["files"], Object);
+	        this.keyboard = this.convertValues(source["keyboard"], Object);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FileFormat {
+	    encoding: string;
+	    bom: boolean;
+	    lineEnding: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileFormat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.encoding = source["encoding"];
+	        this.bom = source["bom"];
+	        this.lineEnding = source["lineEnding"];
+	    }
+	}
+	export class FileContent {
+	    path: string;
+	    content: string;
+	    version: string;
+	    format: FileFormat;
+	    mixedLineEndings: boolean;
+	    size: number;
+	    isLarge: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileContent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.content = source["content"];
+	        this.version = source["version"];
+	        this.format = this.convertValues(source["format"], FileFormat);
+	        this.mixedLineEndings = source["mixedLineEndings"];
+	        this.size = source["size"];
+	        this.isLarge = source["isLarge"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FileDiff {
+	    path: string;
+	    content: string;
+	    stats: DiffStats;
+	    isBinary: boolean;
+	    hunks: DiffHunk[];
+	    hash: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.content = source["content"];
+	        this.stats = this.convertValues(source["stats"], DiffStats);
+	        this.isBinary = source["isBinary"];
+	        this.hunks = this.convertValues(source["hunks"], DiffHunk);
+	        this.hash = source["hash"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class FileOperation {
+	    id: number;
+	    projectPath: string;
+	    kind: string;
+	    path: string;
+	    targetPath: string;
+	    isDir: boolean;
+	    undone: boolean;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileOperation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.projectPath = source["projectPath"];
+	        this.kind = source["kind"];
+	        this.path = source["path"];
+	        this.targetPath = source["targetPath"];
+	        this.isDir = source["isDir"];
+	        this.undone = source["undone"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReplaceEdit {
+	    index: number;
+	    line: number;
+	    column: number;
+	    length: number;
+	    original: string;
+	    replacement: string;
+	    preview: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplaceEdit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.length = source["length"];
+	        this.original = source["original"];
+	        this.replacement = source["replacement"];
+	        this.preview = source["preview"];
+	    }
+	}
+	export class FileReplacePreview {
+	    path: string;
+	    version: string;
+	    edits: ReplaceEdit[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FileReplacePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.version = source["version"];
+	        this.edits = this.convertValues(source["edits"], ReplaceEdit);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FileSearchOptions {
+	    query: string;
+	    offset: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileSearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.query = source["query"];
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class FileSearchPage {
+	    files: FileNode[];
+	    total: number;
+	    hasMore: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileSearchPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = this.convertValues(source["files"], FileNode);
+	        this.total = source["total"];
+	        this.hasMore = source["hasMore"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FileSnapshot {
+	    id: number;
+	    projectPath: string;
+	    path: string;
+	    hash: string;
+	    size: number;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.projectPath = source["projectPath"];
+	        this.path = source["path"];
+	        this.hash = source["hash"];
+	        this.size = source["size"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FileStatus {
+	    file: string;
+	    status: string;
+	    staged: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.status = source["status"];
+	        this.staged = source["staged"];
+	    }
+	}
+	export class KeyBinding {
+	    key: string;
+	    modifiers: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new KeyBinding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.modifiers = source["modifiers"];
+	    }
+	}
+	export class LargeFileInfo {
+	    path: string;
+	    size: number;
+	    indexed: number;
+	    lines: number;
+	    complete: boolean;
+	    tailing: boolean;
+	    reset: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LargeFileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.indexed = source["indexed"];
+	        this.lines = source["lines"];
+	        this.complete = source["complete"];
+	        this.tailing = source["tailing"];
+	        this.reset = source["reset"];
+	    }
+	}
+	export class LineRange {
+	    startLine: number;
+	    lines: string[];
+	    totalLines: number;
+	    complete: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LineRange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.startLine = source["startLine"];
+	        this.lines = source["lines"];
+	        this.totalLines = source["totalLines"];
+	        this.complete = source["complete"];
+	    }
+	}
+	export class MergeOptions {
+	    message: string;
+	    noFastForward: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.message = source["message"];
+	        this.noFastForward = source["noFastForward"];
+	    }
+	}
+	export class MergeResult {
+	    status: string;
+	    commit?: CommitInfo;
+	    conflicts: ConflictFile[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.commit = this.convertValues(source["commit"], CommitInfo);
+	        this.conflicts = this.convertValues(source["conflicts"], ConflictFile);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MergeState {
+	    inProgress: boolean;
+	    operation: string;
+	    mergeHead: string;
+	    message: string;
+	    conflicts: ConflictFile[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inProgress = source["inProgress"];
+	        this.operation = source["operation"];
+	        this.mergeHead = source["mergeHead"];
+	        this.message = source["message"];
+	        this.conflicts = this.convertValues(source["conflicts"], ConflictFile);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PickOptions {
+	    noCommit: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PickOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.noCommit = source["noCommit"];
+	    }
+	}
+	export class RebaseStep {
+	    action: string;
+	    hash: string;
+	    subject: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RebaseStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.action = source["action"];
+	        this.hash = source["hash"];
+	        this.subject = source["subject"];
+	        this.message = source["message"];
+	    }
+	}
+	export class RebasePlan {
+	    onto: string;
+	    steps: RebaseStep[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RebasePlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.onto = source["onto"];
+	        this.steps = this.convertValues(source["steps"], RebaseStep);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RebaseState {
+	    inProgress: boolean;
+	    status: string;
+	    branch: string;
+	    onto: string;
+	    current?: RebaseStep;
+	    done: RebaseStep[];
+	    todo: RebaseStep[];
+	    conflicts: ConflictFile[];
+	    commit?: CommitInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new RebaseState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.inProgress = source["inProgress"];
+	        this.status = source["status"];
+	        this.branch = source["branch"];
+	        this.onto = source["onto"];
+	        this.current = this.convertValues(source["current"], RebaseStep);
+	        this.done = this.convertValues(source["done"], RebaseStep);
+	        this.todo = this.convertValues(source["todo"], RebaseStep);
+	        this.conflicts = this.convertValues(source["conflicts"], ConflictFile);
+	        this.commit = this.convertValues(source["commit"], CommitInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class ReplaceFileSelection {
+	    path: string;
+	    version: string;
+	    excluded: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplaceFileSelection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.version = source["version"];
+	        this.excluded = source["excluded"];
+	    }
+	}
+	export class SearchOptions {
+	    id: string;
+	    query: string;
+	    isRegex: boolean;
+	    caseSensitive: boolean;
+	    wholeWord: boolean;
+	    include: string[];
+	    exclude: string[];
+	    maxResults: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.query = source["query"];
+	        this.isRegex = source["isRegex"];
+	        this.caseSensitive = source["caseSensitive"];
+	        this.wholeWord = source["wholeWord"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.maxResults = source["maxResults"];
+	    }
+	}
+	export class ReplaceOptions {
+	    search: SearchOptions;
+	    replacement: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplaceOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.search = this.convertValues(source["search"], SearchOptions);
+	        this.replacement = source["replacement"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReplacePreview {
+	    files: FileReplacePreview[];
+	    edits: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplacePreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = this.convertValues(source["files"], FileReplacePreview);
+	        this.edits = source["edits"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReplaceResult {
+	    filesChanged: number;
+	    edits: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReplaceResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filesChanged = source["filesChanged"];
+	        this.edits = source["edits"];
+	    }
+	}
+	export class RootStatus {
+	    root: string;
+	    isRepository: boolean;
+	    files: FileStatus[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RootStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.root = source["root"];
+	        this.isRepository = source["isRepository"];
+	        this.files = this.convertValues(source["files"], FileStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SaveOptions {
+	    version: string;
+	    format?: FileFormat;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.format = this.convertValues(source["format"], FileFormat);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SearchSummary {
+	    id: string;
+	    filesMatched: number;
+	    matches: number;
+	    truncated: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.filesMatched = source["filesMatched"];
+	        this.matches = source["matches"];
+	        this.truncated = source["truncated"];
+	    }
+	}
+	export class StashInfo {
+	    index: number;
+	    hash: string;
+	    message: string;
+	    branch: string;
+	    // Go type: time
+	    date: any;
+	    files: DirectoryDiffEntry[];
+	    stats: DiffStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new StashInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.hash = source["hash"];
+	        this.message = source["message"];
+	        this.branch = source["branch"];
+	        this.date = this.convertValues(source["date"], null);
+	        this.files = this.convertValues(source["files"], DirectoryDiffEntry);
+	        this.stats = this.convertValues(source["stats"], DiffStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StashOptions {
+	    message: string;
+	    includeUntracked: boolean;
+	    stagedOnly: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StashOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.message = source["message"];
+	        this.includeUntracked = source["includeUntracked"];
+	        this.stagedOnly = source["stagedOnly"];
+	    }
+	}
+	export class TransferOptions {
+	    id: string;
+	    sources: string[];
+	    destination: string;
+	    conflict: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TransferOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sources = source["sources"];
+	        this.destination = source["destination"];
+	        this.conflict = source["conflict"];
+	    }
+	}
+	export class TransferResult {
+	    paths: string[];
+	    skipped: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TransferResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class TrashItem {
+	    id: number;
+	    projectPath: string;
+	    originalPath: string;
+	    isDir: boolean;
+	    size: number;
+	    // Go type: time
+	    deletedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.projectPath = source["projectPath"];
+	        this.originalPath = source["originalPath"];
+	        this.isDir = source["isDir"];
+	        this.size = source["size"];
+	        this.deletedAt = this.convertValues(source["deletedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WorkspaceRoot {
+	    path: string;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorkspaceRoot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	    }
+	}
+	export class Workspace {
+	    id: number;
+	    name: string;
+	    roots: WorkspaceRoot[];
+	    // Go type: time
+	    lastOpened: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Workspace(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.roots = this.convertValues(source["roots"], WorkspaceRoot);
+	        this.lastOpened = this.convertValues(source["lastOpened"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
@@ -13,6 +13,8 @@ interface OpenFile {
     language: string;
     type: 'file' | 'diff';
     stats?: DiffStats;
+    version?: string;
+    isLarge?: boolean; // Opened read-only, the content is not loaded
 }
 
 interface FileState {
@@ -132,12 +134,17 @@ function createFileStore() {
             }
 
             try {
-                const content = await GetFileContent(path);
+                const file = await GetFileContent(path);
                 update(state => {
                     const newOpenFiles = new Map(state.openFiles);
                     const openFile: OpenFile = {
                         path,
-                        content,
+                        // Large files come without content, saving the empty buffer would truncate them
+                        content: file.isLarge
+                            ? `This file is too large to edit (${(file.size / (1 << 20)).toFixed(1)} MB) and is shown read-only.`
+                            : file.content,
+                        version: file.version,
+                        isLarge: file.isLarge,
                         isDirty: false,
                         language: getLanguageFromPath(path),
                         type: 'file'
@@ -219,7 +226,7 @@ function createFileStore() {
         markAsDirty(path: string) {
             update(state => {
                 const file = state.openFiles.get(path);
-                if (!file || file.isDirty) return state; // Skip if already dirty
+                if (!file || file.isDirty || file.isLarge) return state; // Skip if already dirty or read-only
 
                 const newOpenFiles = new Map(state.openFiles);
                 newOpenFiles.set(path, { ...file, isDirty: true });
@@ -232,7 +239,7 @@ function createFileStore() {
             update(state => {
                 const openFiles = new Map(state.openFiles);
                 const file = openFiles.get(path);
-                if (file) {
+                if (file && !file.isLarge) {
                     openFiles.set(path, {
                         ...file,
                         content,
@@ -247,11 +254,11 @@ function createFileStore() {
         async saveFile(path: string) {
             const state = get({ subscribe });
             const file = state.openFiles.get(path);
-            if (!file) return;
+            if (!file || file.isLarge) return;
             
             try {
                 const content = file.content;
-                await SaveFile(path, content);
+                const version = await SaveFile(path, content, { version: file.version ?? '' } as service.SaveOptions);
                 
                 // Update the store to mark file as not dirty
                 update(state => {
@@ -260,7 +267,8 @@ function createFileStore() {
                         const newOpenFiles = new Map(state.openFiles);
                         newOpenFiles.set(path, { 
                             ...file, 
-                            isDirty: false 
+                            isDirty: false,
+                            version
                         });
                         return { ...state, openFiles: newOpenFiles };
                     }
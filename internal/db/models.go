@@ -8,6 +8,36 @@ import (
 	"database/sql"
 )
 
+type FileOpen struct {
+	ID         int64
+	Path       string
+	OpenCount  int64
+	LastOpened sql.NullTime
+}
+
+type FileOperation struct {
+	ID                  int64
+	ProjectPath         string
+	Kind                string
+	Path                string
+	TargetPath          string
+	IsDir               bool
+	TrashItemID         sql.NullInt64
+	Overwrite           bool
+	ReplacedTrashItemID sql.NullInt64
+	Undone              bool
+	CreatedAt           sql.NullTime
+}
+
+type FileSnapshot struct {
+	ID          int64
+	ProjectPath string
+	Path        string
+	Hash        string
+	Size        int64
+	CreatedAt   sql.NullTime
+}
+
 type Project struct {
 	ID         int64
 	Name       string
@@ -16,3 +46,29 @@ type Project struct {
 	CreatedAt  sql.NullTime
 	UpdatedAt  sql.NullTime
 }
+
+type TrashItem struct {
+	ID           int64
+	ProjectPath  string
+	OriginalPath string
+	TrashPath    string
+	IsDir        bool
+	Size         int64
+	DeletedAt    sql.NullTime
+}
+
+type Workspace struct {
+	ID         int64
+	Name       string
+	LastOpened sql.NullTime
+	CreatedAt  sql.NullTime
+	UpdatedAt  sql.NullTime
+}
+
+type WorkspaceRoot struct {
+	ID          int64
+	WorkspaceID int64
+	Path        string
+	Name        string
+	Position    int64
+}
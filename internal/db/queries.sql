@@ -15,4 +15,170 @@ WHERE id = ?;
 -- name: ListRecentProjects :many
 SELECT * FROM projects
 ORDER BY last_opened DESC
+LIMIT ?;
+
+-- name: CreateTrashItem :one
+INSERT INTO trash_items (project_path, original_path, trash_path, is_dir, size)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetTrashItem :one
+SELECT * FROM trash_items
+WHERE id = ? LIMIT 1;
+
+-- name: ListTrashItems :many
+SELECT * FROM trash_items
+WHERE project_path = ?
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: ListAllTrashItems :many
+SELECT * FROM trash_items
+ORDER BY deleted_at ASC, id ASC;
+
+-- name: DeleteTrashItem :exec
+DELETE FROM trash_items
+WHERE id = ?;
+
+-- name: CreateFileOperation :one
+INSERT INTO file_operations (project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListFileOperations :many
+SELECT * FROM file_operations
+WHERE project_path = ?
+ORDER BY id DESC;
+
+-- name: GetLastDoneFileOperation :one
+SELECT * FROM file_operations
+WHERE project_path = ? AND undone = 0
+ORDER BY id DESC LIMIT 1;
+
+-- name: GetFirstUndoneFileOperation :one
+SELECT * FROM file_operations
+WHERE project_path = ? AND undone = 1
+ORDER BY id ASC LIMIT 1;
+
+-- name: UpdateFileOperationState :exec
+UPDATE file_operations
+SET undone = ?, trash_item_id = ?, replaced_trash_item_id = ?
+WHERE id = ?;
+
+-- name: DeleteUndoneFileOperations :exec
+DELETE FROM file_operations
+WHERE project_path = ? AND undone = 1;
+
+-- name: DeleteFileOperationsOfTrashItem :exec
+DELETE FROM file_operations
+WHERE id IN (
+    SELECT o.id FROM file_operations o
+    JOIN file_operations p ON p.project_path = o.project_path AND (p.trash_item_id = ? OR p.replaced_trash_item_id = ?)
+    WHERE (p.undone = 0 AND o.undone = 0 AND o.id <= p.id)
+       OR (p.undone = 1 AND o.undone = 1 AND o.id >= p.id)
+);
+
+-- name: TrimFileOperations :exec
+DELETE FROM file_operations
+WHERE project_path = ? AND id NOT IN (
+    SELECT id FROM file_operations
+    WHERE project_path = ?
+    ORDER BY id DESC LIMIT ?
+);
+
+-- name: CreateFileSnapshot :one
+INSERT INTO file_snapshots (project_path, path, hash, size)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetFileSnapshot :one
+SELECT * FROM file_snapshots
+WHERE id = ? LIMIT 1;
+
+-- name: GetLatestFileSnapshot :one
+SELECT * FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC LIMIT 1;
+
+-- name: ListFileSnapshots :many
+SELECT * FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC;
+
+-- name: ListExcessFileSnapshots :many
+SELECT * FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC
+LIMIT -1 OFFSET ?;
+
+-- name: ListExpiredFileSnapshots :many
+SELECT * FROM file_snapshots
+WHERE created_at < ?;
+
+-- name: CountFileSnapshotsByHash :one
+SELECT COUNT(*) FROM file_snapshots
+WHERE hash = ?;
+
+-- name: DeleteFileSnapshot :exec
+DELETE FROM file_snapshots
+WHERE id = ?;
+
+-- name: CreateWorkspace :one
+INSERT INTO workspaces (name)
+VALUES (?)
+RETURNING *;
+
+-- name: GetWorkspace :one
+SELECT * FROM workspaces
+WHERE id = ? LIMIT 1;
+
+-- name: ListWorkspaces :many
+SELECT * FROM workspaces
+ORDER BY last_opened DESC;
+
+-- name: UpdateWorkspaceLastOpened :exec
+UPDATE workspaces
+SET last_opened = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RenameWorkspace :exec
+UPDATE workspaces
+SET name = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: DeleteWorkspace :exec
+DELETE FROM workspaces
+WHERE id = ?;
+
+-- name: AddWorkspaceRoot :one
+INSERT INTO workspace_roots (workspace_id, path, name, position)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListWorkspaceRoots :many
+SELECT * FROM workspace_roots
+WHERE workspace_id = ?
+ORDER BY position ASC, id ASC;
+
+-- name: RemoveWorkspaceRoot :exec
+DELETE FROM workspace_roots
+WHERE workspace_id = ? AND path = ?;
+
+-- name: DeleteWorkspaceRoots :exec
+DELETE FROM workspace_roots
+WHERE workspace_id = ?;
+
+-- name: CountWorkspaceRootsByPath :one
+SELECT COUNT(*) FROM workspace_roots
+WHERE path = ?;
+
+-- name: RecordFileOpen :exec
+INSERT INTO file_opens (path)
+VALUES (?)
+ON CONFLICT (path) DO UPDATE
+SET open_count = open_count + 1, last_opened = CURRENT_TIMESTAMP;
+
+-- name: ListFileOpens :many
+SELECT * FROM file_opens
+WHERE path LIKE ? ESCAPE '\'
+ORDER BY last_opened DESC
 LIMIT ?;
\ No newline at end of file
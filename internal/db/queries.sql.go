@@ -7,8 +7,141 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const addWorkspaceRoot = `-- name: AddWorkspaceRoot :one
+INSERT INTO workspace_roots (workspace_id, path, name, position)
+VALUES (?, ?, ?, ?)
+RETURNING id, workspace_id, path, name, position
+`
+
+type AddWorkspaceRootParams struct {
+	WorkspaceID int64
+	Path        string
+	Name        string
+	Position    int64
+}
+
+func (q *Queries) AddWorkspaceRoot(ctx context.Context, arg AddWorkspaceRootParams) (WorkspaceRoot, error) {
+	row := q.db.QueryRowContext(ctx, addWorkspaceRoot,
+		arg.WorkspaceID,
+		arg.Path,
+		arg.Name,
+		arg.Position,
+	)
+	var i WorkspaceRoot
+	err := row.Scan(
+		&i.ID,
+		&i.WorkspaceID,
+		&i.Path,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
+const countFileSnapshotsByHash = `-- name: CountFileSnapshotsByHash :one
+SELECT COUNT(*) FROM file_snapshots
+WHERE hash = ?
+`
+
+func (q *Queries) CountFileSnapshotsByHash(ctx context.Context, hash string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFileSnapshotsByHash, hash)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countWorkspaceRootsByPath = `-- name: CountWorkspaceRootsByPath :one
+SELECT COUNT(*) FROM workspace_roots
+WHERE path = ?
+`
+
+func (q *Queries) CountWorkspaceRootsByPath(ctx context.Context, path string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWorkspaceRootsByPath, path)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFileOperation = `-- name: CreateFileOperation :one
+INSERT INTO file_operations (project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id, undone, created_at
+`
+
+type CreateFileOperationParams struct {
+	ProjectPath         string
+	Kind                string
+	Path                string
+	TargetPath          string
+	IsDir               bool
+	TrashItemID         sql.NullInt64
+	Overwrite           bool
+	ReplacedTrashItemID sql.NullInt64
+}
+
+func (q *Queries) CreateFileOperation(ctx context.Context, arg CreateFileOperationParams) (FileOperation, error) {
+	row := q.db.QueryRowContext(ctx, createFileOperation,
+		arg.ProjectPath,
+		arg.Kind,
+		arg.Path,
+		arg.TargetPath,
+		arg.IsDir,
+		arg.TrashItemID,
+		arg.Overwrite,
+		arg.ReplacedTrashItemID,
+	)
+	var i FileOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Kind,
+		&i.Path,
+		&i.TargetPath,
+		&i.IsDir,
+		&i.TrashItemID,
+		&i.Overwrite,
+		&i.ReplacedTrashItemID,
+		&i.Undone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createFileSnapshot = `-- name: CreateFileSnapshot :one
+INSERT INTO file_snapshots (project_path, path, hash, size)
+VALUES (?, ?, ?, ?)
+RETURNING id, project_path, path, hash, size, created_at
+`
+
+type CreateFileSnapshotParams struct {
+	ProjectPath string
+	Path        string
+	Hash        string
+	Size        int64
+}
+
+func (q *Queries) CreateFileSnapshot(ctx context.Context, arg CreateFileSnapshotParams) (FileSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, createFileSnapshot,
+		arg.ProjectPath,
+		arg.Path,
+		arg.Hash,
+		arg.Size,
+	)
+	var i FileSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Path,
+		&i.Hash,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createProject = `-- name: CreateProject :one
 INSERT INTO projects (name, path)
 VALUES (?, ?)
@@ -34,6 +167,219 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 	return i, err
 }
 
+const createTrashItem = `-- name: CreateTrashItem :one
+INSERT INTO trash_items (project_path, original_path, trash_path, is_dir, size)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, project_path, original_path, trash_path, is_dir, size, deleted_at
+`
+
+type CreateTrashItemParams struct {
+	ProjectPath  string
+	OriginalPath string
+	TrashPath    string
+	IsDir        bool
+	Size         int64
+}
+
+func (q *Queries) CreateTrashItem(ctx context.Context, arg CreateTrashItemParams) (TrashItem, error) {
+	row := q.db.QueryRowContext(ctx, createTrashItem,
+		arg.ProjectPath,
+		arg.OriginalPath,
+		arg.TrashPath,
+		arg.IsDir,
+		arg.Size,
+	)
+	var i TrashItem
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.OriginalPath,
+		&i.TrashPath,
+		&i.IsDir,
+		&i.Size,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const createWorkspace = `-- name: CreateWorkspace :one
+INSERT INTO workspaces (name)
+VALUES (?)
+RETURNING id, name, last_opened, created_at, updated_at
+`
+
+func (q *Queries) CreateWorkspace(ctx context.Context, name string) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, createWorkspace, name)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LastOpened,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteFileOperationsOfTrashItem = `-- name: DeleteFileOperationsOfTrashItem :exec
+DELETE FROM file_operations
+WHERE id IN (
+    SELECT o.id FROM file_operations o
+    JOIN file_operations p ON p.project_path = o.project_path AND (p.trash_item_id = ? OR p.replaced_trash_item_id = ?)
+    WHERE (p.undone = 0 AND o.undone = 0 AND o.id <= p.id)
+       OR (p.undone = 1 AND o.undone = 1 AND o.id >= p.id)
+)
+`
+
+type DeleteFileOperationsOfTrashItemParams struct {
+	TrashItemID         sql.NullInt64
+	ReplacedTrashItemID sql.NullInt64
+}
+
+func (q *Queries) DeleteFileOperationsOfTrashItem(ctx context.Context, arg DeleteFileOperationsOfTrashItemParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFileOperationsOfTrashItem, arg.TrashItemID, arg.ReplacedTrashItemID)
+	return err
+}
+
+const deleteFileSnapshot = `-- name: DeleteFileSnapshot :exec
+DELETE FROM file_snapshots
+WHERE id = ?
+`
+
+func (q *Queries) DeleteFileSnapshot(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFileSnapshot, id)
+	return err
+}
+
+const deleteTrashItem = `-- name: DeleteTrashItem :exec
+DELETE FROM trash_items
+WHERE id = ?
+`
+
+func (q *Queries) DeleteTrashItem(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTrashItem, id)
+	return err
+}
+
+const deleteUndoneFileOperations = `-- name: DeleteUndoneFileOperations :exec
+DELETE FROM file_operations
+WHERE project_path = ? AND undone = 1
+`
+
+func (q *Queries) DeleteUndoneFileOperations(ctx context.Context, projectPath string) error {
+	_, err := q.db.ExecContext(ctx, deleteUndoneFileOperations, projectPath)
+	return err
+}
+
+const deleteWorkspace = `-- name: DeleteWorkspace :exec
+DELETE FROM workspaces
+WHERE id = ?
+`
+
+func (q *Queries) DeleteWorkspace(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspace, id)
+	return err
+}
+
+const deleteWorkspaceRoots = `-- name: DeleteWorkspaceRoots :exec
+DELETE FROM workspace_roots
+WHERE workspace_id = ?
+`
+
+func (q *Queries) DeleteWorkspaceRoots(ctx context.Context, workspaceID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceRoots, workspaceID)
+	return err
+}
+
+const getFileSnapshot = `-- name: GetFileSnapshot :one
+SELECT id, project_path, path, hash, size, created_at FROM file_snapshots
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetFileSnapshot(ctx context.Context, id int64) (FileSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, getFileSnapshot, id)
+	var i FileSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Path,
+		&i.Hash,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFirstUndoneFileOperation = `-- name: GetFirstUndoneFileOperation :one
+SELECT id, project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id, undone, created_at FROM file_operations
+WHERE project_path = ? AND undone = 1
+ORDER BY id ASC LIMIT 1
+`
+
+func (q *Queries) GetFirstUndoneFileOperation(ctx context.Context, projectPath string) (FileOperation, error) {
+	row := q.db.QueryRowContext(ctx, getFirstUndoneFileOperation, projectPath)
+	var i FileOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Kind,
+		&i.Path,
+		&i.TargetPath,
+		&i.IsDir,
+		&i.TrashItemID,
+		&i.Overwrite,
+		&i.ReplacedTrashItemID,
+		&i.Undone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLastDoneFileOperation = `-- name: GetLastDoneFileOperation :one
+SELECT id, project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id, undone, created_at FROM file_operations
+WHERE project_path = ? AND undone = 0
+ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetLastDoneFileOperation(ctx context.Context, projectPath string) (FileOperation, error) {
+	row := q.db.QueryRowContext(ctx, getLastDoneFileOperation, projectPath)
+	var i FileOperation
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Kind,
+		&i.Path,
+		&i.TargetPath,
+		&i.IsDir,
+		&i.TrashItemID,
+		&i.Overwrite,
+		&i.ReplacedTrashItemID,
+		&i.Undone,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLatestFileSnapshot = `-- name: GetLatestFileSnapshot :one
+SELECT id, project_path, path, hash, size, created_at FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestFileSnapshot(ctx context.Context, path string) (FileSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, getLatestFileSnapshot, path)
+	var i FileSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.Path,
+		&i.Hash,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getProject = `-- name: GetProject :one
 SELECT id, name, path, last_opened, created_at, updated_at FROM projects
 WHERE path = ? LIMIT 1
@@ -53,6 +399,274 @@ func (q *Queries) GetProject(ctx context.Context, path string) (Project, error)
 	return i, err
 }
 
+const getTrashItem = `-- name: GetTrashItem :one
+SELECT id, project_path, original_path, trash_path, is_dir, size, deleted_at FROM trash_items
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetTrashItem(ctx context.Context, id int64) (TrashItem, error) {
+	row := q.db.QueryRowContext(ctx, getTrashItem, id)
+	var i TrashItem
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectPath,
+		&i.OriginalPath,
+		&i.TrashPath,
+		&i.IsDir,
+		&i.Size,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getWorkspace = `-- name: GetWorkspace :one
+SELECT id, name, last_opened, created_at, updated_at FROM workspaces
+WHERE id = ? LIMIT 1
+`
+
+func (q *Queries) GetWorkspace(ctx context.Context, id int64) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspace, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.LastOpened,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listAllTrashItems = `-- name: ListAllTrashItems :many
+SELECT id, project_path, original_path, trash_path, is_dir, size, deleted_at FROM trash_items
+ORDER BY deleted_at ASC, id ASC
+`
+
+func (q *Queries) ListAllTrashItems(ctx context.Context) ([]TrashItem, error) {
+	rows, err := q.db.QueryContext(ctx, listAllTrashItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrashItem
+	for rows.Next() {
+		var i TrashItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.OriginalPath,
+			&i.TrashPath,
+			&i.IsDir,
+			&i.Size,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExcessFileSnapshots = `-- name: ListExcessFileSnapshots :many
+SELECT id, project_path, path, hash, size, created_at FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC
+LIMIT -1 OFFSET ?
+`
+
+type ListExcessFileSnapshotsParams struct {
+	Path   string
+	Offset int64
+}
+
+func (q *Queries) ListExcessFileSnapshots(ctx context.Context, arg ListExcessFileSnapshotsParams) ([]FileSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, listExcessFileSnapshots, arg.Path, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileSnapshot
+	for rows.Next() {
+		var i FileSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.Path,
+			&i.Hash,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredFileSnapshots = `-- name: ListExpiredFileSnapshots :many
+SELECT id, project_path, path, hash, size, created_at FROM file_snapshots
+WHERE created_at < ?
+`
+
+func (q *Queries) ListExpiredFileSnapshots(ctx context.Context, createdAt sql.NullTime) ([]FileSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredFileSnapshots, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileSnapshot
+	for rows.Next() {
+		var i FileSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.Path,
+			&i.Hash,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFileOpens = `-- name: ListFileOpens :many
+SELECT id, path, open_count, last_opened FROM file_opens
+WHERE path LIKE ? ESCAPE '\'
+ORDER BY last_opened DESC
+LIMIT ?
+`
+
+type ListFileOpensParams struct {
+	Path  string
+	Limit int64
+}
+
+func (q *Queries) ListFileOpens(ctx context.Context, arg ListFileOpensParams) ([]FileOpen, error) {
+	rows, err := q.db.QueryContext(ctx, listFileOpens, arg.Path, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileOpen
+	for rows.Next() {
+		var i FileOpen
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.OpenCount,
+			&i.LastOpened,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFileOperations = `-- name: ListFileOperations :many
+SELECT id, project_path, kind, path, target_path, is_dir, trash_item_id, overwrite, replaced_trash_item_id, undone, created_at FROM file_operations
+WHERE project_path = ?
+ORDER BY id DESC
+`
+
+func (q *Queries) ListFileOperations(ctx context.Context, projectPath string) ([]FileOperation, error) {
+	rows, err := q.db.QueryContext(ctx, listFileOperations, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileOperation
+	for rows.Next() {
+		var i FileOperation
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.Kind,
+			&i.Path,
+			&i.TargetPath,
+			&i.IsDir,
+			&i.TrashItemID,
+			&i.Overwrite,
+			&i.ReplacedTrashItemID,
+			&i.Undone,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFileSnapshots = `-- name: ListFileSnapshots :many
+SELECT id, project_path, path, hash, size, created_at FROM file_snapshots
+WHERE path = ?
+ORDER BY id DESC
+`
+
+func (q *Queries) ListFileSnapshots(ctx context.Context, path string) ([]FileSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, listFileSnapshots, path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileSnapshot
+	for rows.Next() {
+		var i FileSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.Path,
+			&i.Hash,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listRecentProjects = `-- name: ListRecentProjects :many
 SELECT id, name, path, last_opened, created_at, updated_at FROM projects
 ORDER BY last_opened DESC
@@ -89,6 +703,198 @@ func (q *Queries) ListRecentProjects(ctx context.Context, limit int64) ([]Projec
 	return items, nil
 }
 
+const listTrashItems = `-- name: ListTrashItems :many
+SELECT id, project_path, original_path, trash_path, is_dir, size, deleted_at FROM trash_items
+WHERE project_path = ?
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) ListTrashItems(ctx context.Context, projectPath string) ([]TrashItem, error) {
+	rows, err := q.db.QueryContext(ctx, listTrashItems, projectPath)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TrashItem
+	for rows.Next() {
+		var i TrashItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectPath,
+			&i.OriginalPath,
+			&i.TrashPath,
+			&i.IsDir,
+			&i.Size,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkspaceRoots = `-- name: ListWorkspaceRoots :many
+SELECT id, workspace_id, path, name, position FROM workspace_roots
+WHERE workspace_id = ?
+ORDER BY position ASC, id ASC
+`
+
+func (q *Queries) ListWorkspaceRoots(ctx context.Context, workspaceID int64) ([]WorkspaceRoot, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkspaceRoots, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceRoot
+	for rows.Next() {
+		var i WorkspaceRoot
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.Path,
+			&i.Name,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkspaces = `-- name: ListWorkspaces :many
+SELECT id, name, last_opened, created_at, updated_at FROM workspaces
+ORDER BY last_opened DESC
+`
+
+func (q *Queries) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkspaces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Workspace
+	for rows.Next() {
+		var i Workspace
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.LastOpened,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordFileOpen = `-- name: RecordFileOpen :exec
+INSERT INTO file_opens (path)
+VALUES (?)
+ON CONFLICT (path) DO UPDATE
+SET open_count = open_count + 1, last_opened = CURRENT_TIMESTAMP
+`
+
+func (q *Queries) RecordFileOpen(ctx context.Context, path string) error {
+	_, err := q.db.ExecContext(ctx, recordFileOpen, path)
+	return err
+}
+
+const removeWorkspaceRoot = `-- name: RemoveWorkspaceRoot :exec
+DELETE FROM workspace_roots
+WHERE workspace_id = ? AND path = ?
+`
+
+type RemoveWorkspaceRootParams struct {
+	WorkspaceID int64
+	Path        string
+}
+
+func (q *Queries) RemoveWorkspaceRoot(ctx context.Context, arg RemoveWorkspaceRootParams) error {
+	_, err := q.db.ExecContext(ctx, removeWorkspaceRoot, arg.WorkspaceID, arg.Path)
+	return err
+}
+
+const renameWorkspace = `-- name: RenameWorkspace :exec
+UPDATE workspaces
+SET name = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type RenameWorkspaceParams struct {
+	Name string
+	ID   int64
+}
+
+func (q *Queries) RenameWorkspace(ctx context.Context, arg RenameWorkspaceParams) error {
+	_, err := q.db.ExecContext(ctx, renameWorkspace, arg.Name, arg.ID)
+	return err
+}
+
+const trimFileOperations = `-- name: TrimFileOperations :exec
+DELETE FROM file_operations
+WHERE project_path = ? AND id NOT IN (
+    SELECT id FROM file_operations
+    WHERE project_path = ?
+    ORDER BY id DESC LIMIT ?
+)
+`
+
+type TrimFileOperationsParams struct {
+	ProjectPath   string
+	ProjectPath_2 string
+	Limit         int64
+}
+
+func (q *Queries) TrimFileOperations(ctx context.Context, arg TrimFileOperationsParams) error {
+	_, err := q.db.ExecContext(ctx, trimFileOperations, arg.ProjectPath, arg.ProjectPath_2, arg.Limit)
+	return err
+}
+
+const updateFileOperationState = `-- name: UpdateFileOperationState :exec
+UPDATE file_operations
+SET undone = ?, trash_item_id = ?, replaced_trash_item_id = ?
+WHERE id = ?
+`
+
+type UpdateFileOperationStateParams struct {
+	Undone              bool
+	TrashItemID         sql.NullInt64
+	ReplacedTrashItemID sql.NullInt64
+	ID                  int64
+}
+
+func (q *Queries) UpdateFileOperationState(ctx context.Context, arg UpdateFileOperationStateParams) error {
+	_, err := q.db.ExecContext(ctx, updateFileOperationState,
+		arg.Undone,
+		arg.TrashItemID,
+		arg.ReplacedTrashItemID,
+		arg.ID,
+	)
+	return err
+}
+
 const updateProjectLastOpened = `-- name: UpdateProjectLastOpened :exec
 UPDATE projects
 SET last_opened = CURRENT_TIMESTAMP
@@ -99,3 +905,14 @@ func (q *Queries) UpdateProjectLastOpened(ctx context.Context, id int64) error {
 	_, err := q.db.ExecContext(ctx, updateProjectLastOpened, id)
 	return err
 }
+
+const updateWorkspaceLastOpened = `-- name: UpdateWorkspaceLastOpened :exec
+UPDATE workspaces
+SET last_opened = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) UpdateWorkspaceLastOpened(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceLastOpened, id)
+	return err
+}
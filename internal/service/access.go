@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AccessError is returned when a path outside the open project roots is requested
+type AccessError struct {
+	Op   string `json:"op"`   // Operation that was refused
+	Path string `json:"path"` // Requested path
+}
+
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("access denied: %s is outside the open projects", e.Path)
+}
+
+// AccessGuard restricts file system access to the open project roots and an allowlist of files.
+// Paths are compared after resolving symlinks, so a link can't be used to escape a root.
+type AccessGuard struct {
+	mu        sync.RWMutex
+	roots     map[string]bool // Resolved project roots
+	allowed   map[string]bool // Resolved paths of single files allowed outside the roots
+	audit     *log.Logger
+	auditFile *os.File
+}
+
+// NewAccessGuard creates a guard writing refused requests to the audit log at auditPath
+func NewAccessGuard(auditPath string) (*AccessGuard, error) {
+	if err := os.MkdirAll(filepath.Dir(auditPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(auditPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &AccessGuard{
+		roots:     make(map[string]bool),
+		allowed:   make(map[string]bool),
+		audit:     log.New(f, "", log.LstdFlags),
+		auditFile: f,
+	}, nil
+}
+
+// Close closes the audit log
+func (g *AccessGuard) Close() error {
+	return g.auditFile.Close()
+}
+
+// AddRoot opens a project root for access
+func (g *AccessGuard) AddRoot(path string) error {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.roots[resolved] = true
+	return nil
+}
+
+// RemoveRoot closes a project root
+func (g *AccessGuard) RemoveRoot(path string) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.roots, resolved)
+}
+
+// Allow permits access to a single file outside the project roots, e.g. the editor config
+func (g *AccessGuard) Allow(path string) error {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.allowed[resolved] = true
+	return nil
+}
+
+// Check returns an AccessError for the first path outside the open project roots and the allowlist.
+// Refused requests are written to the audit log.
+func (g *AccessGuard) Check(op string, paths ...string) error {
+	for _, path := range paths {
+		if g.permits(path) {
+			continue
+		}
+		g.audit.Printf("denied %s %q", op, path)
+		return &AccessError{Op: op, Path: path}
+	}
+	return nil
+}
+
+// permits reports whether a single path may be accessed
+func (g *AccessGuard) permits(path string) bool {
+	if path == "" || !filepath.IsAbs(path) {
+		return false
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.allowed[resolved] {
+		return true
+	}
+	for root := range g.roots {
+		if isSubPath(root, resolved) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns the absolute path with every symlink resolved.
+// For a path that doesn't exist yet, the deepest existing parent is resolved instead.
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		// Follow a dangling symlink to where a write through it would end up
+		if target, err := os.Readlink(path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			path = target
+			continue
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(path))
+		path = parent
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessGuardRemoveRoot(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	g, err := NewAccessGuard(auditPath)
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	root := t.TempDir()
+	file := filepath.Join(root, "file.txt")
+
+	if err := g.AddRoot(root); err != nil {
+		t.Fatalf("failed to add root: %v", err)
+	}
+	if err := g.Check("GetFileContent", file); err != nil {
+		t.Fatalf("access inside an open root was refused: %v", err)
+	}
+
+	g.RemoveRoot(root)
+	err = g.Check("GetFileContent", file)
+	if !errors.As(err, new(*AccessError)) {
+		t.Fatalf("access after removing the root returned %v, want an AccessError", err)
+	}
+
+	if err := g.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "denied GetFileContent") {
+		t.Errorf("audit log = %q, want the refused request", data)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// resolveWritePath follows symlinks so writes go to the link target instead of replacing the link.
+// Paths that don't exist yet are returned unchanged.
+func resolveWritePath(path string) (string, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return target, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	// A dangling symlink still points to where the file should be created
+	if link, linkErr := os.Readlink(path); linkErr == nil {
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(path), link)
+		}
+		return link, nil
+	}
+
+	return path, nil
+}
+
+// writeFileAtomic replaces a file through a synced temporary file and a rename, so readers never
+// see a partially written file. The original mode and owner are kept and symlinks are followed.
+func writeFileAtomic(path string, content []byte) error {
+	target, err := resolveWritePath(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := writeTempFile(target, content, info)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// writeTempFile writes content to a new synced temporary file next to target.
+// If info is not nil the temporary file gets its mode and owner, otherwise mode 0644.
+func writeTempFile(target string, content []byte, info os.FileInfo) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".edit4i-*")
+	if err != nil {
+		return "", err
+	}
+
+	fail := func(err error) (string, error) {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return fail(err)
+	}
+
+	mode := os.FileMode(0644)
+	if info != nil {
+		mode = info.Mode().Perm()
+		if err := copyOwner(f, info); err != nil {
+			return fail(err)
+		}
+	}
+	if err := f.Chmod(mode); err != nil {
+		return fail(err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// syncDir flushes a directory so a rename inside it survives a crash. Errors are ignored
+// since not every platform supports syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
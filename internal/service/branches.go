@@ -0,0 +1,537 @@
+package service
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// errDirtyWorktree is returned when an operation would overwrite local changes
+var errDirtyWorktree = errors.New("you have local changes, commit or stash them first")
+
+// CheckoutOptions controls what happens to local changes when switching branches
+type CheckoutOptions struct {
+	Stash bool `json:"stash"` // Stash local changes before switching instead of refusing
+	Force bool `json:"force"` // Discard local changes, untracked files are kept either way
+}
+
+// CreateBranch creates a branch at a revision, e.g. a commit hash, a branch or a tag, HEAD if empty
+func (s *GitService) CreateBranch(projectPath string, name string, startPoint string) (*BranchInfo, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	refName := plumbing.NewBranchReferenceName(name)
+	if err := refName.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid branch name %q: %w", name, err)
+	}
+	if _, err := repo.Reference(refName, false); err == nil {
+		return nil, fmt.Errorf("branch %s already exists", name)
+	}
+
+	commit, err := resolveCommit(repo, startPoint)
+	if err != nil {
+		return nil, err
+	}
+	ref := plumbing.NewHashReference(refName, commit.Hash)
+	if err := repo.Storer.SetReference(ref); err != nil {
+		return nil, fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return s.branchInfo(repo, cfg, ref, ""), nil
+}
+
+// CheckoutBranch switches to a branch. A remote branch like "origin/feature" is checked out
+// as a local branch tracking it, created if needed.
+// Local changes are refused unless opts asks to stash or discard them.
+func (s *GitService) CheckoutBranch(projectPath string, name string, opts CheckoutOptions) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	refName := plumbing.NewBranchReferenceName(name)
+	var tracking *config.Branch
+	if _, err := repo.Reference(refName, false); err != nil {
+		if err != plumbing.ErrReferenceNotFound {
+			return fmt.Errorf("failed to get branch: %w", err)
+		}
+		refName, tracking, err = s.trackingBranch(repo, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if head, err := repo.Head(); err == nil && head.Name() == refName {
+		return nil
+	}
+
+	// Everything that can stop the checkout is checked before local changes are stashed or discarded
+	targetHash, err := checkoutTarget(repo, refName, tracking, name)
+	if err != nil {
+		return err
+	}
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	targetFiles, err := commitFiles(repo, targetHash)
+	if err != nil {
+		return err
+	}
+	switchFiles := &treeMerge{files: targetFiles, conflicts: map[string]*mergeConflict{}}
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if err := checkUntrackedFiles(status, treeMergeChanges(headFiles, switchFiles)); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", name, err)
+	}
+
+	changes, err := localChanges(worktree)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(changes) == 0:
+	case opts.Force:
+		if err := restoreHeadFiles(repo, changes); err != nil {
+			return fmt.Errorf("failed to discard changes: %w", err)
+		}
+	case opts.Stash:
+		if err := stashPush(repo, StashOptions{}); err != nil {
+			return fmt.Errorf("failed to stash changes: %w", err)
+		}
+	default:
+		return errDirtyWorktree
+	}
+
+	if tracking != nil {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(refName, targetHash)); err != nil {
+			return fmt.Errorf("failed to create branch: %w", err)
+		}
+		if err := s.setBranchConfig(repo, tracking); err != nil {
+			return err
+		}
+	}
+
+	// The files of the branch replace those of HEAD
+	if err := applyTreeMerge(repo, headFiles, switchFiles, true); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", name, err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, refName)); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return nil
+}
+
+// checkoutTarget returns the commit a checkout switches to, the remote branch when a tracking branch is created
+func checkoutTarget(repo *git.Repository, refName plumbing.ReferenceName, tracking *config.Branch, name string) (plumbing.Hash, error) {
+	if tracking != nil {
+		remoteRef, err := repo.Reference(plumbing.ReferenceName("refs/remotes/"+name), true)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get remote branch: %w", err)
+		}
+		return remoteRef.Hash(), nil
+	}
+
+	target, err := repo.Reference(refName, true)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get branch: %w", err)
+	}
+	return target.Hash(), nil
+}
+
+// RenameBranch renames a local branch along with its upstream configuration
+func (s *GitService) RenameBranch(projectPath string, oldName string, newName string) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	oldRef, err := repo.Reference(plumbing.NewBranchReferenceName(oldName), false)
+	if err != nil {
+		return fmt.Errorf("branch not found: %s", oldName)
+	}
+	newRefName := plumbing.NewBranchReferenceName(newName)
+	if err := newRefName.Validate(); err != nil {
+		return fmt.Errorf("invalid branch name %q: %w", newName, err)
+	}
+	if _, err := repo.Reference(newRefName, false); err == nil {
+		return fmt.Errorf("branch %s already exists", newName)
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(newRefName, oldRef.Hash())); err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	// HEAD follows the branch it points to
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err == nil && head.Type() == plumbing.SymbolicReference && head.Target() == oldRef.Name() {
+		if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, newRefName)); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+	}
+
+	if err := repo.Storer.RemoveReference(oldRef.Name()); err != nil {
+		return fmt.Errorf("failed to remove old branch: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if branch, ok := cfg.Branches[oldName]; ok {
+		delete(cfg.Branches, oldName)
+		branch.Name = newName
+		cfg.Branches[newName] = branch
+		if err := repo.SetConfig(cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteBranch deletes a local branch. Unless force is set, it refuses to delete a branch
+// whose commits aren't merged into its upstream, or into HEAD if it has none.
+func (s *GitService) DeleteBranch(projectPath string, name string, force bool) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(name), false)
+	if err != nil {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+	head, err := repo.Head()
+	if err == nil && head.Name() == ref.Name() {
+		return fmt.Errorf("cannot delete the current branch %s", name)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if !force {
+		target := plumbing.HEAD
+		if upstream := upstreamRefName(cfg, name); upstream != "" {
+			if _, err := repo.Reference(upstream, false); err == nil {
+				target = upstream
+			}
+		}
+		merged, err := isMerged(repo, ref.Hash(), target)
+		if err != nil {
+			return err
+		}
+		if !merged {
+			return fmt.Errorf("branch %s is not fully merged, delete it with force to lose its commits", name)
+		}
+	}
+
+	if err := repo.Storer.RemoveReference(ref.Name()); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	if _, ok := cfg.Branches[name]; ok {
+		delete(cfg.Branches, name)
+		if err := repo.SetConfig(cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetUpstream sets the branch a local branch tracks, e.g. "origin/main" or another local branch.
+// An empty upstream removes the tracking.
+func (s *GitService) SetUpstream(projectPath string, branch string, upstream string) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName(branch), false); err != nil {
+		return fmt.Errorf("branch not found: %s", branch)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if upstream == "" {
+		if b, ok := cfg.Branches[branch]; ok {
+			b.Remote = ""
+			b.Merge = ""
+			if b.Rebase == "" {
+				delete(cfg.Branches, branch)
+			}
+			if err := repo.SetConfig(cfg); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+		}
+		return nil
+	}
+
+	tracking := &config.Branch{Name: branch}
+	if b, ok := cfg.Branches[branch]; ok {
+		tracking.Rebase = b.Rebase
+	}
+	if _, err := repo.Reference(plumbing.ReferenceName("refs/remotes/"+upstream), false); err == nil {
+		remote, remoteBranch := splitRemoteBranch(cfg, upstream)
+		if remote == "" {
+			return fmt.Errorf("no remote matches %s", upstream)
+		}
+		tracking.Remote = remote
+		tracking.Merge = plumbing.NewBranchReferenceName(remoteBranch)
+	} else if _, err := repo.Reference(plumbing.NewBranchReferenceName(upstream), false); err == nil {
+		tracking.Remote = "."
+		tracking.Merge = plumbing.NewBranchReferenceName(upstream)
+	} else {
+		return fmt.Errorf("upstream branch not found: %s", upstream)
+	}
+
+	return s.setBranchConfig(repo, tracking)
+}
+
+// trackingBranch returns the local branch and the tracking configuration used to check out a remote branch
+func (s *GitService) trackingBranch(repo *git.Repository, name string) (plumbing.ReferenceName, *config.Branch, error) {
+	if _, err := repo.Reference(plumbing.ReferenceName("refs/remotes/"+name), false); err != nil {
+		return "", nil, fmt.Errorf("branch not found: %s", name)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	remote, localName := splitRemoteBranch(cfg, name)
+	if remote == "" {
+		return "", nil, fmt.Errorf("no remote matches %s", name)
+	}
+
+	refName := plumbing.NewBranchReferenceName(localName)
+	if _, err := repo.Reference(refName, false); err == nil {
+		// The local branch already exists, it is checked out as is
+		return refName, nil, nil
+	}
+	return refName, &config.Branch{Name: localName, Remote: remote, Merge: refName}, nil
+}
+
+// setBranchConfig stores the configuration of a branch
+func (s *GitService) setBranchConfig(repo *git.Repository, branch *config.Branch) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.Branches[branch.Name] = branch
+	if err := repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// branchInfo describes a branch reference with its upstream and last commit.
+// current is the name of the checked out branch.
+func (s *GitService) branchInfo(repo *git.Repository, cfg *config.Config, ref *plumbing.Reference, current string) *BranchInfo {
+	info := &BranchInfo{
+		Name:     ref.Name().Short(),
+		IsRemote: ref.Name().IsRemote(),
+		IsHead:   ref.Name().IsBranch() && ref.Name().Short() == current,
+	}
+
+	if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+		info.LastCommit = commitInfo(commit)
+	}
+
+	if info.IsRemote {
+		return info
+	}
+	upstream := upstreamRefName(cfg, info.Name)
+	if upstream == "" {
+		return info
+	}
+	info.Upstream = upstream.Short()
+	if upstreamRef, err := repo.Reference(upstream, true); err == nil {
+		info.Ahead, info.Behind, _ = aheadBehind(repo, ref.Hash(), upstreamRef.Hash())
+	}
+	return info
+}
+
+// upstreamRefName returns the reference tracked by a local branch, empty if it has none
+func upstreamRefName(cfg *config.Config, branch string) plumbing.ReferenceName {
+	b, ok := cfg.Branches[branch]
+	if !ok || b.Merge == "" {
+		return ""
+	}
+	if b.Remote == "" || b.Remote == "." {
+		return b.Merge
+	}
+	return plumbing.NewRemoteReferenceName(b.Remote, b.Merge.Short())
+}
+
+// splitRemoteBranch splits a remote branch like "origin/feature" into its remote and branch names.
+// Remote names may contain slashes, the longest configured remote wins.
+func splitRemoteBranch(cfg *config.Config, name string) (string, string) {
+	remote := ""
+	for remoteName := range cfg.Remotes {
+		if strings.HasPrefix(name, remoteName+"/") && len(remoteName) > len(remote) {
+			remote = remoteName
+		}
+	}
+	if remote == "" {
+		return "", ""
+	}
+	return remote, strings.TrimPrefix(name, remote+"/")
+}
+
+// isDirty reports whether the index or the tracked files have changes, untracked files don't count
+func isDirty(worktree *git.Worktree) (bool, error) {
+	changes, err := localChanges(worktree)
+	return len(changes) > 0, err
+}
+
+// localChanges returns the files changed in the index or the working tree, untracked files left out
+func localChanges(worktree *git.Worktree) ([]string, error) {
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	var changes []string
+	for file, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked {
+			continue
+		}
+		if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
+			changes = append(changes, file)
+		}
+	}
+	return changes, nil
+}
+
+// isMerged reports whether a commit is reachable from a reference
+func isMerged(repo *git.Repository, hash plumbing.Hash, target plumbing.ReferenceName) (bool, error) {
+	targetRef, err := repo.Reference(target, true)
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s: %w", target, err)
+	}
+	if targetRef.Hash() == hash {
+		return true, nil
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit: %w", err)
+	}
+	targetCommit, err := repo.CommitObject(targetRef.Hash())
+	if err != nil {
+		return false, fmt.Errorf("failed to get commit: %w", err)
+	}
+	return commit.IsAncestor(targetCommit)
+}
+
+// aheadBehind counts the commits reachable from a but not from b, and from b but not from a
+func aheadBehind(repo *git.Repository, a, b plumbing.Hash) (int, int, error) {
+	onlyA, onlyB, err := exclusiveCommits(repo, a, b)
+	return len(onlyA), len(onlyB), err
+}
+
+// exclusiveCommits returns the commits reachable from a but not from b, and from b but not from a.
+// Both histories are walked newest first and the walk stops once every pending commit is
+// reachable from both sides, like git does.
+func exclusiveCommits(repo *git.Repository, a, b plumbing.Hash) ([]plumbing.Hash, []plumbing.Hash, error) {
+	if a == b {
+		return nil, nil, nil
+	}
+
+	const (
+		fromA = 1 << iota
+		fromB
+	)
+	flags := make(map[plumbing.Hash]int)
+	done := make(map[plumbing.Hash]int)
+	queue := &commitQueue{}
+
+	push := func(hash plumbing.Hash, flag int) error {
+		if flags[hash]|flag == flags[hash] {
+			return nil
+		}
+		flags[hash] |= flag
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get commit: %w", err)
+		}
+		heap.Push(queue, commit)
+		return nil
+	}
+	if err := push(a, fromA); err != nil {
+		return nil, nil, err
+	}
+	if err := push(b, fromB); err != nil {
+		return nil, nil, err
+	}
+
+	for queue.Len() > 0 {
+		stale := true
+		for _, commit := range *queue {
+			if flags[commit.Hash] != fromA|fromB {
+				stale = false
+				break
+			}
+		}
+		if stale {
+			break
+		}
+
+		commit := heap.Pop(queue).(*object.Commit)
+		flag := flags[commit.Hash]
+		if done[commit.Hash] == flag {
+			continue
+		}
+		done[commit.Hash] = flag
+		for _, parent := range commit.ParentHashes {
+			if err := push(parent, flag); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var onlyA, onlyB []plumbing.Hash
+	for hash, flag := range flags {
+		switch flag {
+		case fromA:
+			onlyA = append(onlyA, hash)
+		case fromB:
+			onlyB = append(onlyB, hash)
+		}
+	}
+	return onlyA, onlyB, nil
+}
+
+// commitQueue is a heap of commits, most recently committed first
+type commitQueue []*object.Commit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].Committer.When.After(q[j].Committer.When)
+}
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)   { *q = append(*q, x.(*object.Commit)) }
+func (q *commitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	commit := old[n-1]
+	*q = old[:n-1]
+	return commit
+}
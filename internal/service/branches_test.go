@@ -0,0 +1,145 @@
+package service
+
+import "testing"
+
+// branch creates a branch at HEAD with one more commit writing a file, then checks out the starting branch again
+func (r *testRepo) branch(name, file, content string) {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	s := NewGitService()
+	if _, err := s.CreateBranch(r.dir, name, ""); err != nil {
+		r.t.Fatalf("failed to create branch %s: %v", name, err)
+	}
+	if err := s.CheckoutBranch(r.dir, name, CheckoutOptions{}); err != nil {
+		r.t.Fatalf("failed to checkout %s: %v", name, err)
+	}
+	r.write(file, content)
+	r.add(file)
+	r.commit("change " + file)
+	if err := s.CheckoutBranch(r.dir, head.Name().Short(), CheckoutOptions{}); err != nil {
+		r.t.Fatalf("failed to checkout %s: %v", head.Name().Short(), err)
+	}
+}
+
+func (r *testRepo) headBranch() string {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return head.Name().Short()
+}
+
+func TestCheckoutBranchKeepsUntrackedFiles(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "file.txt", "two\n")
+	r.write("notes.txt", "notes\n")
+
+	if err := NewGitService().CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout: %v", err)
+	}
+
+	if got := r.headBranch(); got != "feature" {
+		t.Errorf("HEAD = %s, want feature", got)
+	}
+	if content, _ := r.read("file.txt"); content != "two\n" {
+		t.Errorf("file.txt = %q, want %q", content, "two\n")
+	}
+	if content, ok := r.read("notes.txt"); !ok || content != "notes\n" {
+		t.Errorf("untracked file = %q (exists %v), want %q", content, ok, "notes\n")
+	}
+}
+
+func TestCheckoutBranchRemovesFilesMissingFromBranch(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "added.txt", "added\n")
+	s := NewGitService()
+
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout feature: %v", err)
+	}
+	if _, ok := r.read("added.txt"); !ok {
+		t.Fatal("file of the branch is missing")
+	}
+	if err := s.CheckoutBranch(r.dir, "master", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout master: %v", err)
+	}
+	if _, ok := r.read("added.txt"); ok {
+		t.Error("file missing from master is still in the working copy")
+	}
+	if got := r.status("added.txt"); got != "  " {
+		t.Errorf("status of added.txt = %q, want it gone from the index", got)
+	}
+}
+
+func TestCheckoutBranchRefusesToOverwriteUntrackedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "added.txt", "added\n")
+	r.write("added.txt", "mine\n")
+
+	for _, opts := range []CheckoutOptions{{}, {Force: true}} {
+		if err := NewGitService().CheckoutBranch(r.dir, "feature", opts); err == nil {
+			t.Errorf("checkout with %+v succeeded over an untracked file", opts)
+		}
+		if content, _ := r.read("added.txt"); content != "mine\n" {
+			t.Errorf("untracked file = %q, want %q", content, "mine\n")
+		}
+		if got := r.headBranch(); got != "master" {
+			t.Errorf("HEAD = %s, want master", got)
+		}
+	}
+}
+
+func TestCheckoutBranchForceDiscardsTrackedChanges(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n", "other.txt": "other\n"})
+	r.branch("feature", "file.txt", "two\n")
+	r.write("other.txt", "changed\n")
+	r.write("notes.txt", "notes\n")
+	s := NewGitService()
+
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != errDirtyWorktree {
+		t.Fatalf("checkout with local changes = %v, want %v", err, errDirtyWorktree)
+	}
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{Force: true}); err != nil {
+		t.Fatalf("failed to checkout: %v", err)
+	}
+
+	if content, _ := r.read("other.txt"); content != "other\n" {
+		t.Errorf("other.txt = %q, want %q", content, "other\n")
+	}
+	if content, _ := r.read("file.txt"); content != "two\n" {
+		t.Errorf("file.txt = %q, want %q", content, "two\n")
+	}
+	if _, ok := r.read("notes.txt"); !ok {
+		t.Error("untracked file was deleted")
+	}
+}
+
+func TestCheckoutBranchStashKeepsChangesWhenBlocked(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "added.txt", "added\n")
+	r.write("file.txt", "changed\n")
+	r.write("added.txt", "mine\n")
+	s := NewGitService()
+
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{Stash: true}); err == nil {
+		t.Fatal("checkout succeeded over an untracked file")
+	}
+
+	if content, _ := r.read("file.txt"); content != "changed\n" {
+		t.Errorf("file.txt = %q, want the local change kept in place", content)
+	}
+	stashes, err := s.ListStashes(r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stashes) != 0 {
+		t.Errorf("%d stashes were created by a failed checkout", len(stashes))
+	}
+	if got := r.headBranch(); got != "master" {
+		t.Errorf("HEAD = %s, want master", got)
+	}
+}
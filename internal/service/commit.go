@@ -0,0 +1,322 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// trailerPattern matches a trailer line like "Signed-off-by: Jane <jane@example.com>"
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+
+// CommitIdentity is a name and email used as author or committer
+type CommitIdentity struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CommitOptions contains options for creating a commit
+type CommitOptions struct {
+	Amend      bool            `json:"amend"`      // Replace the last commit, keeping its author unless overridden
+	SignOff    bool            `json:"signOff"`    // Add a Signed-off-by trailer with the committer identity
+	AllowEmpty bool            `json:"allowEmpty"` // Commit even if nothing changed
+	Author     *CommitIdentity `json:"author"`     // Overrides the configured identity as author
+	Committer  *CommitIdentity `json:"committer"`  // Overrides the configured identity as committer
+	Sign       bool            `json:"sign"`       // Sign with user.signingkey, as gpg.format says: openpgp or ssh
+}
+
+// CommitWithOptions commits the staged changes and returns the new commit.
+// During a merge, the commit concludes it.
+func (s *GitService) CommitWithOptions(projectPath string, message string, opts CommitOptions) (*CommitInfo, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, errors.New("the commit message is empty")
+	}
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	mergeHead, merging, err := readGitFile(repo, mergeHeadFile)
+	if err != nil {
+		return nil, err
+	}
+	if merging && opts.Amend {
+		return nil, errors.New("cannot amend during a merge, commit or abort it first")
+	}
+
+	files, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := writeTree(repo, files)
+	if err != nil {
+		return nil, err
+	}
+
+	// The parents are HEAD, those of HEAD when amending, none for the first commit
+	var head *plumbing.Reference
+	var headCommit *object.Commit
+	var parents []plumbing.Hash
+	head, err = repo.Head()
+	switch {
+	case err == plumbing.ErrReferenceNotFound:
+		if opts.Amend {
+			return nil, errors.New("there is no commit to amend")
+		}
+		if head, err = repo.Storer.Reference(plumbing.HEAD); err != nil {
+			return nil, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	default:
+		if headCommit, err = repo.CommitObject(head.Hash()); err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		parents = []plumbing.Hash{head.Hash()}
+		if opts.Amend {
+			parents = headCommit.ParentHashes
+		}
+	}
+	if merging {
+		parents = append(parents, plumbing.NewHash(strings.TrimSpace(mergeHead)))
+	}
+
+	if !opts.AllowEmpty && !merging {
+		previous, err := parentTree(repo, parents)
+		if err != nil {
+			return nil, err
+		}
+		if tree == previous {
+			return nil, errors.New("nothing to commit, stage some changes first")
+		}
+	}
+
+	author, committer, err := commitSignatures(repo, opts, headCommit)
+	if err != nil {
+		return nil, err
+	}
+	message = strings.TrimSpace(message) + "\n"
+	if opts.SignOff {
+		message = addTrailer(message, fmt.Sprintf("Signed-off-by: %s <%s>", committer.Name, committer.Email))
+	}
+
+	commit := &object.Commit{
+		Author:       *author,
+		Committer:    *committer,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: parents,
+	}
+	if opts.Sign {
+		if commit.PGPSignature, err = signCommit(repo, commit); err != nil {
+			return nil, err
+		}
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return nil, fmt.Errorf("failed to encode commit: %w", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store commit: %w", err)
+	}
+
+	if head.Type() == plumbing.SymbolicReference {
+		// HEAD of a repository without commits points to an unborn branch
+		head = plumbing.NewHashReference(head.Target(), hash)
+	}
+	if err := updateHead(repo, head, hash); err != nil {
+		return nil, err
+	}
+	if merging {
+		if err := removeGitFiles(repo, mergeHeadFile, mergeMsgFile, mergeModeFile); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	return commitInfo(created), nil
+}
+
+// parentTree returns the tree of the first parent, the empty tree hash for a first commit
+func parentTree(repo *git.Repository, parents []plumbing.Hash) (plumbing.Hash, error) {
+	if len(parents) == 0 {
+		return writeTree(repo, map[string]treeFile{})
+	}
+	parent, err := repo.CommitObject(parents[0])
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get commit: %w", err)
+	}
+	return parent.TreeHash, nil
+}
+
+// commitSignatures picks the author and committer of a commit. Overrides win over the configured identity,
+// the committer stands in when none is configured, and an amended commit keeps its author.
+func commitSignatures(repo *git.Repository, opts CommitOptions, amended *object.Commit) (author, committer *object.Signature, err error) {
+	now := time.Now()
+	for _, identity := range []*CommitIdentity{opts.Author, opts.Committer} {
+		if identity == nil {
+			continue
+		}
+		if strings.TrimSpace(identity.Name) == "" || strings.TrimSpace(identity.Email) == "" {
+			return nil, nil, errors.New("the author and committer need a name and an email")
+		}
+		if strings.ContainsAny(identity.Name+identity.Email, "<>\n") {
+			return nil, nil, fmt.Errorf("invalid identity %s <%s>", identity.Name, identity.Email)
+		}
+	}
+
+	switch {
+	case opts.Committer != nil:
+		committer = &object.Signature{Name: opts.Committer.Name, Email: opts.Committer.Email, When: now}
+	case opts.Author != nil:
+		if committer, err = gitSignature(repo); err != nil {
+			committer = &object.Signature{Name: opts.Author.Name, Email: opts.Author.Email, When: now}
+		}
+	default:
+		if committer, err = gitSignature(repo); err != nil {
+			return nil, nil, fmt.Errorf("%w, or set an author for the commit", err)
+		}
+	}
+
+	switch {
+	case opts.Author != nil:
+		author = &object.Signature{Name: opts.Author.Name, Email: opts.Author.Email, When: now}
+	case opts.Amend && amended != nil:
+		author = &amended.Author
+	default:
+		copied := *committer
+		author = &copied
+	}
+	return author, committer, nil
+}
+
+// addTrailer appends a trailer to a commit message, in the trailer block if it already ends with one
+func addTrailer(message string, trailer string) string {
+	message = strings.TrimRight(message, "\n")
+	lines := strings.Split(message, "\n")
+	for _, line := range lines {
+		if line == trailer {
+			return message + "\n"
+		}
+	}
+
+	last := lines[len(lines)-1]
+	if len(lines) > 1 && trailerPattern.MatchString(last) {
+		return message + "\n" + trailer + "\n"
+	}
+	return message + "\n\n" + trailer + "\n"
+}
+
+// signCommit signs a commit like git does, with gpg for openpgp keys or ssh-keygen for ssh keys
+func signCommit(repo *git.Repository, commit *object.Commit) (string, error) {
+	obj := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(obj); err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+	reader, err := obj.Reader()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode commit: %w", err)
+	}
+
+	format, err := configOption(repo, "gpg", "", "format")
+	if err != nil {
+		return "", err
+	}
+	key, err := configOption(repo, "user", "", "signingkey")
+	if err != nil {
+		return "", err
+	}
+
+	var cmd *exec.Cmd
+	switch format {
+	case "", "openpgp":
+		program, err := configOption(repo, "gpg", "", "program")
+		if err != nil {
+			return "", err
+		}
+		if program == "" {
+			program = "gpg"
+		}
+		args := []string{"--status-fd=2", "-bsa"}
+		if key != "" {
+			args = append(args, "-u", key)
+		}
+		cmd = exec.Command(program, args...)
+	case "ssh":
+		if key == "" {
+			return "", errors.New("user.signingkey must be set to sign with ssh")
+		}
+		program, err := configOption(repo, "gpg", "ssh", "program")
+		if err != nil {
+			return "", err
+		}
+		if program == "" {
+			program = "ssh-keygen"
+		}
+		keyFile, cleanup, err := sshKeyFile(key)
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		cmd = exec.Command(program, "-Y", "sign", "-n", "git", "-f", keyFile)
+	default:
+		return "", fmt.Errorf("unsupported signing format %q", format)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to sign commit: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return "", errors.New("failed to sign commit: the signing program returned no signature")
+	}
+	return stdout.String(), nil
+}
+
+// sshKeyFile returns the path of an ssh signing key. A literal "key::" public key is written to a
+// temporary file, the private key then comes from the ssh agent.
+func sshKeyFile(key string) (string, func(), error) {
+	if literal, ok := strings.CutPrefix(key, "key::"); ok {
+		file, err := os.CreateTemp("", "signing-key-*.pub")
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to write signing key: %w", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(literal + "\n"); err != nil {
+			os.Remove(file.Name())
+			return "", nil, fmt.Errorf("failed to write signing key: %w", err)
+		}
+		return file.Name(), func() { os.Remove(file.Name()) }, nil
+	}
+
+	if rest, ok := strings.CutPrefix(key, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		key = filepath.Join(home, rest)
+	}
+	return key, func() {}, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -35,6 +36,18 @@ type EditorConfig struct {
 			SelectionForeground string `json:"selectionForeground" mapstructure:"selectionForeground"`
 		} `json:"theme" mapstructure:"theme"`
 	} `json:"terminal" mapstructure:"terminal"`
+	Files struct {
+		Encoding          string   `json:"encoding" mapstructure:"encoding"`                   // Encoding of new files, e.g. "utf-8"
+		LineEnding        string   `json:"lineEnding" mapstructure:"lineEnding"`               // "lf", "crlf", "cr" or "auto" for the platform default
+		BOM               bool     `json:"bom" mapstructure:"bom"`                             // Whether new files start with a byte order mark
+		LargeFileSizeMB   int      `json:"largeFileSizeMB" mapstructure:"largeFileSizeMB"`     // Bigger files are opened in chunks, 0 disables it
+		TrashMaxAgeDays   int      `json:"trashMaxAgeDays" mapstructure:"trashMaxAgeDays"`     // Trashed files are purged after this many days, 0 keeps them
+		TrashMaxSizeMB    int      `json:"trashMaxSizeMB" mapstructure:"trashMaxSizeMB"`       // The oldest trashed files are purged above this size, 0 disables the limit
+		HistoryMaxAgeDays int      `json:"historyMaxAgeDays" mapstructure:"historyMaxAgeDays"` // Snapshots are deleted after this many days, 0 keeps them
+		HistoryMaxEntries int      `json:"historyMaxEntries" mapstructure:"historyMaxEntries"` // Snapshots kept per file, 0 keeps all of them
+		Exclude           []string `json:"exclude" mapstructure:"exclude"`                     // Glob patterns of entries left out of the file tree
+		ShowHidden        bool     `json:"showHidden" mapstructure:"showHidden"`               // Show dotfiles in the file tree
+	} `json:"files" mapstructure:"files"`
 	Keyboard struct {
 		CustomBindings map[string]KeyBinding `json:"customBindings" mapstructure:"customBindings"`
 	} `json:"keyboard" mapstructure:"keyboard"`
@@ -79,6 +92,17 @@ func NewConfigService() (*ConfigService, error) {
 	v.SetConfigFile(configPath)
 	v.SetConfigType("yaml")
 
+	// Defaults for settings added after the config file was created
+	v.SetDefault("files.encoding", "utf-8")
+	v.SetDefault("files.lineEnding", "auto")
+	v.SetDefault("files.largeFileSizeMB", 0)
+	v.SetDefault("files.trashMaxAgeDays", 30)
+	v.SetDefault("files.trashMaxSizeMB", 1024)
+	v.SetDefault("files.historyMaxAgeDays", 30)
+	v.SetDefault("files.historyMaxEntries", 50)
+	v.SetDefault("files.exclude", defaultFilesExclude)
+	v.SetDefault("files.showHidden", true)
+
 	if err := v.ReadInConfig(); err != nil {
 		return nil, err
 	}
@@ -99,6 +123,24 @@ func (s *ConfigService) GetConfig() *EditorConfig {
 	return s.config
 }
 
+// FileSettings returns the settings of the file service
+func (c *EditorConfig) FileSettings() FileSettings {
+	return FileSettings{
+		DefaultFormat: FileFormat{
+			Encoding:   c.Files.Encoding,
+			LineEnding: c.Files.LineEnding,
+			BOM:        c.Files.BOM,
+		},
+		LargeFileThreshold: int64(c.Files.LargeFileSizeMB) << 20,
+		TrashMaxAge:        time.Duration(c.Files.TrashMaxAgeDays) * 24 * time.Hour,
+		TrashMaxSize:       int64(c.Files.TrashMaxSizeMB) << 20,
+		HistoryMaxAge:      time.Duration(c.Files.HistoryMaxAgeDays) * 24 * time.Hour,
+		HistoryMaxEntries:  c.Files.HistoryMaxEntries,
+		Exclude:            c.Files.Exclude,
+		ShowHidden:         c.Files.ShowHidden,
+	}
+}
+
 // OpenConfigFile opens the config file in the editor
 func (s *ConfigService) OpenConfigFile() string {
 	return s.configPath
@@ -129,6 +171,23 @@ terminal:
     selectionBackground: "#3e4451"
     selectionForeground: "#d1d5db"
 
+files:
+  encoding: utf-8
+  lineEnding: auto  # lf, crlf, cr or auto for the platform default
+  bom: false
+  largeFileSizeMB: 0  # Bigger files are opened in chunks, 0 disables it. The editor can't show chunked files yet
+  trashMaxAgeDays: 30  # Deleted files are purged from the trash after this many days, 0 keeps them
+  trashMaxSizeMB: 1024  # The oldest deleted files are purged above this size, 0 disables the limit
+  historyMaxAgeDays: 30  # Local history snapshots are deleted after this many days, 0 keeps them
+  historyMaxEntries: 50  # Local history snapshots kept per file, 0 keeps all of them
+  showHidden: true  # Show dotfiles in the file tree
+  exclude:  # Glob patterns of entries left out of the file tree, gitignored entries are shown dimmed
+    - .git
+    - .svn
+    - .hg
+    - .DS_Store
+    - Thumbs.db
+
 keyboard:
   customBindings: {}`
 
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Sides to resolve a conflict region with
+const (
+	ResolveOurs   = "ours"
+	ResolveTheirs = "theirs"
+	ResolveBoth   = "both" // Ours followed by theirs
+	ResolveBase   = "base" // The common ancestor, only with diff3 markers
+)
+
+// Conflict markers, followed by a label except for the separator
+const (
+	markerOurs      = "<<<<<<<"
+	markerBase      = "|||||||"
+	markerSeparator = "======="
+	markerTheirs    = ">>>>>>>"
+)
+
+// ConflictVersion is the version of a conflicted file on one side of the merge
+type ConflictVersion struct {
+	Hash    string `json:"hash"`
+	Content string `json:"content"` // Empty for binary files
+}
+
+// ConflictRegion is a block between conflict markers in the working copy of a file
+type ConflictRegion struct {
+	Index       int    `json:"index"`
+	StartLine   int    `json:"startLine"` // Line of the <<<<<<< marker, 1-based
+	EndLine     int    `json:"endLine"`   // Line of the >>>>>>> marker
+	OursLabel   string `json:"oursLabel"`
+	TheirsLabel string `json:"theirsLabel"`
+	Ours        string `json:"ours"`
+	Base        string `json:"base"`    // Only with diff3 markers
+	HasBase     bool   `json:"hasBase"` // Whether the region has a ||||||| base section
+	Theirs      string `json:"theirs"`
+}
+
+// ConflictDetails contains the versions of a conflicted file and the conflict regions of its working copy
+type ConflictDetails struct {
+	Path     string           `json:"path"`
+	Type     string           `json:"type"` // One of the Conflict constants
+	IsBinary bool             `json:"isBinary"`
+	Base     *ConflictVersion `json:"base"`    // Nil if the file was added on both sides
+	Ours     *ConflictVersion `json:"ours"`    // Nil if we deleted the file
+	Theirs   *ConflictVersion `json:"theirs"`  // Nil if they deleted the file
+	Content  string           `json:"content"` // Working copy, with conflict markers
+	Regions  []ConflictRegion `json:"regions"`
+}
+
+// GetConflict returns the base, ours and theirs versions of a conflicted file with the regions left to resolve
+func (s *GitService) GetConflict(projectPath string, file string) (*ConflictDetails, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	stages, err := conflictStages(repo, file)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &ConflictDetails{Path: file, Type: conflictType(stages), Regions: []ConflictRegion{}}
+	for stage, entry := range stages {
+		content, err := readBlob(repo, entry.Hash)
+		if err != nil {
+			return nil, err
+		}
+		version := &ConflictVersion{Hash: entry.Hash.String()}
+		if !entry.Mode.IsRegular() || isBinaryContent(content) {
+			details.IsBinary = true
+		} else {
+			version.Content = string(content)
+		}
+
+		switch stage {
+		case index.AncestorMode:
+			details.Base = version
+		case index.OurMode:
+			details.Ours = version
+		case index.TheirMode:
+			details.Theirs = version
+		}
+	}
+	if details.IsBinary {
+		for _, version := range []*ConflictVersion{details.Base, details.Ours, details.Theirs} {
+			if version != nil {
+				version.Content = ""
+			}
+		}
+		return details, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(projectPath, filepath.FromSlash(file)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	details.Content = string(content)
+	details.Regions = parseConflictRegions(details.Content)
+	return details, nil
+}
+
+// ResolveConflictRegion replaces a conflict region of the working copy with one of its sides.
+// The file stays conflicted until MarkResolved, it returns the remaining regions.
+func (s *GitService) ResolveConflictRegion(projectPath string, file string, region int, side string) (*ConflictDetails, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, err := conflictStages(repo, file); err != nil {
+		return nil, err
+	}
+
+	fullPath := filepath.Join(projectPath, filepath.FromSlash(file))
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	regions := parseConflictRegions(string(content))
+	if region < 0 || region >= len(regions) {
+		return nil, fmt.Errorf("conflict region %d does not exist in %s", region, file)
+	}
+	r := regions[region]
+
+	var resolved string
+	switch side {
+	case ResolveOurs:
+		resolved = r.Ours
+	case ResolveTheirs:
+		resolved = r.Theirs
+	case ResolveBoth:
+		resolved = r.Ours + r.Theirs
+	case ResolveBase:
+		if !r.HasBase {
+			return nil, fmt.Errorf("conflict region %d of %s has no base section", region, file)
+		}
+		resolved = r.Base
+	default:
+		return nil, fmt.Errorf("unknown side %q", side)
+	}
+
+	lines := splitLinesKeepEOL(string(content))
+	var out strings.Builder
+	out.WriteString(strings.Join(lines[:r.StartLine-1], ""))
+	out.WriteString(resolved)
+	out.WriteString(strings.Join(lines[r.EndLine:], ""))
+
+	if err := os.WriteFile(fullPath, []byte(out.String()), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return s.GetConflict(projectPath, file)
+}
+
+// MarkResolved stages the working copy of a conflicted file as its resolution, a deleted file resolves as deleted
+func (s *GitService) MarkResolved(projectPath string, file string) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if _, err := conflictStages(repo, file); err != nil {
+		return err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	root := worktree.Filesystem.Root()
+	resolved, err := workingTreeFile(repo, root, file)
+	switch {
+	case os.IsNotExist(err):
+		removeIndexEntries(idx, file)
+	case err != nil:
+		return err
+	default:
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		setIndexEntry(idx, file, resolved, int(info.Size()))
+	}
+
+	sortIndex(idx)
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// conflictStages returns the conflict stages of a file in the index.
+// It fails for paths leaving the repository and for files without conflicts.
+func conflictStages(repo *git.Repository, file string) (map[index.Stage]*index.Entry, error) {
+	if !filepath.IsLocal(filepath.FromSlash(file)) {
+		return nil, fmt.Errorf("invalid path %s", file)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	stages := make(map[index.Stage]*index.Entry)
+	for _, entry := range idx.Entries {
+		if entry.Name == file && entry.Stage != 0 {
+			stages[entry.Stage] = entry
+		}
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("%s has no conflicts", file)
+	}
+	return stages, nil
+}
+
+// parseConflictRegions finds the conflict regions of a file, unterminated ones are ignored
+func parseConflictRegions(content string) []ConflictRegion {
+	regions := []ConflictRegion{}
+	var current *ConflictRegion
+	section := ""
+	var ours, base, theirs strings.Builder
+
+	for i, line := range splitLinesKeepEOL(content) {
+		marker, label := conflictMarker(line)
+		switch {
+		case marker == markerOurs:
+			current = &ConflictRegion{StartLine: i + 1, OursLabel: label}
+			section = markerOurs
+			ours.Reset()
+			base.Reset()
+			theirs.Reset()
+		case current == nil:
+		case marker == markerBase && section == markerOurs:
+			section = markerBase
+			current.HasBase = true
+		case marker == markerSeparator && section != markerSeparator:
+			section = markerSeparator
+		case marker == markerTheirs && section == markerSeparator:
+			current.EndLine = i + 1
+			current.TheirsLabel = label
+			current.Index = len(regions)
+			current.Ours = ours.String()
+			current.Base = base.String()
+			current.Theirs = theirs.String()
+			regions = append(regions, *current)
+			current = nil
+		case section == markerOurs:
+			ours.WriteString(line)
+		case section == markerBase:
+			base.WriteString(line)
+		default:
+			theirs.WriteString(line)
+		}
+	}
+	return regions
+}
+
+// conflictMarker returns the marker starting a line and its label, or an empty marker
+func conflictMarker(line string) (marker string, label string) {
+	text := strings.TrimRight(line, "\r\n")
+	for _, m := range []string{markerOurs, markerBase, markerSeparator, markerTheirs} {
+		if !strings.HasPrefix(text, m) {
+			continue
+		}
+		rest := text[len(m):]
+		if m == markerSeparator && rest == "" {
+			return m, ""
+		}
+		if m != markerSeparator && (rest == "" || rest[0] == ' ') {
+			return m, strings.TrimPrefix(rest, " ")
+		}
+	}
+	return "", ""
+}
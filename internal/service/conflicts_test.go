@@ -0,0 +1,104 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// conflictRepo returns a repository whose merge of branch feature conflicts on file.txt
+func conflictRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "file.txt", "theirs\n")
+	r.write("file.txt", "ours\n")
+	r.add("file.txt")
+	r.commit("ours")
+
+	result, err := NewGitService().MergeBranch(r.dir, "feature", MergeOptions{})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.Status != MergeConflicted {
+		t.Fatalf("merge status = %s, want %s", result.Status, MergeConflicted)
+	}
+	return r
+}
+
+func TestConflictPathsOutsideRepository(t *testing.T) {
+	r := conflictRepo(t)
+	outside := filepath.Join(filepath.Dir(r.dir), "outside.txt")
+	content := "<<<<<<< HEAD\nmine\n=======\nother\n>>>>>>> feature\n"
+	if err := os.WriteFile(outside, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewGitService()
+
+	if _, err := s.ResolveConflictRegion(r.dir, "../outside.txt", 0, ResolveOurs); err == nil {
+		t.Error("resolved a region of a file outside the repository")
+	}
+	if data, _ := os.ReadFile(outside); string(data) != content {
+		t.Errorf("file outside the repository = %q, want it unchanged", data)
+	}
+	if err := s.MarkResolved(r.dir, "../outside.txt"); err == nil {
+		t.Error("marked a file outside the repository as resolved")
+	}
+	if _, err := s.GetConflict(r.dir, "../outside.txt"); err == nil {
+		t.Error("returned the conflict of a file outside the repository")
+	}
+}
+
+func TestMarkResolvedRequiresConflict(t *testing.T) {
+	r := conflictRepo(t)
+	r.write("other.txt", "other\n")
+
+	if err := NewGitService().MarkResolved(r.dir, "other.txt"); err == nil {
+		t.Error("marked a file without conflicts as resolved")
+	}
+	if _, found := r.staged("other.txt"); found {
+		t.Error("file without conflicts was staged")
+	}
+}
+
+func TestResolveConflictRegion(t *testing.T) {
+	r := conflictRepo(t)
+	s := NewGitService()
+
+	details, err := s.ResolveConflictRegion(r.dir, "file.txt", 0, ResolveTheirs)
+	if err != nil {
+		t.Fatalf("failed to resolve region: %v", err)
+	}
+	if len(details.Regions) != 0 {
+		t.Errorf("%d regions left, want none", len(details.Regions))
+	}
+	if err := s.MarkResolved(r.dir, "file.txt"); err != nil {
+		t.Fatalf("failed to mark resolved: %v", err)
+	}
+	if content, _ := r.staged("file.txt"); content != "theirs\n" {
+		t.Errorf("index = %q, want %q", content, "theirs\n")
+	}
+}
+
+func TestResolveBaseWithoutBaseSection(t *testing.T) {
+	r := conflictRepo(t)
+	before, _ := r.read("file.txt")
+
+	if _, err := NewGitService().ResolveConflictRegion(r.dir, "file.txt", 0, ResolveBase); err == nil {
+		t.Error("resolved a region without base section with the base")
+	}
+	if content, _ := r.read("file.txt"); content != before {
+		t.Errorf("working copy = %q, want it unchanged", content)
+	}
+}
+
+func TestResolveBaseWithDiff3Markers(t *testing.T) {
+	r := conflictRepo(t)
+	r.write("file.txt", "<<<<<<< HEAD\nours\n||||||| base\none\n=======\ntheirs\n>>>>>>> feature\n")
+
+	if _, err := NewGitService().ResolveConflictRegion(r.dir, "file.txt", 0, ResolveBase); err != nil {
+		t.Fatalf("failed to resolve region: %v", err)
+	}
+	if content, _ := r.read("file.txt"); content != "one\n" {
+		t.Errorf("working copy = %q, want %q", content, "one\n")
+	}
+}
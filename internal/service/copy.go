@@ -0,0 +1,396 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+// progressInterval is the minimum time between two progress reports of a transfer
+const progressInterval = 100 * time.Millisecond
+
+// Conflict policies of copy and move operations
+const (
+	ConflictSkip      = "skip"      // Keep the existing entry and leave the source alone
+	ConflictOverwrite = "overwrite" // Move the existing entry to the trash and replace it
+	ConflictRename    = "rename"    // Copy or move under a free name, e.g. "main copy.go"
+)
+
+// TransferOptions contains options for copying or moving entries into a directory
+type TransferOptions struct {
+	ID          string   `json:"id"`          // Caller provided ID, echoed in progress events
+	Sources     []string `json:"sources"`     // Files and directories to copy or move
+	Destination string   `json:"destination"` // Directory receiving the entries
+	Conflict    string   `json:"conflict"`    // One of the Conflict constants, skip if empty
+}
+
+// TransferProgress reports the progress of a running copy or move
+type TransferProgress struct {
+	ID         string `json:"id"`
+	Current    string `json:"current"` // Path being transferred
+	FilesDone  int    `json:"filesDone"`
+	FilesTotal int    `json:"filesTotal"`
+	BytesDone  int64  `json:"bytesDone"`
+	BytesTotal int64  `json:"bytesTotal"`
+	Done       bool   `json:"done"`
+}
+
+// TransferResult describes a finished copy or move
+type TransferResult struct {
+	Paths   []string `json:"paths"`   // New paths of the transferred entries
+	Skipped []string `json:"skipped"` // Sources left alone because of a conflict
+}
+
+// transferProgress tracks a transfer and throttles its reports
+type transferProgress struct {
+	progress TransferProgress
+	onChange func(TransferProgress)
+	last     time.Time
+}
+
+// newTransferProgress counts the files and bytes of the sources
+func newTransferProgress(id string, sources []string, onChange func(TransferProgress)) *transferProgress {
+	p := &transferProgress{progress: TransferProgress{ID: id}, onChange: onChange}
+	for _, source := range sources {
+		filepath.WalkDir(source, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			p.progress.FilesTotal++
+			if info, err := d.Info(); err == nil && d.Type().IsRegular() {
+				p.progress.BytesTotal += info.Size()
+			}
+			return nil
+		})
+	}
+	return p
+}
+
+// file records a transferred file
+func (p *transferProgress) file(path string, size int64) {
+	p.progress.Current = path
+	p.progress.FilesDone++
+	p.progress.BytesDone += size
+	if time.Since(p.last) >= progressInterval {
+		p.report()
+	}
+}
+
+// skip records the files of a source that is not transferred
+func (p *transferProgress) skip(source string) {
+	filepath.WalkDir(source, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		p.progress.FilesDone++
+		if info, err := d.Info(); err == nil && d.Type().IsRegular() {
+			p.progress.BytesDone += info.Size()
+		}
+		return nil
+	})
+}
+
+// finish sends the final report
+func (p *transferProgress) finish() {
+	p.progress.Current = ""
+	p.progress.Done = true
+	p.report()
+}
+
+// report sends the current progress
+func (p *transferProgress) report() {
+	p.last = time.Now()
+	if p.onChange != nil {
+		p.onChange(p.progress)
+	}
+}
+
+// CopyFiles copies files and directory trees into a directory, keeping permissions and symlinks
+func (s *FileService) CopyFiles(opts TransferOptions, onProgress func(TransferProgress)) (*TransferResult, error) {
+	return s.transferFiles(opts, false, onProgress)
+}
+
+// MoveFiles moves files and directories into a directory
+func (s *FileService) MoveFiles(opts TransferOptions, onProgress func(TransferProgress)) (*TransferResult, error) {
+	return s.transferFiles(opts, true, onProgress)
+}
+
+// DuplicateFile copies a file or directory next to itself under a free name and returns the new path
+func (s *FileService) DuplicateFile(path string) (string, error) {
+	result, err := s.CopyFiles(TransferOptions{
+		Sources:     []string{path},
+		Destination: filepath.Dir(path),
+		Conflict:    ConflictRename,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Paths[0], nil
+}
+
+// transferFiles copies or moves every source into the destination directory
+func (s *FileService) transferFiles(opts TransferOptions, move bool, onProgress func(TransferProgress)) (*TransferResult, error) {
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = ConflictSkip
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy: %s", opts.Conflict)
+	}
+
+	info, err := os.Stat(opts.Destination)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("destination is not a directory: %s", opts.Destination)
+	}
+
+	for _, source := range opts.Sources {
+		if _, err := os.Lstat(source); err != nil {
+			return nil, fmt.Errorf("source not found: %s", source)
+		}
+		if isSubPath(source, opts.Destination) {
+			return nil, fmt.Errorf("can't copy or move %s into itself", source)
+		}
+	}
+
+	// Trashing a replaced entry must not trash any of the sources along with it
+	if opts.Conflict == ConflictOverwrite {
+		for _, source := range opts.Sources {
+			target := filepath.Join(opts.Destination, filepath.Base(source))
+			for _, other := range opts.Sources {
+				if other != target && isSubPath(target, other) {
+					return nil, fmt.Errorf("can't replace %s, it contains %s", target, other)
+				}
+			}
+		}
+	}
+
+	progress := newTransferProgress(opts.ID, opts.Sources, onProgress)
+	defer s.InvalidateCache(opts.Destination)
+
+	result := &TransferResult{Paths: []string{}, Skipped: []string{}}
+	for _, source := range opts.Sources {
+		target := filepath.Join(opts.Destination, filepath.Base(source))
+
+		// Moving an entry into its own directory changes nothing
+		if move && target == source {
+			progress.skip(source)
+			result.Paths = append(result.Paths, source)
+			continue
+		}
+
+		info, err := os.Lstat(source)
+		if err != nil {
+			return result, fmt.Errorf("source not found: %s", source)
+		}
+
+		var replaced *db.TrashItem
+		if _, err := os.Lstat(target); err == nil {
+			switch {
+			case opts.Conflict == ConflictRename || target == source:
+				target = availablePath(target)
+			case opts.Conflict == ConflictSkip:
+				progress.skip(source)
+				result.Skipped = append(result.Skipped, source)
+				continue
+			default:
+				// Keep the replaced entry restorable, the journal restores it when the transfer is undone
+				item, err := s.moveToTrash(target)
+				if err != nil {
+					return result, err
+				}
+				replaced = &item
+			}
+		}
+
+		if move {
+			err = movePath(source, target, progress.file)
+		} else {
+			err = copyTree(source, target, progress.file)
+		}
+		if err != nil {
+			if replaced != nil {
+				// A partial copy makes way for the replaced entry, the source is still complete
+				if !move {
+					os.RemoveAll(target)
+				}
+				s.restoreReplacedTarget(*replaced)
+			}
+			return result, fmt.Errorf("failed to transfer %s: %w", source, err)
+		}
+
+		if move {
+			s.InvalidateCache(filepath.Dir(source))
+			s.recordOverwrite(FileOperationRename, source, target, info.IsDir(), 0, replaced)
+		} else {
+			s.recordOverwrite(FileOperationCopy, source, target, info.IsDir(), 0, replaced)
+		}
+		result.Paths = append(result.Paths, target)
+	}
+
+	progress.finish()
+	return result, nil
+}
+
+// restoreReplacedTarget puts an overwritten entry back after its replacement failed to transfer
+func (s *FileService) restoreReplacedTarget(item db.TrashItem) {
+	if err := s.restoreTrashItem(item); err != nil {
+		log.Printf("[FileService] Failed to restore %s: %v", item.OriginalPath, err)
+	}
+}
+
+// availablePath returns a free path for a copy of path, e.g. "main copy.go" or "main copy 2.go"
+func availablePath(path string) string {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	// Dotfiles like .gitignore and directories keep their whole name as base
+	if ext == name {
+		ext = ""
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; ; i++ {
+		suffix := " copy"
+		if i > 1 {
+			suffix += " " + strconv.Itoa(i)
+		}
+		candidate := filepath.Join(dir, base+suffix+ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// pathSize returns the total size of the regular files under path
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// movePath renames a file or directory, copying it when source and target are on different devices.
+// onFile is called for every copied file and may be nil.
+func movePath(src, dst string, onFile func(path string, size int64)) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		if onFile != nil {
+			progressTree(dst, onFile)
+		}
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	if err := copyTree(src, dst, onFile); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// progressTree reports every file under a renamed path as transferred
+func progressTree(path string, onFile func(path string, size int64)) {
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		var size int64
+		if info, err := d.Info(); err == nil && d.Type().IsRegular() {
+			size = info.Size()
+		}
+		onFile(p, size)
+		return nil
+	})
+}
+
+// copyTree copies a file, symlink or directory tree, keeping permissions and links.
+// onFile is called for every copied file and may be nil.
+func copyTree(src, dst string, onFile func(path string, size int64)) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+		if onFile != nil {
+			onFile(src, 0)
+		}
+		return nil
+
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), onFile); err != nil {
+				return err
+			}
+		}
+		// Restore the exact mode after the contents were written
+		return os.Chmod(dst, info.Mode().Perm())
+
+	case info.Mode().IsRegular():
+		if err := copyFile(src, dst, info); err != nil {
+			return err
+		}
+		if onFile != nil {
+			onFile(src, info.Size())
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unsupported file type: %s", strings.TrimSpace(info.Mode().Type().String()))
+}
+
+// copyFile copies the content, permissions and modification time of a regular file
+func copyFile(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	os.Chtimes(dst, info.ModTime(), info.ModTime())
+	return os.Chmod(dst, info.Mode().Perm())
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestProject returns a file service with an opened project containing the given files
+func newTestProject(t *testing.T, files map[string]string) (*FileService, string) {
+	t.Helper()
+	s := newTestFiles(t)
+	root := t.TempDir()
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(root, filepath.FromSlash(name)), content)
+	}
+	if _, err := s.GetProjectFiles(root); err != nil {
+		t.Fatalf("failed to open project: %v", err)
+	}
+	return s, root
+}
+
+// checkTestFiles compares the content of files, an empty want means the file must not exist
+func checkTestFiles(t *testing.T, root string, want map[string]string) {
+	t.Helper()
+	for name, content := range want {
+		got, found := readTestFile(t, filepath.Join(root, filepath.FromSlash(name)))
+		switch {
+		case content == "" && found:
+			t.Errorf("%s exists with %q, want it gone", name, got)
+		case content != "" && got != content:
+			t.Errorf("%s = %q (exists %v), want %q", name, got, found, content)
+		}
+	}
+}
+
+func TestCopyConflictSkip(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+
+	result, err := s.CopyFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "src", "a.txt")},
+		Destination: filepath.Join(root, "dst"),
+		Conflict:    ConflictSkip,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Skipped) != 1 || len(result.Paths) != 0 {
+		t.Errorf("result = %+v, want the source skipped", result)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+}
+
+func TestCopyConflictRename(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"src/a.txt": "new", "dst/a.txt": "old", "dst/a copy.txt": "older"})
+
+	result, err := s.CopyFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "src", "a.txt")},
+		Destination: filepath.Join(root, "dst"),
+		Conflict:    ConflictRename,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(root, "dst", "a copy 2.txt")
+	if len(result.Paths) != 1 || result.Paths[0] != want {
+		t.Errorf("paths = %q, want %q", result.Paths, want)
+	}
+	checkTestFiles(t, root, map[string]string{"dst/a.txt": "old", "dst/a copy.txt": "older", "dst/a copy 2.txt": "new"})
+}
+
+func TestCopyConflictOverwrite(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+
+	_, err := s.CopyFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "src", "a.txt")},
+		Destination: filepath.Join(root, "dst"),
+		Conflict:    ConflictOverwrite,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "new", "dst/a.txt": "new"})
+
+	// The replaced file comes back with the undo
+	if _, err := s.UndoFileOperation(root); err != nil {
+		t.Fatal(err)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+
+	if _, err := s.RedoFileOperation(root); err != nil {
+		t.Fatal(err)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "new", "dst/a.txt": "new"})
+}
+
+func TestMoveConflictOverwrite(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+
+	_, err := s.MoveFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "src", "a.txt")},
+		Destination: filepath.Join(root, "dst"),
+		Conflict:    ConflictOverwrite,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "", "dst/a.txt": "new"})
+
+	if _, err := s.UndoFileOperation(root); err != nil {
+		t.Fatal(err)
+	}
+	checkTestFiles(t, root, map[string]string{"src/a.txt": "new", "dst/a.txt": "old"})
+}
+
+func TestMoveOverwriteRejectsAncestorOfSource(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"x/a/a": "content"})
+
+	_, err := s.MoveFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "x", "a", "a")},
+		Destination: filepath.Join(root, "x"),
+		Conflict:    ConflictOverwrite,
+	}, nil)
+	if err == nil {
+		t.Fatal("moving an entry over its own parent succeeded")
+	}
+
+	checkTestFiles(t, root, map[string]string{"x/a/a": "content"})
+	if items, _ := s.ListTrash(root); len(items) != 0 {
+		t.Errorf("trash has %d items, want nothing trashed", len(items))
+	}
+}
+
+func TestCopyRejectsDestinationInsideSource(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"dir/sub/a.txt": "a"})
+
+	_, err := s.CopyFiles(TransferOptions{
+		Sources:     []string{filepath.Join(root, "dir")},
+		Destination: filepath.Join(root, "dir", "sub"),
+	}, nil)
+	if err == nil {
+		t.Fatal("copying a directory into itself succeeded")
+	}
+}
+
+func TestDuplicateFile(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"main.go": "package main"})
+
+	path, err := s.DuplicateFile(filepath.Join(root, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != filepath.Join(root, "main copy.go") {
+		t.Errorf("path = %q, want %q", path, filepath.Join(root, "main copy.go"))
+	}
+	checkTestFiles(t, root, map[string]string{"main.go": "package main", "main copy.go": "package main"})
+}
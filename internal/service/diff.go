@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// maxDiffFileSize is the largest file the diff service compares line by line
+const maxDiffFileSize = 10 << 20
+
+// DirectoryDiff lists the differences between two directory trees
+type DirectoryDiff struct {
+	OldPath string               `json:"oldPath"`
+	NewPath string               `json:"newPath"`
+	Files   []DirectoryDiffEntry `json:"files"` // Sorted by path
+	Stats   DiffStats            `json:"stats"` // Totals of every file
+}
+
+// DirectoryDiffEntry is a file that differs between two directories
+type DirectoryDiffEntry struct {
+	Path     string    `json:"path"`     // Slash separated path relative to both directories
+	Status   string    `json:"status"`   // "A" only in the new directory, "D" only in the old one, "M" changed
+	IsBinary bool      `json:"isBinary"` // Binary or too large to compare by lines, Stats are empty
+	Stats    DiffStats `json:"stats"`
+}
+
+// DiffService compares files, unsaved buffers and directories outside of Git
+type DiffService struct {
+	files *FileService
+}
+
+// NewDiffService creates a new diff service decoding files like the given file service
+func NewDiffService(files *FileService) *DiffService {
+	return &DiffService{files: files}
+}
+
+// DiffFiles compares two files on disk
+func (s *DiffService) DiffFiles(oldPath, newPath string, opts DiffOptions) (*FileDiff, error) {
+	oldRaw, err := readDiffFile(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newRaw, err := readDiffFile(newPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.diffContents(oldRaw, newRaw, s.label(oldPath), s.label(newPath), opts)
+}
+
+// DiffBuffer compares the saved copy of a file with the unsaved content of its editor buffer.
+// A file that was never saved is compared with an empty one.
+func (s *DiffService) DiffBuffer(path, content string, opts DiffOptions) (*FileDiff, error) {
+	saved, err := readDiffFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	label := s.label(path)
+	if isBinaryContent(saved) {
+		return &FileDiff{Path: label, IsBinary: true}, nil
+	}
+
+	savedText, _, _, err := decodeText(saved, s.files.getSettings().DefaultFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDiff(savedText, content, label, label, opts)
+}
+
+// DiffDirectories lists the files added, removed and changed between two directory trees, with per-file stats.
+// The content of a single file is compared with DiffFiles, opts only affects the stats.
+func (s *DiffService) DiffDirectories(oldDir, newDir string, opts DiffOptions) (*DirectoryDiff, error) {
+	oldFiles, err := listDiffFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := listDiffFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DirectoryDiff{OldPath: oldDir, NewPath: newDir, Files: []DirectoryDiffEntry{}}
+	add := func(entry DirectoryDiffEntry) {
+		result.Files = append(result.Files, entry)
+		result.Stats.Added += entry.Stats.Added
+		result.Stats.Deleted += entry.Stats.Deleted
+		result.Stats.Modified += entry.Stats.Modified
+	}
+
+	for relPath := range oldFiles {
+		if !newFiles[relPath] {
+			add(s.compareDirectoryFile(relPath, "D", filepath.Join(oldDir, relPath), "", opts))
+		}
+	}
+	for relPath := range newFiles {
+		oldPath := filepath.Join(oldDir, relPath)
+		newPath := filepath.Join(newDir, relPath)
+		if !oldFiles[relPath] {
+			add(s.compareDirectoryFile(relPath, "A", "", newPath, opts))
+			continue
+		}
+		if changed, err := filesDiffer(oldPath, newPath); err != nil {
+			return nil, err
+		} else if changed {
+			add(s.compareDirectoryFile(relPath, "M", oldPath, newPath, opts))
+		}
+	}
+
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].Path < result.Files[j].Path
+	})
+	return result, nil
+}
+
+// compareDirectoryFile computes the stats of a file of a directory diff, an empty path stands for a missing file
+func (s *DiffService) compareDirectoryFile(relPath, status, oldPath, newPath string, opts DiffOptions) DirectoryDiffEntry {
+	entry := DirectoryDiffEntry{Path: filepath.ToSlash(relPath), Status: status}
+
+	var oldRaw, newRaw []byte
+	var err error
+	if oldPath != "" {
+		if oldRaw, err = readDiffFile(oldPath); err != nil {
+			entry.IsBinary = true
+			return entry
+		}
+	}
+	if newPath != "" {
+		if newRaw, err = readDiffFile(newPath); err != nil {
+			entry.IsBinary = true
+			return entry
+		}
+	}
+
+	diff, err := s.diffContents(oldRaw, newRaw, entry.Path, entry.Path, opts)
+	if err != nil || diff.IsBinary {
+		entry.IsBinary = true
+		return entry
+	}
+	entry.Stats = diff.Stats
+	return entry
+}
+
+// diffContents decodes and compares raw file contents
+func (s *DiffService) diffContents(oldRaw, newRaw []byte, oldLabel, newLabel string, opts DiffOptions) (*FileDiff, error) {
+	if isBinaryContent(oldRaw) || isBinaryContent(newRaw) {
+		return &FileDiff{Path: newLabel, IsBinary: true}, nil
+	}
+
+	fallback := s.files.getSettings().DefaultFormat
+	oldText, _, _, err := decodeText(oldRaw, fallback)
+	if err != nil {
+		return nil, err
+	}
+	newText, _, _, err := decodeText(newRaw, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDiff(oldText, newText, oldLabel, newLabel, opts)
+}
+
+// label returns the path shown in a diff header, relative to the project of the file if it has one
+func (s *DiffService) label(path string) string {
+	if relPath, err := filepath.Rel(s.files.projectRoot(path), path); err == nil {
+		return filepath.ToSlash(relPath)
+	}
+	return filepath.Base(path)
+}
+
+// readDiffFile reads a file to compare, refusing files too large to diff by lines
+func readDiffFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxDiffFileSize {
+		return nil, fmt.Errorf("file too large to compare: %s", path)
+	}
+	return os.ReadFile(path)
+}
+
+// listDiffFiles returns the relative paths of the files below dir, skipping .git directories
+func listDiffFiles(dir string) (map[string]bool, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	files := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[relPath] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// filesDiffer reports whether two files have a different content, symlinks are compared by target
+func filesDiffer(oldPath, newPath string) (bool, error) {
+	oldInfo, err := os.Lstat(oldPath)
+	if err != nil {
+		return false, err
+	}
+	newInfo, err := os.Lstat(newPath)
+	if err != nil {
+		return false, err
+	}
+
+	oldLink := oldInfo.Mode()&os.ModeSymlink != 0
+	newLink := newInfo.Mode()&os.ModeSymlink != 0
+	if oldLink || newLink {
+		if oldLink != newLink {
+			return true, nil
+		}
+		oldTarget, _ := os.Readlink(oldPath)
+		newTarget, _ := os.Readlink(newPath)
+		return oldTarget != newTarget, nil
+	}
+
+	if oldInfo.Size() != newInfo.Size() {
+		return true, nil
+	}
+
+	oldFile, err := os.Open(oldPath)
+	if err != nil {
+		return false, err
+	}
+	defer oldFile.Close()
+	newFile, err := os.Open(newPath)
+	if err != nil {
+		return false, err
+	}
+	defer newFile.Close()
+
+	// Compare in chunks so large files aren't read into memory
+	oldBuf := make([]byte, 64<<10)
+	newBuf := make([]byte, 64<<10)
+	for {
+		n, oldErr := io.ReadFull(oldFile, oldBuf)
+		m, newErr := io.ReadFull(newFile, newBuf)
+		if n != m || !bytes.Equal(oldBuf[:n], newBuf[:m]) {
+			return true, nil
+		}
+		if oldErr == io.EOF || oldErr == io.ErrUnexpectedEOF {
+			return newErr != oldErr, nil
+		}
+		if oldErr != nil {
+			return false, oldErr
+		}
+		if newErr != nil {
+			return false, newErr
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Supported text encodings
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+)
+
+// Supported line endings
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+	LineEndingCR   = "cr"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// FileFormat describes how the text of a file is stored on disk
+type FileFormat struct {
+	Encoding   string `json:"encoding"`   // One of the Encoding constants
+	BOM        bool   `json:"bom"`        // Whether the file starts with a byte order mark
+	LineEnding string `json:"lineEnding"` // One of the LineEnding constants
+}
+
+// platformLineEnding returns the native line ending of the running system
+func platformLineEnding() string {
+	if runtime.GOOS == "windows" {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// normalizeFileFormat fills empty fields with defaults and validates the rest
+func normalizeFileFormat(format FileFormat) (FileFormat, error) {
+	format.Encoding = strings.ToLower(format.Encoding)
+	switch format.Encoding {
+	case "", "utf8":
+		format.Encoding = EncodingUTF8
+	case "latin1", "latin-1":
+		format.Encoding = EncodingLatin1
+	case EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingLatin1:
+	default:
+		return format, fmt.Errorf("unsupported encoding: %s", format.Encoding)
+	}
+
+	format.LineEnding = strings.ToLower(format.LineEnding)
+	switch format.LineEnding {
+	case "", "auto":
+		format.LineEnding = platformLineEnding()
+	case LineEndingLF, LineEndingCRLF, LineEndingCR:
+	default:
+		return format, fmt.Errorf("unsupported line ending: %s", format.LineEnding)
+	}
+
+	// Latin-1 has no byte order mark
+	if format.Encoding == EncodingLatin1 {
+		format.BOM = false
+	}
+
+	return format, nil
+}
+
+// textEncoding returns the x/text encoding for a format, without BOM handling
+func textEncoding(name string) encoding.Encoding {
+	switch name {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case EncodingLatin1:
+		return charmap.ISO8859_1
+	}
+	return nil
+}
+
+// detectEncoding guesses the encoding of raw file content and strips its BOM
+func detectEncoding(raw []byte) (string, bool, []byte) {
+	switch {
+	case bytes.HasPrefix(raw, bomUTF8):
+		return EncodingUTF8, true, raw[len(bomUTF8):]
+	case bytes.HasPrefix(raw, bomUTF16LE):
+		return EncodingUTF16LE, true, raw[len(bomUTF16LE):]
+	case bytes.HasPrefix(raw, bomUTF16BE):
+		return EncodingUTF16BE, true, raw[len(bomUTF16BE):]
+	}
+
+	// UTF-16 text without BOM has NUL in every other byte for ASCII characters.
+	// Such content is also valid UTF-8, so it is checked first.
+	if name := guessUTF16(raw); name != "" {
+		return name, false, raw
+	}
+
+	if utf8.Valid(raw) {
+		return EncodingUTF8, false, raw
+	}
+
+	// Every byte sequence is valid Latin-1, so the content survives a round trip unchanged
+	return EncodingLatin1, false, raw
+}
+
+// guessUTF16 detects BOM-less UTF-16 from the position of NUL bytes in a sample
+func guessUTF16(raw []byte) string {
+	if len(raw) < 2 || len(raw)%2 != 0 {
+		return ""
+	}
+
+	sample := raw
+	if len(sample) > binaryCheckSize {
+		sample = sample[:binaryCheckSize]
+	}
+
+	var evenNul, oddNul int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNul++
+		} else {
+			oddNul++
+		}
+	}
+
+	pairs := len(sample) / 2
+	switch {
+	case oddNul > pairs*3/4 && evenNul == 0:
+		return EncodingUTF16LE
+	case evenNul > pairs*3/4 && oddNul == 0:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// detectLineEnding returns the dominant line ending of text and whether several kinds are mixed
+func detectLineEnding(text string) (string, bool) {
+	crlf := strings.Count(text, "\r\n")
+	lf := strings.Count(text, "\n") - crlf
+	cr := strings.Count(text, "\r") - crlf
+
+	kinds := 0
+	for _, n := range []int{crlf, lf, cr} {
+		if n > 0 {
+			kinds++
+		}
+	}
+
+	switch {
+	case kinds == 0:
+		return "", false
+	case crlf >= lf && crlf >= cr:
+		return LineEndingCRLF, kinds > 1
+	case lf >= cr:
+		return LineEndingLF, kinds > 1
+	default:
+		return LineEndingCR, kinds > 1
+	}
+}
+
+// decodeText converts raw file content into text with "\n" line endings.
+// The detected format falls back to the given defaults for anything the content doesn't reveal.
+func decodeText(raw []byte, fallback FileFormat) (string, FileFormat, bool, error) {
+	if len(raw) == 0 {
+		return "", fallback, false, nil
+	}
+
+	name, bom, body := detectEncoding(raw)
+	format := FileFormat{Encoding: name, BOM: bom}
+
+	text := string(body)
+	if enc := textEncoding(name); enc != nil {
+		decoded, err := enc.NewDecoder().Bytes(body)
+		if err != nil {
+			return "", format, false, fmt.Errorf("failed to decode %s content: %w", name, err)
+		}
+		text = string(decoded)
+	}
+
+	lineEnding, mixed := detectLineEnding(text)
+	if lineEnding == "" {
+		lineEnding = fallback.LineEnding
+	}
+	format.LineEnding = lineEnding
+
+	return normalizeLineEndings(text, LineEndingLF), format, mixed, nil
+}
+
+// encodeText converts editor text into raw file content in the given format
+func encodeText(text string, format FileFormat) ([]byte, error) {
+	format, err := normalizeFileFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	text = normalizeLineEndings(text, format.LineEnding)
+
+	var body []byte
+	if enc := textEncoding(format.Encoding); enc != nil {
+		body, err = enc.NewEncoder().Bytes([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("content can't be encoded as %s: %w", format.Encoding, err)
+		}
+	} else {
+		body = []byte(text)
+	}
+
+	if !format.BOM {
+		return body, nil
+	}
+
+	var bom []byte
+	switch format.Encoding {
+	case EncodingUTF8:
+		bom = bomUTF8
+	case EncodingUTF16LE:
+		bom = bomUTF16LE
+	case EncodingUTF16BE:
+		bom = bomUTF16BE
+	}
+	return append(append([]byte{}, bom...), body...), nil
+}
+
+// normalizeLineEndings converts every line ending in text to the given one
+func normalizeLineEndings(text string, lineEnding string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	switch lineEnding {
+	case LineEndingCRLF:
+		return strings.ReplaceAll(text, "\n", "\r\n")
+	case LineEndingCR:
+		return strings.ReplaceAll(text, "\n", "\r")
+	}
+	return text
+}
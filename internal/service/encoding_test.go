@@ -0,0 +1,101 @@
+package service
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+	fallback := FileFormat{Encoding: EncodingUTF8, LineEnding: LineEndingLF}
+	tests := []struct {
+		name   string
+		raw    []byte
+		text   string
+		format FileFormat
+	}{
+		{"utf-8", []byte("héllo\nworld\n"), "héllo\nworld\n", FileFormat{EncodingUTF8, false, LineEndingLF}},
+		{"utf-8 bom crlf", []byte("\xEF\xBB\xBFa\r\nb\r\n"), "a\nb\n", FileFormat{EncodingUTF8, true, LineEndingCRLF}},
+		{"cr", []byte("a\rb\r"), "a\nb\n", FileFormat{EncodingUTF8, false, LineEndingCR}},
+		{"utf-16le bom", []byte("\xFF\xFEh\x00i\x00\n\x00"), "hi\n", FileFormat{EncodingUTF16LE, true, LineEndingLF}},
+		{"utf-16be bom", []byte("\xFE\xFF\x00h\x00i\x00\r\x00\n"), "hi\n", FileFormat{EncodingUTF16BE, true, LineEndingCRLF}},
+		{"utf-16le", []byte("h\x00i\x00\n\x00"), "hi\n", FileFormat{EncodingUTF16LE, false, LineEndingLF}},
+		{"latin-1", []byte("caf\xe9\n"), "café\n", FileFormat{EncodingLatin1, false, LineEndingLF}},
+		{"no line ending", []byte("a"), "a", FileFormat{EncodingUTF8, false, LineEndingLF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, format, mixed, err := decodeText(tt.raw, fallback)
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if text != tt.text || format != tt.format || mixed {
+				t.Fatalf("decoded %q %+v (mixed %v), want %q %+v", text, format, mixed, tt.text, tt.format)
+			}
+
+			raw, err := encodeText(text, format)
+			if err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+			if !bytes.Equal(raw, tt.raw) {
+				t.Errorf("encoded %q, want %q", raw, tt.raw)
+			}
+		})
+	}
+}
+
+func TestDecodeMixedLineEndings(t *testing.T) {
+	text, format, mixed, err := decodeText([]byte("a\r\nb\nc\r\n"), FileFormat{})
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if text != "a\nb\nc\n" || format.LineEnding != LineEndingCRLF || !mixed {
+		t.Fatalf("decoded %q %s (mixed %v), want crlf text marked as mixed", text, format.LineEnding, mixed)
+	}
+	raw, err := encodeText(text, format)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if string(raw) != "a\r\nb\r\nc\r\n" {
+		t.Errorf("encoded %q, want every line ending as crlf", raw)
+	}
+}
+
+func TestEncodeRejectsUnrepresentableText(t *testing.T) {
+	if _, err := encodeText("snow ☃\n", FileFormat{Encoding: EncodingLatin1}); err == nil {
+		t.Error("encoding a character outside latin-1 succeeded")
+	}
+	if _, err := encodeText("a", FileFormat{Encoding: "koi8-r"}); err == nil {
+		t.Error("an unsupported encoding was accepted")
+	}
+}
+
+func TestSaveFileKeepsFormat(t *testing.T) {
+	s := newTestFiles(t)
+	path := filepath.Join(t.TempDir(), "utf16.txt")
+	writeTestFile(t, path, "\xFF\xFEa\x00\r\x00\n\x00")
+
+	file, err := s.GetFileContent(path)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if file.Content != "a\n" || file.Format != (FileFormat{EncodingUTF16LE, true, LineEndingCRLF}) {
+		t.Fatalf("read %q %+v, want the decoded utf-16 text", file.Content, file.Format)
+	}
+
+	if _, err := s.SaveFile(path, file.Content+"b\n", SaveOptions{Version: file.Version}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if content, _ := readTestFile(t, path); content != "\xFF\xFEa\x00\r\x00\n\x00b\x00\r\x00\n\x00" {
+		t.Errorf("saved %q, want utf-16le with a bom and crlf line endings", content)
+	}
+
+	// An explicit format converts the file
+	if _, err := s.SaveFile(path, "a\nb\n", SaveOptions{Format: &FileFormat{Encoding: EncodingUTF8, LineEnding: LineEndingLF}}); err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+	if content, _ := readTestFile(t, path); content != "a\nb\n" {
+		t.Errorf("converted %q, want plain utf-8", content)
+	}
+}
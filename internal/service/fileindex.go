@@ -0,0 +1,591 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	// fileIndexVersion is bumped whenever the persisted index format changes
+	fileIndexVersion = 1
+	// fileIndexRescanInterval is the minimum time between two background scans of an index
+	fileIndexRescanInterval = 30 * time.Second
+	// defaultFileSearchLimit is the page size of a file search without a limit
+	defaultFileSearchLimit = 50
+	// maxRankedFileOpens is the number of recently opened files of a root used for ranking
+	maxRankedFileOpens = 500
+)
+
+// FileSearchOptions contains options for a fuzzy file search
+type FileSearchOptions struct {
+	Query  string `json:"query"`  // Fuzzy pattern, recently opened files first if empty
+	Offset int    `json:"offset"` // Number of results to skip
+	Limit  int    `json:"limit"`  // Page size, defaultFileSearchLimit if zero
+}
+
+// FileSearchPage is a page of fuzzy file search results
+type FileSearchPage struct {
+	Files   []*FileNode `json:"files"`
+	Total   int         `json:"total"`   // Number of matching files
+	HasMore bool        `json:"hasMore"` // More results follow this page
+}
+
+// fileIndex is the list of files of a project, kept in memory for fuzzy search.
+// Directories are stored with their modification time, so a rescan only reads the directories that changed.
+type fileIndex struct {
+	mu       sync.RWMutex
+	root     string
+	dirs     map[string]*indexDir // Keyed by slash separated path relative to the root, "." for the root
+	paths    []string             // Relative paths of every file, rebuilt from dirs when nil
+	scanned  time.Time
+	scanning bool
+	scanDone chan struct{} // Closed when the running scan ends
+	missed   []FileEvent   // Watcher events received during a scan, replayed on its result
+}
+
+// indexDir is a scanned directory of a file index
+type indexDir struct {
+	ModTime       int64    // Modification time of the directory when it was read
+	IgnoreModTime int64    // Modification time of its .gitignore, 0 if it has none
+	Files         []string // Names of the files that aren't ignored
+	Dirs          []string // Names of the subdirectories that aren't ignored
+}
+
+// indexFile is the persisted form of a file index
+type indexFile struct {
+	Version int
+	Root    string
+	Dirs    map[string]*indexDir
+}
+
+// FindFiles performs a fuzzy search over the files of every root using their persistent indexes.
+// Files opened often or recently rank higher. With several roots, paths are matched with the root name in front.
+func (s *FileService) FindFiles(ctx context.Context, roots []WorkspaceRoot, opts FileSearchOptions) (*FileSearchPage, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultFileSearchLimit
+	}
+
+	var candidates []indexCandidate
+	for _, root := range roots {
+		index, err := s.fileIndex(ctx, root.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := ""
+		if len(roots) > 1 {
+			prefix = root.Name + "/"
+		}
+		for _, relPath := range index.files() {
+			candidates = append(candidates, indexCandidate{root: root.Path, relPath: relPath, display: prefix + relPath})
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	boosts := s.fileOpenBoosts(roots)
+	ranked := rankCandidates(candidates, opts.Query, boosts)
+
+	page := &FileSearchPage{Files: []*FileNode{}, Total: len(ranked)}
+	if opts.Offset >= len(ranked) {
+		return page, nil
+	}
+	end := opts.Offset + opts.Limit
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	page.HasMore = end < len(ranked)
+
+	for _, c := range ranked[opts.Offset:end] {
+		page.Files = append(page.Files, c.node())
+	}
+	return page, nil
+}
+
+// RecordFileOpen counts an opened file for the ranking of the file search.
+// Failures are only logged, the ranking is a convenience.
+func (s *FileService) RecordFileOpen(path string) {
+	if err := s.queries.RecordFileOpen(context.Background(), path); err != nil {
+		log.Printf("[FileService] Failed to record file open: %v", err)
+	}
+}
+
+// indexCandidate is a file of a fuzzy search
+type indexCandidate struct {
+	root    string
+	relPath string
+	display string // Path the query is matched against
+	score   int
+}
+
+// node creates the search result node of a candidate
+func (c indexCandidate) node() *FileNode {
+	filePath := filepath.Join(c.root, filepath.FromSlash(c.relPath))
+	node := &FileNode{
+		Name:     filepath.Base(filePath),
+		Path:     filePath,
+		Type:     "file",
+		IsLoaded: true,
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		node.Size = info.Size()
+		node.LastModified = info.ModTime()
+	}
+	return node
+}
+
+// candidateSource lets the fuzzy matcher read the display paths of the candidates without copying them
+type candidateSource []indexCandidate
+
+func (c candidateSource) String(i int) string { return c[i].display }
+func (c candidateSource) Len() int            { return len(c) }
+
+// rankCandidates returns the candidates matching query, best first.
+// Without a query every candidate matches and opened files come first.
+func rankCandidates(candidates []indexCandidate, query string, boosts map[string]int) []indexCandidate {
+	boost := func(c indexCandidate) int {
+		return boosts[filepath.Join(c.root, filepath.FromSlash(c.relPath))]
+	}
+
+	var ranked []indexCandidate
+	if query == "" {
+		ranked = candidates
+		for i := range ranked {
+			ranked[i].score = boost(ranked[i])
+		}
+	} else {
+		matches := fuzzy.FindFromNoSort(query, candidateSource(candidates))
+		ranked = make([]indexCandidate, 0, len(matches))
+		for _, match := range matches {
+			c := candidates[match.Index]
+			c.score = match.Score + boost(c)
+			ranked = append(ranked, c)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		// Shorter paths are usually the file being looked for
+		if len(ranked[i].display) != len(ranked[j].display) {
+			return len(ranked[i].display) < len(ranked[j].display)
+		}
+		return ranked[i].display < ranked[j].display
+	})
+	return ranked
+}
+
+// fileOpenBoosts returns the ranking bonus of the files opened in the roots, keyed by absolute path
+func (s *FileService) fileOpenBoosts(roots []WorkspaceRoot) map[string]int {
+	boosts := make(map[string]int)
+	now := time.Now()
+
+	for _, root := range roots {
+		// LIKE ignores the case of ASCII letters, so paths are filtered again below
+		opens, err := s.queries.ListFileOpens(context.Background(), db.ListFileOpensParams{
+			Path:  escapeLike(root.Path+string(filepath.Separator)) + "%",
+			Limit: maxRankedFileOpens,
+		})
+		if err != nil {
+			log.Printf("[FileService] Failed to list opened files: %v", err)
+			continue
+		}
+		for _, open := range opens {
+			if isSubPath(root.Path, open.Path) {
+				boosts[open.Path] = fileOpenBoost(open, now)
+			}
+		}
+	}
+	return boosts
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern, with a backslash as escape character
+func escapeLike(pattern string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+}
+
+// fileOpenBoost rates an opened file by how often and how recently it was opened
+func fileOpenBoost(open db.FileOpen, now time.Time) int {
+	count := open.OpenCount
+	if count > 10 {
+		count = 10
+	}
+	boost := int(count) * 2
+
+	age := now.Sub(open.LastOpened.Time)
+	switch {
+	case age < time.Hour:
+		boost += 20
+	case age < 24*time.Hour:
+		boost += 10
+	case age < 7*24*time.Hour:
+		boost += 5
+	}
+	return boost
+}
+
+// fileIndex returns the index of a root, loading or building it when needed.
+// A stale index is returned as is and refreshed in the background.
+func (s *FileService) fileIndex(ctx context.Context, root string) (*fileIndex, error) {
+	s.indexesLock.Lock()
+	index, ok := s.indexes[root]
+	if !ok {
+		index = &fileIndex{root: root}
+		s.indexes[root] = index
+	}
+	s.indexesLock.Unlock()
+
+	for {
+		index.mu.Lock()
+		if index.dirs == nil && !index.scanning {
+			if dirs, err := s.loadFileIndex(root); err == nil {
+				index.dirs = dirs
+			}
+		}
+
+		if index.dirs != nil {
+			stale := !index.scanning && time.Since(index.scanned) >= fileIndexRescanInterval
+			if stale {
+				index.startScan()
+			}
+			index.mu.Unlock()
+
+			if stale {
+				go s.refreshFileIndex(index)
+			}
+			return index, nil
+		}
+
+		// Nothing usable on disk, the first search has to wait for a full scan
+		if index.scanning {
+			done := index.scanDone
+			index.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-done:
+			}
+			continue
+		}
+		index.startScan()
+		index.mu.Unlock()
+
+		dirs, err := s.scanFileIndex(ctx, root, nil)
+		s.finishIndexScan(index, dirs, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// refreshFileIndex rescans the changed directories of an index in the background.
+// Directory entries are never modified in place, so the scan can share them with the live index.
+func (s *FileService) refreshFileIndex(index *fileIndex) {
+	index.mu.RLock()
+	old := make(map[string]*indexDir, len(index.dirs))
+	for rel, dir := range index.dirs {
+		old[rel] = dir
+	}
+	index.mu.RUnlock()
+
+	dirs, err := s.scanFileIndex(context.Background(), index.root, old)
+	if err != nil {
+		log.Printf("[FileService] Failed to scan %s: %v", index.root, err)
+	}
+	s.finishIndexScan(index, dirs, err)
+}
+
+// startScan marks a scan of the index as running. Caller must hold mu.
+func (index *fileIndex) startScan() {
+	index.scanning = true
+	index.scanDone = make(chan struct{})
+}
+
+// finishIndexScan swaps in the result of a scan, replays the watcher events it may have missed and persists it
+func (s *FileService) finishIndexScan(index *fileIndex, dirs map[string]*indexDir, err error) {
+	index.mu.Lock()
+	index.scanning = false
+	close(index.scanDone)
+	index.scanned = time.Now()
+	missed := index.missed
+	index.missed = nil
+	if err != nil {
+		index.mu.Unlock()
+		return
+	}
+
+	index.dirs = dirs
+	index.paths = nil
+	for i := range missed {
+		s.applyIndexEvent(index, &missed[i])
+	}
+	index.mu.Unlock()
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	s.saveFileIndex(index.root, index.dirs)
+}
+
+// files returns the relative paths of every indexed file
+func (index *fileIndex) files() []string {
+	index.mu.RLock()
+	paths := index.paths
+	index.mu.RUnlock()
+	if paths != nil {
+		return paths
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.paths == nil {
+		index.paths = make([]string, 0, len(index.dirs))
+		for rel, dir := range index.dirs {
+			for _, name := range dir.Files {
+				index.paths = append(index.paths, path.Join(rel, name))
+			}
+		}
+		sort.Strings(index.paths)
+	}
+	return index.paths
+}
+
+// scanFileIndex walks the directories of root, reusing the entries of unchanged directories from old
+func (s *FileService) scanFileIndex(ctx context.Context, root string, old map[string]*indexDir) (map[string]*indexDir, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]*indexDir, len(old))
+	s.scanIndexDir(ctx, root, ".", old, dirs, false)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// scanIndexDir scans a directory and its subdirectories into dirs, until ctx is cancelled.
+// A changed .gitignore forces its whole subtree to be read again, since its rules apply to every level.
+func (s *FileService) scanIndexDir(ctx context.Context, root, rel string, old, dirs map[string]*indexDir, force bool) {
+	if ctx.Err() != nil {
+		return
+	}
+	dirPath := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return
+	}
+
+	var ignoreModTime int64
+	if ignoreInfo, err := os.Stat(filepath.Join(dirPath, ".gitignore")); err == nil {
+		ignoreModTime = ignoreInfo.ModTime().UnixNano()
+	}
+
+	prev := old[rel]
+	if prev != nil && prev.IgnoreModTime != ignoreModTime {
+		s.resetGitIgnore(dirPath)
+		force = true
+	}
+
+	dir := prev
+	if force || prev == nil || prev.ModTime != info.ModTime().UnixNano() {
+		dir = s.readIndexDir(root, dirPath)
+		dir.ModTime = info.ModTime().UnixNano()
+		dir.IgnoreModTime = ignoreModTime
+	}
+	dirs[rel] = dir
+
+	for _, name := range dir.Dirs {
+		s.scanIndexDir(ctx, root, path.Join(rel, name), old, dirs, force)
+	}
+}
+
+// readIndexDir lists the files and subdirectories of a directory that aren't ignored or excluded
+func (s *FileService) readIndexDir(root, dirPath string) *indexDir {
+	dir := &indexDir{}
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return dir
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if s.isExcluded(root, entryPath) {
+			continue
+		}
+		if entry.IsDir() {
+			if !s.isGitIgnored(root, entryPath, true) {
+				dir.Dirs = append(dir.Dirs, entry.Name())
+			}
+			continue
+		}
+		if !s.isIgnored(root, entryPath) {
+			dir.Files = append(dir.Files, entry.Name())
+		}
+	}
+	return dir
+}
+
+// updateFileIndex applies a watcher event to the index of its project, if it is loaded.
+// A running scan may have read the directory before the change, so the event is replayed on its result.
+func (s *FileService) updateFileIndex(event *FileEvent) {
+	s.indexesLock.Lock()
+	index, ok := s.indexes[event.ProjectPath]
+	s.indexesLock.Unlock()
+	if !ok {
+		return
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.scanning {
+		index.missed = append(index.missed, *event)
+	}
+	if index.dirs != nil {
+		s.applyIndexEvent(index, event)
+	}
+}
+
+// applyIndexEvent applies a watcher event to an index. Caller must hold mu.
+// Directory changes only mark the index stale, the next search rescans it.
+func (s *FileService) applyIndexEvent(index *fileIndex, event *FileEvent) {
+	if event.Type == FileEventRemove || event.Type == FileEventRename {
+		index.removeFile(firstNonEmpty(event.OldPath, event.Path))
+	}
+	if event.Type == FileEventAdd || event.Type == FileEventRename {
+		if event.Node.Type == "directory" {
+			index.scanned = time.Time{}
+		} else if !s.isExcluded(index.root, event.Path) && !s.isIgnored(index.root, event.Path) {
+			index.addFile(event.Path)
+		}
+	}
+}
+
+// addFile adds a file to the directory holding it, if that directory is indexed
+func (index *fileIndex) addFile(filePath string) {
+	rel, err := filepath.Rel(index.root, filepath.Dir(filePath))
+	if err != nil {
+		return
+	}
+	dir, ok := index.dirs[filepath.ToSlash(rel)]
+	if !ok {
+		return
+	}
+
+	name := filepath.Base(filePath)
+	for _, existing := range dir.Files {
+		if existing == name {
+			return
+		}
+	}
+
+	updated := *dir
+	updated.Files = append(append([]string{}, dir.Files...), name)
+	index.dirs[filepath.ToSlash(rel)] = &updated
+	index.paths = nil
+}
+
+// removeFile removes a file, or a directory with everything below it, from the index
+func (index *fileIndex) removeFile(filePath string) {
+	rel, err := filepath.Rel(index.root, filePath)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	for dirRel := range index.dirs {
+		if dirRel == rel || strings.HasPrefix(dirRel, rel+"/") {
+			delete(index.dirs, dirRel)
+		}
+	}
+
+	parent, name := path.Split(rel)
+	parent = strings.TrimSuffix(parent, "/")
+	if parent == "" {
+		parent = "."
+	}
+	if dir, ok := index.dirs[parent]; ok {
+		updated := *dir
+		updated.Files = removeName(dir.Files, name)
+		updated.Dirs = removeName(dir.Dirs, name)
+		index.dirs[parent] = &updated
+	}
+	index.paths = nil
+}
+
+// removeName returns a copy of names without name
+func removeName(names []string, name string) []string {
+	kept := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// loadFileIndex reads the persisted index of a root
+func (s *FileService) loadFileIndex(root string) (map[string]*indexDir, error) {
+	f, err := os.Open(s.fileIndexPath(root))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var data indexFile
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		return nil, err
+	}
+	if data.Version != fileIndexVersion || data.Root != root || data.Dirs == nil {
+		return nil, fmt.Errorf("outdated file index: %s", root)
+	}
+	return data.Dirs, nil
+}
+
+// saveFileIndex persists the index of a root, failures are only logged
+func (s *FileService) saveFileIndex(root string, dirs map[string]*indexDir) {
+	indexPath := s.fileIndexPath(root)
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0700); err != nil {
+		log.Printf("[FileService] Failed to save file index: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(indexPath), ".tmp-")
+	if err != nil {
+		log.Printf("[FileService] Failed to save file index: %v", err)
+		return
+	}
+	err = gob.NewEncoder(tmp).Encode(indexFile{Version: fileIndexVersion, Root: root, Dirs: dirs})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), indexPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("[FileService] Failed to save file index: %v", err)
+	}
+}
+
+// fileIndexPath returns where the index of a root is persisted
+func (s *FileService) fileIndexPath(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(s.dataDir, "index", hex.EncodeToString(sum[:8])+".gob")
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+func TestFindFilesFirstScanCancelled(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a", "dir/b.txt": "b"})
+	roots := []WorkspaceRoot{{Name: "project", Path: root}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.FindFiles(ctx, roots, FileSearchOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("search with a cancelled context returned %v, want context.Canceled", err)
+	}
+
+	// The cancelled scan leaves nothing behind, the next search scans again
+	page, err := s.FindFiles(context.Background(), roots, FileSearchOptions{Query: "b"})
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if page.Total != 1 || page.Files[0].Path != filepath.Join(root, "dir", "b.txt") {
+		t.Errorf("results = %+v, want dir/b.txt", page.Files)
+	}
+}
+
+func TestFileIndexReplaysEventsMissedByScan(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a"})
+	index, err := s.fileIndex(context.Background(), root)
+	if err != nil {
+		t.Fatalf("failed to build index: %v", err)
+	}
+
+	// A rescan reads the root, then a file is created before the scan result is swapped in
+	index.mu.Lock()
+	index.startScan()
+	index.mu.Unlock()
+	dirs, err := s.scanFileIndex(context.Background(), root, nil)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+	late := filepath.Join(root, "late.txt")
+	writeTestFile(t, late, "late")
+	s.updateFileIndex(&FileEvent{Type: FileEventAdd, Path: late, ProjectPath: root, Node: &FileNode{Path: late, Type: "file"}})
+	s.finishIndexScan(index, dirs, nil)
+
+	files := index.files()
+	if len(files) != 2 || files[0] != "a.txt" || files[1] != "late.txt" {
+		t.Errorf("indexed files = %q, want a.txt and late.txt", files)
+	}
+}
+
+func TestListFileOpensEscapesWildcards(t *testing.T) {
+	s := newTestFiles(t)
+	dir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(dir, "my_project", "a.txt"),
+		filepath.Join(dir, "myXproject", "b.txt"),
+		filepath.Join(dir, "100%", "c.txt"),
+		filepath.Join(dir, "1000", "d.txt"),
+	} {
+		s.RecordFileOpen(path)
+	}
+
+	for _, root := range []string{filepath.Join(dir, "my_project"), filepath.Join(dir, "100%")} {
+		opens, err := s.queries.ListFileOpens(context.Background(), db.ListFileOpensParams{
+			Path:  escapeLike(root+string(filepath.Separator)) + "%",
+			Limit: maxRankedFileOpens,
+		})
+		if err != nil {
+			t.Fatalf("failed to list opened files: %v", err)
+		}
+		if len(opens) != 1 || !isSubPath(root, opens[0].Path) {
+			t.Errorf("opened files in %s = %+v, want only the one inside it", root, opens)
+		}
+	}
+}
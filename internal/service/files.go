@@ -2,45 +2,111 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/edit4i/editor/internal/db"
 	ignore "github.com/sabhiram/go-gitignore"
-	"github.com/sahilm/fuzzy"
-	"io/fs"
 )
 
 // FileNode represents a file or directory in the project
 type FileNode struct {
 	Name         string      `json:"name"`
 	Path         string      `json:"path"`
-	Type         string      `json:"type"` // "file" or "directory"
+	Type         string      `json:"type"` // "file", "directory" or "workspace" for the merged roots of a workspace
 	Size         int64       `json:"size,omitempty"`
 	LastModified time.Time   `json:"lastModified"`
 	Children     []*FileNode `json:"children,omitempty"`
-	IsLoaded     bool        `json:"isLoaded"` // Indicates if directory contents are loaded
+	IsLoaded     bool        `json:"isLoaded"`             // Indicates if directory contents are loaded
+	Hidden       bool        `json:"hidden,omitempty"`     // Name starts with a dot
+	GitIgnored   bool        `json:"gitIgnored,omitempty"` // Matched by a .gitignore, shown dimmed
+	Symlink      bool        `json:"symlink,omitempty"`    // Symbolic link, Type is the type of its target
 }
 
+// defaultFilesExclude are the entries left out of the file tree unless configured otherwise
+var defaultFilesExclude = []string{".git", ".svn", ".hg", ".DS_Store", "Thumbs.db"}
+
 // FileService handles file operations for projects
 type FileService struct {
 	// Cache file trees with expiration
 	cache     map[string]*FileNode
 	cacheLock sync.RWMutex
-	// TODO: Add file watcher
-	ignores map[string]*ignore.GitIgnore
+	// Roots of the projects opened with GetProjectFiles, guarded by cacheLock. Unlike the cache they are never
+	// invalidated, the trash, the operation journal and the local history are keyed by them.
+	projects map[string]bool
+	// Compiled .gitignore files per directory
+	ignores     map[string]*ignore.GitIgnore
+	ignoresLock sync.Mutex
+	// Directory where the service keeps its own data, e.g. ~/.edit4i
+	dataDir string
+	// User configurable behaviour, set from the editor configuration
+	settings     FileSettings
+	exclude      *globFilter // Compiled settings.Exclude
+	settingsLock sync.RWMutex
+	// Large files opened for chunked access
+	largeFiles     map[string]*largeFile
+	largeFilesLock sync.Mutex
+	// Database holding the trash, the file operation journal, the local history and opened files
+	queries        *db.Queries
+	operationsLock sync.Mutex
+	// Persistent file indexes of the fuzzy file search, keyed by project root
+	indexes     map[string]*fileIndex
+	indexesLock sync.Mutex
+}
+
+// FileSettings holds the user configurable behaviour of the file service
+type FileSettings struct {
+	DefaultFormat      FileFormat    // Format of new files and files without line endings
+	LargeFileThreshold int64         // Files bigger than this many bytes are opened in large file mode, 0 disables it
+	TrashMaxAge        time.Duration // Trashed files older than this are purged, 0 keeps them forever
+	TrashMaxSize       int64         // The oldest trashed files are purged above this total size, 0 disables the limit
+	HistoryMaxAge      time.Duration // Snapshots older than this are deleted, 0 keeps them forever
+	HistoryMaxEntries  int           // Number of snapshots kept per file, 0 keeps all of them
+	Exclude            []string      // Glob patterns of entries left out of the file tree
+	ShowHidden         bool          // Show entries whose name starts with a dot
 }
 
 // NewFileService creates a new file service instance
-func NewFileService() *FileService {
-	return &FileService{
-		cache:   make(map[string]*FileNode),
-		ignores: make(map[string]*ignore.GitIgnore),
-	}
+func NewFileService(dbConn *sql.DB, dataDir string) *FileService {
+	s := &FileService{
+		cache:    make(map[string]*FileNode),
+		projects: make(map[string]bool),
+		ignores:  make(map[string]*ignore.GitIgnore),
+		dataDir:  dataDir,
+		settings: FileSettings{
+			DefaultFormat: FileFormat{
+				Encoding:   EncodingUTF8,
+				LineEnding: platformLineEnding(),
+			},
+			LargeFileThreshold: defaultLargeFileThreshold,
+			TrashMaxAge:        defaultTrashMaxAge,
+			TrashMaxSize:       defaultTrashMaxSize,
+			HistoryMaxAge:      defaultHistoryMaxAge,
+			HistoryMaxEntries:  defaultHistoryMaxEntries,
+			Exclude:            defaultFilesExclude,
+			ShowHidden:         true,
+		},
+		largeFiles: make(map[string]*largeFile),
+		queries:    db.New(dbConn),
+		indexes:    make(map[string]*fileIndex),
+	}
+	s.exclude, _ = newGlobFilter(nil, defaultFilesExclude)
+
+	// Roll back multi-file writes interrupted by a crash
+	if err := RecoverFileTransactions(s.journalDir()); err != nil {
+		log.Printf("[FileService] Failed to recover file transactions: %v", err)
+	}
+
+	return s
 }
 
 // GetProjectFiles returns the file tree for a project
@@ -64,11 +130,38 @@ func (s *FileService) GetProjectFiles(projectPath string) (*FileNode, error) {
 	// Update cache
 	s.cacheLock.Lock()
 	s.cache[projectPath] = root
+	s.projects[projectPath] = true
 	s.cacheLock.Unlock()
 
 	return root, nil
 }
 
+// GetWorkspaceFiles returns a merged tree with the top level of every workspace root as children.
+// Roots that no longer exist are left out.
+func (s *FileService) GetWorkspaceFiles(name string, roots []WorkspaceRoot) (*FileNode, error) {
+	node := &FileNode{
+		Name:     name,
+		Type:     "workspace",
+		Children: make([]*FileNode, 0, len(roots)),
+		IsLoaded: true,
+	}
+
+	for _, root := range roots {
+		tree, err := s.GetProjectFiles(root.Path)
+		if err != nil {
+			log.Printf("[FileService] Skipping workspace root %s: %v", root.Path, err)
+			continue
+		}
+
+		// Copy the cached node so the root can be shown under its workspace name
+		child := *tree
+		child.Name = root.Name
+		node.Children = append(node.Children, &child)
+	}
+
+	return node, nil
+}
+
 // buildTopLevelTree builds only the top level of the file tree
 func (s *FileService) buildTopLevelTree(root string) (*FileNode, error) {
 	info, err := os.Stat(root)
@@ -92,35 +185,10 @@ func (s *FileService) buildTopLevelTree(root string) (*FileNode, error) {
 
 		node.Children = make([]*FileNode, 0, len(entries))
 		for _, entry := range entries {
-			// Skip hidden files and directories
-			if entry.Name()[0] == '.' {
-				continue
-			}
-
-			childPath := filepath.Join(root, entry.Name())
-			childInfo, err := entry.Info()
-			if err != nil {
-				continue
-			}
-
-			childNode := &FileNode{
-				Name:         entry.Name(),
-				Path:         childPath,
-				LastModified: childInfo.ModTime(),
-				IsLoaded:     false,
+			// Don't load children of directories yet
+			if child := s.treeNode(root, filepath.Join(root, entry.Name())); child != nil {
+				node.Children = append(node.Children, child)
 			}
-
-			if entry.IsDir() {
-				childNode.Type = "directory"
-				// Don't load children yet
-				childNode.Children = []*FileNode{}
-			} else {
-				childNode.Type = "file"
-				childNode.Size = childInfo.Size()
-				childNode.IsLoaded = true // Files are always "loaded"
-			}
-
-			node.Children = append(node.Children, childNode)
 		}
 		node.IsLoaded = true // Mark top level as loaded
 	} else {
@@ -139,9 +207,11 @@ func (s *FileService) LoadDirectoryContents(dirPath string) (*FileNode, error) {
 
 	// Find the directory node in the cache
 	var dirNode *FileNode
-	for _, root := range s.cache {
+	var rootPath string
+	for path, root := range s.cache {
 		if node := s.findNode(root, dirPath); node != nil {
 			dirNode = node
+			rootPath = path
 			break
 		}
 	}
@@ -163,39 +233,94 @@ func (s *FileService) LoadDirectoryContents(dirPath string) (*FileNode, error) {
 
 	dirNode.Children = make([]*FileNode, 0, len(entries))
 	for _, entry := range entries {
-		if entry.Name()[0] == '.' {
-			continue
+		if child := s.treeNode(rootPath, filepath.Join(dirPath, entry.Name())); child != nil {
+			dirNode.Children = append(dirNode.Children, child)
 		}
+	}
 
-		childPath := filepath.Join(dirPath, entry.Name())
-		childInfo, err := entry.Info()
-		if err != nil {
-			continue
-		}
+	dirNode.IsLoaded = true
+	s.sortFileTree(dirNode)
 
-		childNode := &FileNode{
-			Name:         entry.Name(),
-			Path:         childPath,
-			LastModified: childInfo.ModTime(),
-			IsLoaded:     false,
-		}
+	return dirNode, nil
+}
+
+// newFileNode creates an unloaded tree node for a directory entry
+func newFileNode(path string, info os.FileInfo) *FileNode {
+	node := &FileNode{
+		Name:         filepath.Base(path),
+		Path:         path,
+		LastModified: info.ModTime(),
+		IsLoaded:     false,
+	}
+
+	if info.IsDir() {
+		node.Type = "directory"
+		node.Children = []*FileNode{}
+	} else {
+		node.Type = "file"
+		node.Size = info.Size()
+		node.IsLoaded = true // Files are always "loaded"
+	}
+
+	return node
+}
+
+// treeNode creates an unloaded tree node for an entry of a project with its hidden, ignored and symlink flags.
+// It returns nil for entries left out of the tree.
+func (s *FileService) treeNode(rootPath, path string) *FileNode {
+	if !s.isShown(rootPath, path) {
+		return nil
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
 
-		if entry.IsDir() {
-			childNode.Type = "directory"
-			childNode.Children = []*FileNode{}
-		} else {
-			childNode.Type = "file"
-			childNode.Size = childInfo.Size()
-			childNode.IsLoaded = true
+	symlink := info.Mode()&os.ModeSymlink != 0
+	if symlink {
+		// Links are shown as what they point to, dangling links as files
+		if target, err := os.Stat(path); err == nil {
+			info = target
 		}
+	}
 
-		dirNode.Children = append(dirNode.Children, childNode)
+	node := newFileNode(path, info)
+	node.Symlink = symlink
+	node.Hidden = isHiddenName(node.Name)
+	node.GitIgnored = s.isGitIgnored(rootPath, path, node.Type == "directory")
+	return node
+}
+
+// isShown reports whether an entry of a project appears in the tree, given the exclude and hidden settings
+func (s *FileService) isShown(rootPath, path string) bool {
+	s.settingsLock.RLock()
+	showHidden := s.settings.ShowHidden
+	s.settingsLock.RUnlock()
+
+	if !showHidden && isHiddenName(filepath.Base(path)) {
+		return false
 	}
 
-	dirNode.IsLoaded = true
-	s.sortFileTree(dirNode)
+	return !s.isExcluded(rootPath, path)
+}
 
-	return dirNode, nil
+// isExcluded reports whether an entry of a project matches the exclude patterns
+func (s *FileService) isExcluded(rootPath, path string) bool {
+	s.settingsLock.RLock()
+	exclude := s.exclude
+	s.settingsLock.RUnlock()
+
+	relPath, err := filepath.Rel(rootPath, path)
+	if err != nil || relPath == "." || exclude == nil {
+		return false
+	}
+	return !exclude.matches(filepath.ToSlash(relPath))
+}
+
+// isHiddenName reports whether a file name is hidden by convention
+func isHiddenName(name string) bool {
+	return strings.HasPrefix(name, ".")
 }
 
 // findNode recursively finds a node by path
@@ -215,31 +340,211 @@ func (s *FileService) findNode(root *FileNode, path string) *FileNode {
 	return nil
 }
 
-// GetFileContent reads and returns the content of a file
-func (s *FileService) GetFileContent(path string) (string, error) {
+// FileContent is the content of a file together with the version it was read at
+type FileContent struct {
+	Path             string     `json:"path"`
+	Content          string     `json:"content"` // Decoded text, always with "\n" line endings
+	Version          string     `json:"version"` // Token to pass back to SaveFile to detect external changes
+	Format           FileFormat `json:"format"`  // Encoding, BOM and dominant line ending on disk
+	MixedLineEndings bool       `json:"mixedLineEndings"`
+	Size             int64      `json:"size"`
+	IsLarge          bool       `json:"isLarge"` // Content is empty and must be read in chunks
+}
+
+// SaveOptions contains options for saving a file
+type SaveOptions struct {
+	Version string      `json:"version"`          // Version the content was based on, skips the conflict check if empty
+	Format  *FileFormat `json:"format,omitempty"` // Convert the file to this format, keeps the current one if nil
+}
+
+// FileConflictError is returned when a file changed on disk since the caller read it
+type FileConflictError struct {
+	Path string `json:"path"`
+}
+
+func (e *FileConflictError) Error() string {
+	return fmt.Sprintf("file was modified on disk: %s", e.Path)
+}
+
+// fileVersion builds the version token of a file from its modification time, size and content hash.
+// Without content the hash is left out, such versions of large files never validate a save.
+func fileVersion(info os.FileInfo, content []byte) string {
+	hash := ""
+	if content != nil {
+		hash = contentHash(content)
+	}
+	return fmt.Sprintf("%d:%d:%s", info.ModTime().UnixNano(), info.Size(), hash)
+}
+
+// checkFileVersion verifies that a file is still at the given version.
+// Time and size are compared first, so unchanged files are not hashed again.
+func checkFileVersion(path, version string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &FileConflictError{Path: path}
+		}
+		return err
+	}
+
+	parts := strings.SplitN(version, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid file version: %s", version)
+	}
+	if parts[2] == "" {
+		// The caller never had the content, saving would replace the file with a partial buffer
+		return fmt.Errorf("%s is open in large file mode and cannot be saved", path)
+	}
+	if parts[0] == strconv.FormatInt(info.ModTime().UnixNano(), 10) && parts[1] == strconv.FormatInt(info.Size(), 10) {
+		return nil
+	}
+
+	// Touched but not edited files keep the same content
 	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if contentHash(content) != parts[2] {
+		return &FileConflictError{Path: path}
+	}
+	return nil
+}
+
+// GetFileContent reads and returns the content of a file, its version and its format
+func (s *FileService) GetFileContent(path string) (*FileContent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Large files are not sent at once, the caller pages through them with ReadLines
+	if threshold := s.getSettings().LargeFileThreshold; threshold > 0 && info.Size() > threshold {
+		return &FileContent{
+			Path:    path,
+			Version: fileVersion(info, nil),
+			Size:    info.Size(),
+			IsLarge: true,
+		}, nil
+	}
+
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	text, format, mixed, err := decodeText(raw, s.getSettings().DefaultFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileContent{
+		Path:             path,
+		Content:          text,
+		Version:          fileVersion(info, raw),
+		Format:           format,
+		MixedLineEndings: mixed,
+		Size:             info.Size(),
+	}, nil
+}
+
+// SaveFile atomically saves content to a file and returns its new version.
+// The file keeps its current encoding, BOM and line ending unless opts asks for a conversion.
+// If opts has a version and the file changed on disk since then, a FileConflictError is returned.
+func (s *FileService) SaveFile(path string, content string, opts SaveOptions) (string, error) {
+	if opts.Version != "" {
+		if err := checkFileVersion(path, opts.Version); err != nil {
+			return "", err
+		}
+	}
+
+	format, err := s.saveFormat(path, opts.Format)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := encodeText(content, format)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the content on disk in the local history in case it changed outside the editor
+	if original, err := os.ReadFile(path); err == nil {
+		s.snapshotFile(path, original)
+	}
+
+	if err := writeFileAtomic(path, raw); err != nil {
+		return "", err
+	}
+	s.snapshotFile(path, raw)
+	s.invalidateSavedFile(path)
+
+	info, err := os.Stat(path)
 	if err != nil {
 		return "", err
 	}
-	return string(content), nil
+	return fileVersion(info, raw), nil
+}
+
+// invalidateSavedFile drops the cached tree of the directory of a file written by the editor
+func (s *FileService) invalidateSavedFile(path string) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	delete(s.cache, filepath.Dir(path))
+}
+
+// saveFormat returns the format a file is written in: the requested one,
+// the one it currently has on disk, or the configured default for new files
+func (s *FileService) saveFormat(path string, requested *FileFormat) (FileFormat, error) {
+	if requested != nil {
+		return normalizeFileFormat(*requested)
+	}
+
+	fallback := s.getSettings().DefaultFormat
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fallback, nil
+		}
+		return FileFormat{}, err
+	}
+
+	_, format, _, err := decodeText(raw, fallback)
+	return format, err
 }
 
-// SaveFile saves content to a file
-func (s *FileService) SaveFile(path string, content string) error {
-	err := os.WriteFile(path, []byte(content), 0644)
+// ApplySettings updates the user configurable behaviour of the service
+func (s *FileService) ApplySettings(settings FileSettings) error {
+	format, err := normalizeFileFormat(settings.DefaultFormat)
 	if err != nil {
 		return err
 	}
+	settings.DefaultFormat = format
 
-	// Invalidate cache for the project containing this file
-	s.cacheLock.Lock()
-	projectPath := filepath.Dir(path)
-	delete(s.cache, projectPath)
-	s.cacheLock.Unlock()
+	exclude, err := newGlobFilter(nil, settings.Exclude)
+	if err != nil {
+		return fmt.Errorf("invalid exclude pattern: %w", err)
+	}
 
+	s.settingsLock.Lock()
+	defer s.settingsLock.Unlock()
+	s.settings = settings
+	s.exclude = exclude
 	return nil
 }
 
+// getSettings returns the current settings of the service
+func (s *FileService) getSettings() FileSettings {
+	s.settingsLock.RLock()
+	defer s.settingsLock.RUnlock()
+	return s.settings
+}
+
 // InvalidateCache removes a project's file tree from cache
 func (s *FileService) InvalidateCache(projectPath string) {
 	s.cacheLock.Lock()
@@ -276,6 +581,9 @@ func (s *FileService) invalidateNode(node *FileNode, targetPath string) bool {
 
 // loadGitIgnore loads the gitignore file for a directory if it exists
 func (s *FileService) loadGitIgnore(dirPath string) *ignore.GitIgnore {
+	s.ignoresLock.Lock()
+	defer s.ignoresLock.Unlock()
+
 	if ig, ok := s.ignores[dirPath]; ok {
 		return ig
 	}
@@ -288,6 +596,14 @@ func (s *FileService) loadGitIgnore(dirPath string) *ignore.GitIgnore {
 	return nil
 }
 
+// resetGitIgnore drops the compiled gitignore of a directory so it is read again
+func (s *FileService) resetGitIgnore(dirPath string) {
+	s.ignoresLock.Lock()
+	defer s.ignoresLock.Unlock()
+
+	delete(s.ignores, dirPath)
+}
+
 // isIgnored checks if a path should be ignored based on gitignore rules
 func (s *FileService) isIgnored(rootPath, path string) bool {
 	// Always ignore .git directory
@@ -309,101 +625,43 @@ func (s *FileService) isIgnored(rootPath, path string) bool {
 	return false
 }
 
-// SearchFiles performs a fuzzy search on files in a directory
-func (s *FileService) SearchFiles(ctx context.Context, dirPath, query string) ([]*FileNode, error) {
-	var allFiles []*FileNode
-	var searchPaths []string
-
-	// Walk the directory tree
-	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Check for cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Skip if ignored
-		if s.isIgnored(dirPath, path) {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip directories in the results
-		if d.IsDir() {
-			return nil
-		}
-
-		// Get file info
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-
-		// Create file node
-		node := &FileNode{
-			Name:         d.Name(),
-			Path:         path,
-			Type:         "file",
-			Size:         info.Size(),
-			LastModified: info.ModTime(),
-			IsLoaded:     true,
-		}
-
-		// Get relative path for search
-		relPath, err := filepath.Rel(dirPath, path)
-		if err == nil {
-			searchPaths = append(searchPaths, relPath)
-			allFiles = append(allFiles, node)
-		}
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
+// isGitIgnored reports whether a tree entry is ignored by a .gitignore.
+// Directories are matched with a trailing slash as well, so patterns like "dist/" apply to them.
+func (s *FileService) isGitIgnored(rootPath, path string, isDir bool) bool {
+	if s.isIgnored(rootPath, path) {
+		return true
 	}
-
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+	if !isDir {
+		return false
 	}
 
-	// If no query, return first 10 files
-	if query == "" || len(allFiles) == 0 {
-		maxResults := 10
-		if len(allFiles) < maxResults {
-			maxResults = len(allFiles)
+	for dir := filepath.Dir(path); isSubPath(rootPath, dir); dir = filepath.Dir(dir) {
+		if ig := s.loadGitIgnore(dir); ig != nil {
+			relPath, err := filepath.Rel(dir, path)
+			if err == nil && ig.MatchesPath(filepath.ToSlash(relPath)+"/") {
+				return true
+			}
 		}
-		return allFiles[:maxResults], nil
-	}
-
-	// Use fuzzy library to search
-	matches := fuzzy.Find(query, searchPaths)
-
-	// Sort matches by score (already done by fuzzy.Find)
-	results := make([]*FileNode, 0, len(matches))
-	seen := make(map[string]bool)
-
-	for _, match := range matches {
-		if len(results) >= 10 {
+		if dir == rootPath {
 			break
 		}
+	}
+	return false
+}
 
-		// Skip if we've already added this file
-		if seen[allFiles[match.Index].Path] {
-			continue
-		}
+// SearchFiles performs a fuzzy search on files in a directory
+func (s *FileService) SearchFiles(ctx context.Context, dirPath, query string) ([]*FileNode, error) {
+	return s.SearchWorkspaceFiles(ctx, []WorkspaceRoot{{Path: dirPath}}, query)
+}
 
-		results = append(results, allFiles[match.Index])
-		seen[allFiles[match.Index].Path] = true
+// SearchWorkspaceFiles performs a fuzzy search over the files of every workspace root.
+// With several roots, paths are matched with the root name in front, e.g. "api/main.go".
+func (s *FileService) SearchWorkspaceFiles(ctx context.Context, roots []WorkspaceRoot, query string) ([]*FileNode, error) {
+	page, err := s.FindFiles(ctx, roots, FileSearchOptions{Query: query})
+	if err != nil {
+		return nil, err
 	}
-
-	return results, nil
+	return page.Files, nil
 }
 
 // sortFileTree sorts the file tree with folders first and by alphabetical order
@@ -450,6 +708,7 @@ func (s *FileService) CreateFile(path string) error {
 
 	// Invalidate cache for the project
 	s.InvalidateCache(filepath.Dir(path))
+	s.recordFileOperation(FileOperationCreateFile, path, "", false, 0)
 	return nil
 }
 
@@ -467,13 +726,15 @@ func (s *FileService) CreateDirectory(path string) error {
 
 	// Invalidate cache for the project
 	s.InvalidateCache(filepath.Dir(path))
+	s.recordFileOperation(FileOperationCreateDirectory, path, "", true, 0)
 	return nil
 }
 
 // RenameFile renames a file or directory
 func (s *FileService) RenameFile(oldPath, newPath string) error {
 	// Check if source exists
-	if _, err := os.Stat(oldPath); err != nil {
+	info, err := os.Lstat(oldPath)
+	if err != nil {
 		return fmt.Errorf("source not found: %s", oldPath)
 	}
 
@@ -496,22 +757,6 @@ func (s *FileService) RenameFile(oldPath, newPath string) error {
 	// Invalidate cache for both old and new parent directories
 	s.InvalidateCache(filepath.Dir(oldPath))
 	s.InvalidateCache(filepath.Dir(newPath))
-	return nil
-}
-
-// DeleteFile deletes a file or directory
-func (s *FileService) DeleteFile(path string) error {
-	// Check if path exists
-	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("path not found: %s", path)
-	}
-
-	// Remove file or directory
-	if err := os.RemoveAll(path); err != nil {
-		return fmt.Errorf("failed to delete: %v", err)
-	}
-
-	// Invalidate cache for the parent directory
-	s.InvalidateCache(filepath.Dir(path))
+	s.recordFileOperation(FileOperationRename, oldPath, newPath, info.IsDir(), 0)
 	return nil
 }
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edit4i/editor/db/migrations"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestFiles returns a file service backed by a fresh database with every migration applied
+func newTestFiles(t *testing.T) *FileService {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "editor.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		data, err := migrations.FS.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(data), "-- migrate:down")
+		if _, err := conn.Exec(strings.TrimPrefix(up, "-- migrate:up")); err != nil {
+			t.Fatalf("failed to apply %s: %v", name, err)
+		}
+	}
+
+	return NewFileService(conn, t.TempDir())
+}
+
+// writeTestFile writes a file, creating its parent directories
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestFile returns the content of a file, found is false if it doesn't exist
+func readTestFile(t *testing.T, path string) (content string, found bool) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestSaveFileRejectsStaleVersion(t *testing.T) {
+	s := newTestFiles(t)
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeTestFile(t, path, "one\n")
+
+	file, err := s.GetFileContent(path)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	version, err := s.SaveFile(path, "two\n", SaveOptions{Version: file.Version})
+	if err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	// The returned version is current, the one read before the save is not
+	if _, err := s.SaveFile(path, "three\n", SaveOptions{Version: file.Version}); !errors.As(err, new(*FileConflictError)) {
+		t.Fatalf("saving with a stale version returned %v, want a conflict", err)
+	}
+	if content, _ := readTestFile(t, path); content != "two\n" {
+		t.Fatalf("file = %q after a rejected save, want it unchanged", content)
+	}
+	if version, err = s.SaveFile(path, "three\n", SaveOptions{Version: version}); err != nil {
+		t.Fatalf("failed to save with the current version: %v", err)
+	}
+
+	// Touching a file without editing it keeps its version valid
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if version, err = s.SaveFile(path, "four\n", SaveOptions{Version: version}); err != nil {
+		t.Fatalf("failed to save a touched file: %v", err)
+	}
+
+	writeTestFile(t, path, "edited outside\n")
+	if _, err := s.SaveFile(path, "six\n", SaveOptions{Version: version}); !errors.As(err, new(*FileConflictError)) {
+		t.Errorf("saving over an external edit returned %v, want a conflict", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveFile(path, "six\n", SaveOptions{Version: version}); !errors.As(err, new(*FileConflictError)) {
+		t.Errorf("saving a deleted file returned %v, want a conflict", err)
+	}
+}
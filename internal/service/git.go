@@ -7,28 +7,39 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/utils/diff"
-	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 // FileStatus represents the status of a file in the Git repository
 type FileStatus struct {
 	File   string `json:"file"`   // File path relative to repository root
-	Status string `json:"status"` // Status code: "M" for modified, "A" for added, "D" for deleted and "?" for untracked
+	Status string `json:"status"` // Status code: "M" for modified, "A" for added, "D" for deleted, "?" for untracked and "U" for conflicted
 	Staged bool   `json:"staged"` // Whether the file is staged
 }
 
+// RootStatus is the Git status of one root of a workspace
+type RootStatus struct {
+	Root         string       `json:"root"`         // Absolute path of the root
+	IsRepository bool         `json:"isRepository"` // Whether the root is a Git repository
+	Files        []FileStatus `json:"files"`        // Changed files, relative to the root
+}
+
 // BranchInfo represents information about a Git branch
 type BranchInfo struct {
-	Name     string `json:"name"`
-	IsRemote bool   `json:"isRemote"`
-	IsHead   bool   `json:"isHead"`
+	Name       string      `json:"name"`
+	IsRemote   bool        `json:"isRemote"`
+	IsHead     bool        `json:"isHead"`
+	Upstream   string      `json:"upstream"`   // Tracked branch, e.g. "origin/main", empty if none
+	Ahead      int         `json:"ahead"`      // Commits of the branch missing from its upstream
+	Behind     int         `json:"behind"`     // Commits of the upstream missing from the branch
+	LastCommit *CommitInfo `json:"lastCommit"` // Commit the branch points to
 }
 
 // CommitInfo represents information about a Git commit
@@ -57,17 +68,19 @@ type CommitFilter struct {
 
 // FileDiff represents the diff information for a file
 type FileDiff struct {
-	Path     string    `json:"path"`     // File path
-	Content  string    `json:"content"`  // Diff content in unified format
-	Stats    DiffStats `json:"stats"`    // Statistics about the changes
-	IsBinary bool      `json:"isBinary"` // Whether the file is binary
+	Path     string     `json:"path"`     // File path
+	Content  string     `json:"content"`  // Diff content in unified format
+	Stats    DiffStats  `json:"stats"`    // Statistics about the changes
+	IsBinary bool       `json:"isBinary"` // Whether the file is binary
+	Hunks    []DiffHunk `json:"hunks"`    // Structured form of Content
+	Hash     string     `json:"hash"`     // Identifies the compared contents and options, see DiffSelection
 }
 
 // DiffStats contains statistics about changes in a diff
 type DiffStats struct {
 	Added    int `json:"added"`    // Number of added lines
 	Deleted  int `json:"deleted"`  // Number of deleted lines
-	Modified int `json:"modified"` // Number of modified lines
+	Modified int `json:"modified"` // Number of deleted lines replaced by an added line, counted in both Added and Deleted
 }
 
 // GitService handles Git operations for projects
@@ -157,11 +170,26 @@ func (s *GitService) GetStatus(projectPath string) ([]FileStatus, error) {
 		return nil, fmt.Errorf("failed to get status: %w", err)
 	}
 
-	// Convert status to our format
+	// Conflicted files are reported once, whatever their staged and unstaged changes
 	var files []FileStatus
+	conflicts, err := indexConflicts(repo)
+	if err != nil {
+		return nil, err
+	}
+	conflicted := make(map[string]bool, len(conflicts))
+	for _, conflict := range conflicts {
+		conflicted[conflict.Path] = true
+		files = append(files, FileStatus{
+			File:   conflict.Path,
+			Staged: false,
+			Status: string(git.UpdatedButUnmerged),
+		})
+	}
+
+	// Convert status to our format
 	for file, fileStatus := range status {
-		// Skip unmodified files
-		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+		// Skip unmodified and conflicted files
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified || conflicted[file] {
 			continue
 		}
 
@@ -197,6 +225,32 @@ func (s *GitService) GetStatus(projectPath string) ([]FileStatus, error) {
 	return files, nil
 }
 
+// GetRootsStatus returns the Git status of every root, roots that aren't repositories have no files
+func (s *GitService) GetRootsStatus(roots []string) ([]RootStatus, error) {
+	statuses := make([]RootStatus, 0, len(roots))
+	for _, root := range roots {
+		status := RootStatus{Root: root, Files: []FileStatus{}}
+
+		isRepo, err := s.IsGitRepository(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", root, err)
+		}
+		if isRepo {
+			files, err := s.GetStatus(root)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get status of %s: %w", root, err)
+			}
+			status.IsRepository = true
+			if files != nil {
+				status.Files = files
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
 // getWorktree is a helper function that returns the worktree for a given project path
 func (s *GitService) getWorktree(projectPath string) (*git.Worktree, error) {
 	repo, err := git.PlainOpen(projectPath)
@@ -227,17 +281,52 @@ func (s *GitService) StageFile(projectPath string, file string) error {
 	return nil
 }
 
+// UnstageFile resets the index entry of a file to HEAD, or removes it if the file isn't in HEAD.
+// The working copy is left untouched.
 func (s *GitService) UnstageFile(projectPath string, file string) error {
-	worktree, err := s.getWorktree(projectPath)
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	headEntry, err := headTreeEntry(repo, file)
 	if err != nil {
 		return err
 	}
 
-	_, err = worktree.Remove(file)
+	idx, err := repo.Storer.Index()
 	if err != nil {
-		return fmt.Errorf("failed to unstage file: %w", err)
+		return fmt.Errorf("failed to get index: %w", err)
 	}
 
+	if headEntry == nil {
+		// Newly added file, it becomes untracked
+		if _, err := idx.Remove(file); err != nil && err != index.ErrEntryNotFound {
+			return fmt.Errorf("failed to unstage file: %w", err)
+		}
+	} else {
+		size, err := repo.Storer.EncodedObjectSize(headEntry.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to get blob size: %w", err)
+		}
+
+		entry, err := idx.Entry(file)
+		if err == index.ErrEntryNotFound {
+			// Staged deletion, the file is tracked again
+			entry = idx.Add(file)
+		} else if err != nil {
+			return fmt.Errorf("failed to get index entry: %w", err)
+		}
+		entry.Hash = headEntry.Hash
+		entry.Mode = headEntry.Mode
+		entry.Size = uint32(size)
+		// The entry no longer describes the working copy, a zero time makes git compare them again
+		entry.ModifiedAt = time.Time{}
+	}
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
 	return nil
 }
 
@@ -324,30 +413,23 @@ func (s *GitService) DiscardChanges(projectPath string, file string) error {
 	return nil
 }
 
-// Commit creates a new commit with the staged changes
-func (s *GitService) Commit(projectPath string, message string) error {
-	worktree, err := s.getWorktree(projectPath)
-	if err != nil {
-		return err
-	}
-
-	// Create the commit
-	_, err = worktree.Commit(message, &git.CommitOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to create commit: %w", err)
-	}
-
-	return nil
+// Commit creates a new commit with the staged changes and returns it
+func (s *GitService) Commit(projectPath string, message string) (*CommitInfo, error) {
+	return s.CommitWithOptions(projectPath, message, CommitOptions{})
 }
 
 // ListBranches returns a list of all branches in the repository
+// Remote branches are the remote-tracking branches of the last fetch
 func (s *GitService) ListBranches(projectPath string) ([]BranchInfo, error) {
 	repo, err := git.PlainOpen(projectPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	branches := []BranchInfo{}
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
 
 	// Get current branch to mark the HEAD
 	head, err := repo.Head()
@@ -356,48 +438,31 @@ func (s *GitService) ListBranches(projectPath string) ([]BranchInfo, error) {
 	}
 	currentBranchName := head.Name().Short()
 
-	// List local branches
-	branchIter, err := repo.Branches()
+	branches := []BranchInfo{}
+	refs, err := repo.References()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list branches: %w", err)
 	}
 
-	err = branchIter.ForEach(func(ref *plumbing.Reference) error {
-		branchName := ref.Name().Short()
-		branches = append(branches, BranchInfo{
-			Name:     branchName,
-			IsRemote: false,
-			IsHead:   branchName == currentBranchName,
-		})
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// Symbolic references like origin/HEAD aren't branches
+		if ref.Type() != plumbing.HashReference || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			return nil
+		}
+		branches = append(branches, *s.branchInfo(repo, cfg, ref, currentBranchName))
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to iterate branches: %w", err)
 	}
 
-	// List remote branches
-	remotes, err := repo.Remotes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list remotes: %w", err)
-	}
-
-	for _, remote := range remotes {
-		refs, err := remote.List(&git.ListOptions{})
-		if err != nil {
-			continue // Skip this remote if we can't list its refs
+	// Local branches first
+	sort.SliceStable(branches, func(i, j int) bool {
+		if branches[i].IsRemote != branches[j].IsRemote {
+			return !branches[i].IsRemote
 		}
-
-		for _, ref := range refs {
-			if ref.Name().IsBranch() {
-				branchName := ref.Name().Short()
-				branches = append(branches, BranchInfo{
-					Name:     branchName,
-					IsRemote: true,
-					IsHead:   false,
-				})
-			}
-		}
-	}
+		return branches[i].Name < branches[j].Name
+	})
 
 	return branches, nil
 }
@@ -603,25 +668,18 @@ func (s *GitService) GetHeadCommit(projectPath string) (*CommitInfo, error) {
 		return nil, fmt.Errorf("failed to get commit object: %w", err)
 	}
 
-	parentHashes := make([]string, len(commit.ParentHashes))
-	for i, hash := range commit.ParentHashes {
-		parentHashes[i] = hash.String()
-	}
-
-	return &CommitInfo{
-		Hash:         commit.Hash.String(),
-		Message:      commit.Message,
-		Author:       commit.Author.Name,
-		AuthorEmail:  commit.Author.Email,
-		Date:         commit.Author.When,
-		ParentHashes: parentHashes,
-	}, nil
+	return commitInfo(commit), nil
 }
 
 // GetFileDiff returns the diff for a specific file
 // If staged is true, returns the diff between HEAD and staged changes
 // If staged is false, returns the diff between staged/HEAD and working directory
 func (s *GitService) GetFileDiff(projectPath string, filePath string, staged bool) (*FileDiff, error) {
+	return s.GetFileDiffWithOptions(projectPath, filePath, staged, DiffOptions{})
+}
+
+// GetFileDiffWithOptions returns the diff for a specific file with a custom context and whitespace mode
+func (s *GitService) GetFileDiffWithOptions(projectPath string, filePath string, staged bool, opts DiffOptions) (*FileDiff, error) {
 	repo, err := git.PlainOpen(projectPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
@@ -670,17 +728,7 @@ func (s *GitService) GetFileDiff(projectPath string, filePath string, staged boo
 			return nil, fmt.Errorf("failed to get file contents: %w", err)
 		}
 
-		diff, stats, err := s.generateDiff(content, "", filePath)
-		if err != nil {
-			return nil, err
-		}
-
-		return &FileDiff{
-			Path:     filePath,
-			Content:  diff,
-			Stats:    stats,
-			IsBinary: false,
-		}, nil
+		return buildDiff(content, "", filePath, filePath, opts)
 	}
 
 	// Check if file is binary (only for non-deleted files)
@@ -696,49 +744,34 @@ func (s *GitService) GetFileDiff(projectPath string, filePath string, staged boo
 		}, nil
 	}
 
-	var diff string
-	var stats DiffStats
-
 	if staged {
 		// Get diff between HEAD and index
-		diff, stats, err = s.getStagedDiff(repo, worktree, filePath)
-	} else {
-		// Get diff between index/HEAD and working directory
-		diff, stats, err = s.getWorkingDiff(repo, worktree, filePath, fileStatus.Staging == git.Untracked)
-	}
-
-	if err != nil {
-		return nil, err
+		return s.getStagedDiff(repo, worktree, filePath, opts)
 	}
-
-	return &FileDiff{
-		Path:     filePath,
-		Content:  diff,
-		Stats:    stats,
-		IsBinary: false,
-	}, nil
+	// Get diff between index/HEAD and working directory
+	return s.getWorkingDiff(repo, worktree, filePath, fileStatus.Staging == git.Untracked, opts)
 }
 
 // getStagedDiff returns the diff between HEAD and index
-func (s *GitService) getStagedDiff(repo *git.Repository, worktree *git.Worktree, filePath string) (string, DiffStats, error) {
+func (s *GitService) getStagedDiff(repo *git.Repository, worktree *git.Worktree, filePath string, opts DiffOptions) (*FileDiff, error) {
 	head, err := repo.Head()
 	if err != nil {
 		if err == plumbing.ErrReferenceNotFound {
 			// If no HEAD (new repo), compare with empty tree
-			return s.getDiffWithEmpty(worktree, filePath, true)
+			return s.getDiffWithEmpty(worktree, filePath, true, opts)
 		}
-		return "", DiffStats{}, fmt.Errorf("failed to get HEAD: %w", err)
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
 	commit, err := repo.CommitObject(head.Hash())
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get commit: %w", err)
+		return nil, fmt.Errorf("failed to get commit: %w", err)
 	}
 
 	// Get the tree for HEAD
 	headTree, err := commit.Tree()
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get tree: %w", err)
+		return nil, fmt.Errorf("failed to get tree: %w", err)
 	}
 
 	var oldContent string
@@ -747,14 +780,14 @@ func (s *GitService) getStagedDiff(repo *git.Repository, worktree *git.Worktree,
 	if headFile, err := headTree.File(filePath); err == nil {
 		oldContent, err = headFile.Contents()
 		if err != nil {
-			return "", DiffStats{}, fmt.Errorf("failed to get HEAD file contents: %w", err)
+			return nil, fmt.Errorf("failed to get HEAD file contents: %w", err)
 		}
 	}
 
 	// Get index content using the underlying index
 	idx, err := repo.Storer.Index()
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get index: %w", err)
+		return nil, fmt.Errorf("failed to get index: %w", err)
 	}
 
 	// Find the entry in the index
@@ -764,32 +797,32 @@ func (s *GitService) getStagedDiff(repo *git.Repository, worktree *git.Worktree,
 			// Get the object from the repository
 			obj, err := repo.BlobObject(entry.Hash)
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to get blob object: %w", err)
+				return nil, fmt.Errorf("failed to get blob object: %w", err)
 			}
 
 			// Read the blob content
 			reader, err := obj.Reader()
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to get blob reader: %w", err)
+				return nil, fmt.Errorf("failed to get blob reader: %w", err)
 			}
 			defer reader.Close()
 
 			content, err := io.ReadAll(reader)
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to read blob content: %w", err)
+				return nil, fmt.Errorf("failed to read blob content: %w", err)
 			}
 			newContent = string(content)
 			break
 		}
 	}
 
-	return s.generateDiff(oldContent, newContent, filePath)
+	return buildDiff(oldContent, newContent, filePath, filePath, opts)
 }
 
 // getWorkingDiff returns the diff between index/HEAD and working directory
-func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree, filePath string, isUntracked bool) (string, DiffStats, error) {
+func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree, filePath string, isUntracked bool, opts DiffOptions) (*FileDiff, error) {
 	if isUntracked {
-		return s.getDiffWithEmpty(worktree, filePath, false)
+		return s.getDiffWithEmpty(worktree, filePath, false, opts)
 	}
 
 	var oldContent string
@@ -797,7 +830,7 @@ func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree
 	// Try to get content from index first
 	idx, err := repo.Storer.Index()
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get index: %w", err)
+		return nil, fmt.Errorf("failed to get index: %w", err)
 	}
 
 	foundInIndex := false
@@ -806,19 +839,19 @@ func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree
 			// Get the object from the repository
 			obj, err := repo.BlobObject(entry.Hash)
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to get blob object: %w", err)
+				return nil, fmt.Errorf("failed to get blob object: %w", err)
 			}
 
 			// Read the blob content
 			reader, err := obj.Reader()
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to get blob reader: %w", err)
+				return nil, fmt.Errorf("failed to get blob reader: %w", err)
 			}
 			defer reader.Close()
 
 			content, err := io.ReadAll(reader)
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to read blob content: %w", err)
+				return nil, fmt.Errorf("failed to read blob content: %w", err)
 			}
 			oldContent = string(content)
 			foundInIndex = true
@@ -831,25 +864,25 @@ func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree
 		head, err := repo.Head()
 		if err != nil {
 			if err == plumbing.ErrReferenceNotFound {
-				return s.getDiffWithEmpty(worktree, filePath, false)
+				return s.getDiffWithEmpty(worktree, filePath, false, opts)
 			}
-			return "", DiffStats{}, fmt.Errorf("failed to get HEAD: %w", err)
+			return nil, fmt.Errorf("failed to get HEAD: %w", err)
 		}
 
 		commit, err := repo.CommitObject(head.Hash())
 		if err != nil {
-			return "", DiffStats{}, fmt.Errorf("failed to get commit: %w", err)
+			return nil, fmt.Errorf("failed to get commit: %w", err)
 		}
 
 		tree, err := commit.Tree()
 		if err != nil {
-			return "", DiffStats{}, fmt.Errorf("failed to get tree: %w", err)
+			return nil, fmt.Errorf("failed to get tree: %w", err)
 		}
 
 		if headFile, err := tree.File(filePath); err == nil {
 			oldContent, err = headFile.Contents()
 			if err != nil {
-				return "", DiffStats{}, fmt.Errorf("failed to get HEAD file contents: %w", err)
+				return nil, fmt.Errorf("failed to get HEAD file contents: %w", err)
 			}
 		}
 	}
@@ -857,14 +890,14 @@ func (s *GitService) getWorkingDiff(repo *git.Repository, worktree *git.Worktree
 	// Get working directory content
 	newContent, err := s.getFileContents(filepath.Join(worktree.Filesystem.Root(), filePath))
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get working file contents: %w", err)
+		return nil, fmt.Errorf("failed to get working file contents: %w", err)
 	}
 
-	return s.generateDiff(oldContent, newContent, filePath)
+	return buildDiff(oldContent, newContent, filePath, filePath, opts)
 }
 
 // getDiffWithEmpty returns a diff comparing with an empty file
-func (s *GitService) getDiffWithEmpty(worktree *git.Worktree, filePath string, staged bool) (string, DiffStats, error) {
+func (s *GitService) getDiffWithEmpty(worktree *git.Worktree, filePath string, staged bool, opts DiffOptions) (*FileDiff, error) {
 	var content string
 	var err error
 
@@ -872,71 +905,10 @@ func (s *GitService) getDiffWithEmpty(worktree *git.Worktree, filePath string, s
 	// since we're dealing with a new file
 	content, err = s.getFileContents(filepath.Join(worktree.Filesystem.Root(), filePath))
 	if err != nil {
-		return "", DiffStats{}, fmt.Errorf("failed to get file contents: %w", err)
-	}
-
-	return s.generateDiff("", content, filePath)
-}
-
-// generateDiff creates a unified diff from old and new content
-func (s *GitService) generateDiff(oldContent, newContent, filePath string) (string, DiffStats, error) {
-	// For deleted files, show all lines as deleted
-	if newContent == "" && oldContent != "" {
-		lines := strings.Split(strings.TrimSuffix(oldContent, "\n"), "\n")
-		stats := DiffStats{
-			Deleted: len(lines),
-		}
-		var diffOutput strings.Builder
-
-		// Write diff header
-		fmt.Fprintf(&diffOutput, "--- a/%s\n+++ b/%s\n", filePath, filePath)
-		fmt.Fprintf(&diffOutput, "@@ -1,%d +0,0 @@\n", len(lines))
-
-		// Show each line as deleted
-		for _, line := range lines {
-			fmt.Fprintf(&diffOutput, "-%s\n", line)
-		}
-
-		return diffOutput.String(), stats, nil
-	}
-
-	// Normalize line endings and split into lines
-	oldLines := strings.Split(strings.TrimSuffix(oldContent, "\n"), "\n")
-	newLines := strings.Split(strings.TrimSuffix(newContent, "\n"), "\n")
-
-	// Calculate diffs using go-git/go-diff
-	diffs := diff.Do(strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
-
-	stats := DiffStats{}
-	var diffOutput strings.Builder
-
-	// Write diff header
-	fmt.Fprintf(&diffOutput, "--- a/%s\n+++ b/%s\n", filePath, filePath)
-
-	// Calculate stats and build diff output
-	for _, d := range diffs {
-		switch d.Type {
-		case diffmatchpatch.DiffDelete:
-			lines := strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
-			stats.Deleted += len(lines)
-			for _, line := range lines {
-				fmt.Fprintf(&diffOutput, "-%s\n", line)
-			}
-		case diffmatchpatch.DiffInsert:
-			lines := strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
-			stats.Added += len(lines)
-			for _, line := range lines {
-				fmt.Fprintf(&diffOutput, "+%s\n", line)
-			}
-		case diffmatchpatch.DiffEqual:
-			lines := strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n")
-			for _, line := range lines {
-				fmt.Fprintf(&diffOutput, " %s\n", line)
-			}
-		}
+		return nil, fmt.Errorf("failed to get file contents: %w", err)
 	}
 
-	return diffOutput.String(), stats, nil
+	return buildDiff("", content, filePath, filePath, opts)
 }
 
 // getFileContents reads a file's contents
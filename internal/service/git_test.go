@@ -0,0 +1,462 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testRepo is a temporary repository with one commit containing the given files
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newTestRepo(t *testing.T, files map[string]string) *testRepo {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+	r := &testRepo{t: t, dir: dir, repo: repo}
+
+	// Stashes and merges commit with the configured identity
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name = "Test"
+	cfg.User.Email = "test@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) > 0 {
+		for name, content := range files {
+			r.write(name, content)
+			r.add(name)
+		}
+		r.commit("initial")
+	}
+	return r
+}
+
+func (r *testRepo) write(name, content string) {
+	r.t.Helper()
+	path := filepath.Join(r.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+func (r *testRepo) read(name string) (string, bool) {
+	r.t.Helper()
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func (r *testRepo) add(name string) {
+	r.t.Helper()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		r.t.Fatalf("failed to add %s: %v", name, err)
+	}
+}
+
+func (r *testRepo) commit(message string) {
+	r.t.Helper()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	_, err = worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatalf("failed to commit: %v", err)
+	}
+}
+
+// status returns the staging and worktree codes of a file, e.g. "A " or " D"
+func (r *testRepo) status(name string) string {
+	r.t.Helper()
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	fs, ok := status[name]
+	if !ok {
+		// Unmodified files are left out of the status
+		return "  "
+	}
+	return string(fs.Staging) + string(fs.Worktree)
+}
+
+// staged returns the content of a file in the index
+func (r *testRepo) staged(name string) (string, bool) {
+	r.t.Helper()
+	content, found, err := stagedFile(r.repo, name)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return content, found
+}
+
+func (r *testRepo) unstage(name string) {
+	r.t.Helper()
+	if err := NewGitService().UnstageFile(r.dir, name); err != nil {
+		r.t.Fatalf("failed to unstage %s: %v", name, err)
+	}
+}
+
+func TestUnstageAddedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"README.md": "readme\n"})
+	r.write("new.txt", "new\n")
+	r.add("new.txt")
+	if got := r.status("new.txt"); got != "A " {
+		t.Fatalf("status before unstage = %q, want %q", got, "A ")
+	}
+
+	r.unstage("new.txt")
+
+	if got := r.status("new.txt"); got != "??" {
+		t.Errorf("status = %q, want %q", got, "??")
+	}
+	if _, found := r.staged("new.txt"); found {
+		t.Error("file is still in the index")
+	}
+	if content, ok := r.read("new.txt"); !ok || content != "new\n" {
+		t.Errorf("working copy = %q (exists %v), want %q", content, ok, "new\n")
+	}
+}
+
+func TestUnstageAddedFileWithoutCommits(t *testing.T) {
+	r := newTestRepo(t, nil)
+	r.write("first.txt", "first\n")
+	r.add("first.txt")
+
+	r.unstage("first.txt")
+
+	if got := r.status("first.txt"); got != "??" {
+		t.Errorf("status = %q, want %q", got, "??")
+	}
+	if content, ok := r.read("first.txt"); !ok || content != "first\n" {
+		t.Errorf("working copy = %q (exists %v), want %q", content, ok, "first\n")
+	}
+}
+
+func TestUnstageModifiedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	if got := r.status("file.txt"); got != "M " {
+		t.Fatalf("status before unstage = %q, want %q", got, "M ")
+	}
+
+	r.unstage("file.txt")
+
+	if got := r.status("file.txt"); got != " M" {
+		t.Errorf("status = %q, want %q", got, " M")
+	}
+	if content, _ := r.staged("file.txt"); content != "one\n" {
+		t.Errorf("index = %q, want the HEAD content %q", content, "one\n")
+	}
+	if content, ok := r.read("file.txt"); !ok || content != "two\n" {
+		t.Errorf("working copy = %q (exists %v), want %q", content, ok, "two\n")
+	}
+}
+
+func TestUnstageKeepsUnstagedChanges(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	r.write("file.txt", "three\n")
+
+	r.unstage("file.txt")
+
+	if got := r.status("file.txt"); got != " M" {
+		t.Errorf("status = %q, want %q", got, " M")
+	}
+	if content, _ := r.read("file.txt"); content != "three\n" {
+		t.Errorf("working copy = %q, want %q", content, "three\n")
+	}
+}
+
+func TestUnstageDeletedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "content\n", "other.txt": "other\n"})
+	if err := os.Remove(filepath.Join(r.dir, "file.txt")); err != nil {
+		t.Fatal(err)
+	}
+	r.add("file.txt")
+	if got := r.status("file.txt"); got != "D " {
+		t.Fatalf("status before unstage = %q, want %q", got, "D ")
+	}
+
+	r.unstage("file.txt")
+
+	if got := r.status("file.txt"); got != " D" {
+		t.Errorf("status = %q, want %q", got, " D")
+	}
+	if content, found := r.staged("file.txt"); !found || content != "content\n" {
+		t.Errorf("index = %q (found %v), want %q", content, found, "content\n")
+	}
+	if _, ok := r.read("file.txt"); ok {
+		t.Error("deleted file was restored in the working copy")
+	}
+}
+
+func TestUnstageRenamedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"old.txt": "content\n"})
+	if err := os.Rename(filepath.Join(r.dir, "old.txt"), filepath.Join(r.dir, "new.txt")); err != nil {
+		t.Fatal(err)
+	}
+	r.add("old.txt")
+	r.add("new.txt")
+
+	// Unstaging the new path leaves the deletion of the old one staged
+	r.unstage("new.txt")
+	if got := r.status("new.txt"); got != "??" {
+		t.Errorf("status of new path = %q, want %q", got, "??")
+	}
+	if got := r.status("old.txt"); got != "D " {
+		t.Errorf("status of old path = %q, want %q", got, "D ")
+	}
+
+	r.unstage("old.txt")
+	if got := r.status("old.txt"); got != " D" {
+		t.Errorf("status of old path = %q, want %q", got, " D")
+	}
+	if _, ok := r.read("old.txt"); ok {
+		t.Error("old path was restored in the working copy")
+	}
+	if content, ok := r.read("new.txt"); !ok || content != "content\n" {
+		t.Errorf("new path = %q (exists %v), want %q", content, ok, "content\n")
+	}
+}
+
+func TestUnstageFileInSubdirectory(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"src/main.go": "package main\n"})
+	r.write("src/main.go", "package main\n\nfunc main() {}\n")
+	r.add("src/main.go")
+
+	r.unstage("src/main.go")
+
+	if got := r.status("src/main.go"); got != " M" {
+		t.Errorf("status = %q, want %q", got, " M")
+	}
+	if content, _ := r.staged("src/main.go"); content != "package main\n" {
+		t.Errorf("index = %q, want %q", content, "package main\n")
+	}
+}
+
+func TestUnstageUnchangedFile(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "content\n"})
+
+	r.unstage("file.txt")
+
+	if got := r.status("file.txt"); got != "  " {
+		t.Errorf("status = %q, want an unmodified file", got)
+	}
+}
+
+// headCommit returns the commit HEAD points to
+func (r *testRepo) headCommit() *object.Commit {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return commit
+}
+
+func TestCommitAmendKeepsAuthorAndParents(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	s := NewGitService()
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	author := &CommitIdentity{Name: "Author", Email: "author@example.com"}
+	if _, err := s.CommitWithOptions(r.dir, "second", CommitOptions{Author: author}); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	original := r.headCommit()
+
+	r.write("file.txt", "three\n")
+	r.add("file.txt")
+	if _, err := s.CommitWithOptions(r.dir, "second, amended", CommitOptions{Amend: true}); err != nil {
+		t.Fatalf("failed to amend: %v", err)
+	}
+
+	amended := r.headCommit()
+	if amended.Hash == original.Hash {
+		t.Fatal("HEAD didn't change")
+	}
+	if len(amended.ParentHashes) != 1 || amended.ParentHashes[0] != original.ParentHashes[0] {
+		t.Errorf("parents = %v, want those of the amended commit %v", amended.ParentHashes, original.ParentHashes)
+	}
+	if amended.Author.Name != "Author" || amended.Author.Email != "author@example.com" {
+		t.Errorf("author = %s <%s>, want the original author", amended.Author.Name, amended.Author.Email)
+	}
+	if amended.Committer.Name != "Test" {
+		t.Errorf("committer = %s, want the configured identity", amended.Committer.Name)
+	}
+	if amended.Message != "second, amended\n" {
+		t.Errorf("message = %q, want the new one", amended.Message)
+	}
+	file, err := amended.File("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := file.Contents(); content != "three\n" {
+		t.Errorf("file.txt = %q, want the newly staged content", content)
+	}
+}
+
+func TestCommitSignOff(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	info, err := NewGitService().CommitWithOptions(r.dir, "change\n\nbody", CommitOptions{SignOff: true})
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if want := "change\n\nbody\n\nSigned-off-by: Test <test@example.com>\n"; info.Message != want {
+		t.Errorf("message = %q, want %q", info.Message, want)
+	}
+}
+
+func TestAddTrailer(t *testing.T) {
+	const trailer = "Signed-off-by: Test <test@example.com>"
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"subject only", "subject\n", "subject\n\n" + trailer + "\n"},
+		{"subject looking like a trailer", "fix: typo\n", "fix: typo\n\n" + trailer + "\n"},
+		{"body", "subject\n\nbody\n", "subject\n\nbody\n\n" + trailer + "\n"},
+		{"trailer block", "subject\n\nCo-authored-by: A <a@example.com>\n", "subject\n\nCo-authored-by: A <a@example.com>\n" + trailer + "\n"},
+		{"already signed off", "subject\n\n" + trailer + "\nReviewed-by: B <b@example.com>\n", "subject\n\n" + trailer + "\nReviewed-by: B <b@example.com>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addTrailer(tt.message, trailer); got != tt.want {
+				t.Errorf("addTrailer(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitIdentityWithoutConfig(t *testing.T) {
+	// Hide the global config so only the overrides are left
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	cfg, err := r.repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name, cfg.User.Email = "", ""
+	cfg.Raw.RemoveSection("user")
+	if err := r.repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	s := NewGitService()
+
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	if _, err := s.CommitWithOptions(r.dir, "no identity", CommitOptions{}); err == nil {
+		t.Fatal("committing without an identity succeeded")
+	}
+
+	// The author stands in as committer
+	author := &CommitIdentity{Name: "Author", Email: "author@example.com"}
+	if _, err := s.CommitWithOptions(r.dir, "author", CommitOptions{Author: author}); err != nil {
+		t.Fatalf("failed to commit with an author: %v", err)
+	}
+	commit := r.headCommit()
+	if commit.Author.Email != author.Email || commit.Committer.Email != author.Email {
+		t.Errorf("author %s, committer %s, want both %s", commit.Author.Email, commit.Committer.Email, author.Email)
+	}
+
+	// and the committer as author
+	r.write("file.txt", "three\n")
+	r.add("file.txt")
+	committer := &CommitIdentity{Name: "Committer", Email: "committer@example.com"}
+	if _, err := s.CommitWithOptions(r.dir, "committer", CommitOptions{Committer: committer}); err != nil {
+		t.Fatalf("failed to commit with a committer: %v", err)
+	}
+	commit = r.headCommit()
+	if commit.Author.Email != committer.Email || commit.Committer.Email != committer.Email {
+		t.Errorf("author %s, committer %s, want both %s", commit.Author.Email, commit.Committer.Email, committer.Email)
+	}
+
+	invalid := &CommitIdentity{Name: "Bad <name>", Email: "bad@example.com"}
+	if _, err := s.CommitWithOptions(r.dir, "invalid", CommitOptions{Author: invalid, AllowEmpty: true}); err == nil {
+		t.Error("an identity with angle brackets was accepted")
+	}
+}
+
+func TestCommitAllowEmpty(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	s := NewGitService()
+	parent := r.headCommit()
+
+	if _, err := s.CommitWithOptions(r.dir, "empty", CommitOptions{}); err == nil {
+		t.Fatal("committing without staged changes succeeded")
+	}
+	if r.headCommit().Hash != parent.Hash {
+		t.Fatal("HEAD moved after a rejected commit")
+	}
+
+	if _, err := s.CommitWithOptions(r.dir, "empty", CommitOptions{AllowEmpty: true}); err != nil {
+		t.Fatalf("failed to commit with AllowEmpty: %v", err)
+	}
+	commit := r.headCommit()
+	if commit.TreeHash != parent.TreeHash || len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != parent.Hash {
+		t.Errorf("commit = %s with tree %s, want an empty commit on %s", commit.Hash, commit.TreeHash, parent.Hash)
+	}
+}
+
+func TestCommitRejectsBlankMessage(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("file.txt", "two\n")
+	r.add("file.txt")
+	parent := r.headCommit()
+
+	for _, message := range []string{"", "  \n\t\n"} {
+		if _, err := NewGitService().CommitWithOptions(r.dir, message, CommitOptions{}); err == nil {
+			t.Errorf("committing with message %q succeeded", message)
+		}
+	}
+	if r.headCommit().Hash != parent.Hash {
+		t.Error("HEAD moved after a rejected commit")
+	}
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// treeFile is a file of a flattened tree
+type treeFile struct {
+	Mode filemode.FileMode
+	Hash plumbing.Hash
+}
+
+// commitInfo converts a commit object into a CommitInfo
+func commitInfo(commit *object.Commit) *CommitInfo {
+	parentHashes := make([]string, len(commit.ParentHashes))
+	for i, hash := range commit.ParentHashes {
+		parentHashes[i] = hash.String()
+	}
+
+	return &CommitInfo{
+		Hash:         commit.Hash.String(),
+		Message:      commit.Message,
+		Author:       commit.Author.Name,
+		AuthorEmail:  commit.Author.Email,
+		Date:         commit.Author.When,
+		ParentHashes: parentHashes,
+	}
+}
+
+// gitSignature returns the identity configured for new commits
+func gitSignature(repo *git.Repository) (*object.Signature, error) {
+	cfg, err := repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if cfg.User.Name == "" || cfg.User.Email == "" {
+		return nil, errors.New("user.name and user.email must be set in the git config")
+	}
+	return &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}, nil
+}
+
+// resolveCommit returns the commit a revision points to, e.g. a hash, a branch or a tag.
+// An empty revision stands for HEAD.
+func resolveCommit(repo *git.Repository, revision string) (*object.Commit, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s: %w", revision, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	return commit, nil
+}
+
+// writeBlob stores content as a blob and returns its hash
+func writeBlob(repo *git.Repository, content []byte) (plumbing.Hash, error) {
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+
+	writer, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to create blob: %w", err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		writer.Close()
+		return plumbing.ZeroHash, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store blob: %w", err)
+	}
+	return hash, nil
+}
+
+// readBlob returns the content of a blob
+func readBlob(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob object: %w", err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob reader: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob content: %w", err)
+	}
+	return data, nil
+}
+
+// treeFiles flattens a tree into its files, by slash separated path
+func treeFiles(tree *object.Tree) (map[string]treeFile, error) {
+	files := make(map[string]treeFile)
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk tree: %w", err)
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		files[name] = treeFile{Mode: entry.Mode, Hash: entry.Hash}
+	}
+	return files, nil
+}
+
+// writeTree stores the trees of a flattened list of files and returns the hash of the root tree
+func writeTree(repo *git.Repository, files map[string]treeFile) (plumbing.Hash, error) {
+	// Group the files by directory, every parent directory gets an entry in its own parent
+	dirs := map[string][]object.TreeEntry{"": {}}
+	var addDir func(dir string)
+	addDir = func(dir string) {
+		if _, ok := dirs[dir]; ok {
+			return
+		}
+		dirs[dir] = []object.TreeEntry{}
+		parent := path.Dir(dir)
+		if parent == "." {
+			parent = ""
+		}
+		addDir(parent)
+		dirs[parent] = append(dirs[parent], object.TreeEntry{Name: path.Base(dir), Mode: filemode.Dir})
+	}
+
+	for name, file := range files {
+		dir := path.Dir(name)
+		if dir == "." {
+			dir = ""
+		}
+		addDir(dir)
+		dirs[dir] = append(dirs[dir], object.TreeEntry{Name: path.Base(name), Mode: file.Mode, Hash: file.Hash})
+	}
+
+	// Children are written before their parents, deepest directories first
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	depth := func(dir string) int {
+		if dir == "" {
+			return -1
+		}
+		return strings.Count(dir, "/")
+	}
+	sort.Slice(names, func(i, j int) bool { return depth(names[i]) > depth(names[j]) })
+
+	hashes := make(map[string]plumbing.Hash, len(dirs))
+	for _, dir := range names {
+		entries := dirs[dir]
+		for i := range entries {
+			if entries[i].Mode == filemode.Dir {
+				entries[i].Hash = hashes[path.Join(dir, entries[i].Name)]
+			}
+		}
+		sort.Sort(object.TreeEntrySorter(entries))
+
+		obj := repo.Storer.NewEncodedObject()
+		if err := (&object.Tree{Entries: entries}).Encode(obj); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to encode tree: %w", err)
+		}
+		hash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to store tree: %w", err)
+		}
+		hashes[dir] = hash
+	}
+
+	return hashes[""], nil
+}
+
+// writeCommit stores a commit object and returns its hash
+func writeCommit(repo *git.Repository, tree plumbing.Hash, parents []plumbing.Hash, message string, author, committer *object.Signature) (plumbing.Hash, error) {
+	commit := &object.Commit{
+		Author:       *author,
+		Committer:    *committer,
+		Message:      message,
+		TreeHash:     tree,
+		ParentHashes: parents,
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to encode commit: %w", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store commit: %w", err)
+	}
+	return hash, nil
+}
+
+// gitDir returns the storage of a repository, whose filesystem is the .git directory
+func gitDir(repo *git.Repository) (*filesystem.Storage, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil, errors.New("the repository is not stored on disk")
+	}
+	return storage, nil
+}
+
+// readGitFile reads a file of the .git directory like MERGE_HEAD, found is false if it doesn't exist
+func readGitFile(repo *git.Repository, name string) (content string, found bool, err error) {
+	storage, err := gitDir(repo)
+	if err != nil {
+		return "", false, err
+	}
+	file, err := storage.Filesystem().Open(name)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return string(data), true, nil
+}
+
+// writeGitFile writes a file of the .git directory, creating its parent directories
+func writeGitFile(repo *git.Repository, name string, content string) error {
+	storage, err := gitDir(repo)
+	if err != nil {
+		return err
+	}
+	fs := storage.Filesystem()
+
+	if err := fs.MkdirAll(path.Dir(name), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	file, err := fs.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	if _, err := file.Write([]byte(content)); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// removeGitFiles removes files of the .git directory, missing ones are ignored
+func removeGitFiles(repo *git.Repository, names ...string) error {
+	storage, err := gitDir(repo)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := storage.Filesystem().Remove(name); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// updateHead moves the current branch to a commit, or HEAD itself when it is detached
+func updateHead(repo *git.Repository, head *plumbing.Reference, hash plumbing.Hash) error {
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash)); err != nil {
+		return fmt.Errorf("failed to update %s: %w", head.Name().Short(), err)
+	}
+	return nil
+}
+
+// removeGitDir removes a directory of the .git directory with its content
+func removeGitDir(repo *git.Repository, name string) error {
+	storage, err := gitDir(repo)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(storage.Filesystem().Root(), filepath.FromSlash(name))); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", name, err)
+	}
+	return nil
+}
+
+// configOption returns an option of the git config, the repository config overriding the global and system ones
+func configOption(repo *git.Repository, section, subsection, key string) (string, error) {
+	local, err := repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read config: %w", err)
+	}
+	configs := []*config.Config{local}
+	for _, scope := range []config.Scope{config.GlobalScope, config.SystemScope} {
+		cfg, err := config.LoadConfig(scope)
+		if err != nil {
+			return "", fmt.Errorf("failed to read config: %w", err)
+		}
+		configs = append(configs, cfg)
+	}
+
+	for _, cfg := range configs {
+		if cfg.Raw == nil || !cfg.Raw.HasSection(section) {
+			continue
+		}
+		s := cfg.Raw.Section(section)
+		if subsection == "" {
+			if s.HasOption(key) {
+				return s.Option(key), nil
+			}
+		} else if s.HasSubsection(subsection) && s.Subsection(subsection).HasOption(key) {
+			return s.Subsection(subsection).Option(key), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+const (
+	// maxSnapshotSize is the largest file kept in the local history
+	maxSnapshotSize = 10 << 20
+	// defaultHistoryMaxAge is how long snapshots are kept
+	defaultHistoryMaxAge = 30 * 24 * time.Hour
+	// defaultHistoryMaxEntries is the number of snapshots kept per file
+	defaultHistoryMaxEntries = 50
+)
+
+// FileSnapshot is a saved version of a file in the local history
+type FileSnapshot struct {
+	ID          int64     `json:"id"`
+	ProjectPath string    `json:"projectPath"`
+	Path        string    `json:"path"`
+	Hash        string    `json:"hash"` // SHA-256 of the raw file content
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// newFileSnapshot converts a database row into a FileSnapshot
+func newFileSnapshot(snapshot db.FileSnapshot) FileSnapshot {
+	return FileSnapshot{
+		ID:          snapshot.ID,
+		ProjectPath: snapshot.ProjectPath,
+		Path:        snapshot.Path,
+		Hash:        snapshot.Hash,
+		Size:        snapshot.Size,
+		CreatedAt:   snapshot.CreatedAt.Time,
+	}
+}
+
+// ListFileHistory returns the snapshots of a file, most recent first
+func (s *FileService) ListFileHistory(path string) ([]FileSnapshot, error) {
+	rows, err := s.queries.ListFileSnapshots(context.Background(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file history: %w", err)
+	}
+
+	snapshots := make([]FileSnapshot, 0, len(rows))
+	for _, row := range rows {
+		snapshots = append(snapshots, newFileSnapshot(row))
+	}
+	return snapshots, nil
+}
+
+// GetFileSnapshot returns a single snapshot of the local history
+func (s *FileService) GetFileSnapshot(id int64) (*FileSnapshot, error) {
+	snapshot, err := s.queries.GetFileSnapshot(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("snapshot not found: %d", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	result := newFileSnapshot(snapshot)
+	return &result, nil
+}
+
+// GetSnapshotContent returns the decoded content of a snapshot
+func (s *FileService) GetSnapshotContent(id int64) (*FileContent, error) {
+	snapshot, raw, err := s.readSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	text, format, mixed, err := decodeText(raw, s.getSettings().DefaultFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileContent{
+		Path:             snapshot.Path,
+		Content:          text,
+		Format:           format,
+		MixedLineEndings: mixed,
+		Size:             snapshot.Size,
+	}, nil
+}
+
+// DiffSnapshot compares a snapshot with the current content of its file
+func (s *FileService) DiffSnapshot(id int64, opts DiffOptions) (*FileDiff, error) {
+	snapshot, raw, err := s.readSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(snapshot.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", snapshot.Path, err)
+	}
+
+	relPath, err := filepath.Rel(snapshot.ProjectPath, snapshot.Path)
+	if err != nil {
+		relPath = filepath.Base(snapshot.Path)
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if isBinaryContent(raw) || isBinaryContent(current) {
+		return &FileDiff{Path: relPath, IsBinary: true}, nil
+	}
+
+	fallback := s.getSettings().DefaultFormat
+	oldText, _, _, err := decodeText(raw, fallback)
+	if err != nil {
+		return nil, err
+	}
+	newText, _, _, err := decodeText(current, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDiff(oldText, newText, relPath, relPath, opts)
+}
+
+// RestoreSnapshot writes the content of a snapshot back to its file and returns the new file version.
+// The content being replaced is snapshotted first, so a restore can be reverted as well.
+func (s *FileService) RestoreSnapshot(id int64) (string, error) {
+	snapshot, raw, err := s.readSnapshot(id)
+	if err != nil {
+		return "", err
+	}
+
+	if current, err := os.ReadFile(snapshot.Path); err == nil {
+		s.snapshotFile(snapshot.Path, current)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(snapshot.Path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := writeFileAtomic(snapshot.Path, raw); err != nil {
+		return "", err
+	}
+	s.snapshotFile(snapshot.Path, raw)
+
+	s.InvalidateCache(filepath.Dir(snapshot.Path))
+
+	info, err := os.Stat(snapshot.Path)
+	if err != nil {
+		return "", err
+	}
+	return fileVersion(info, raw), nil
+}
+
+// CleanHistory deletes snapshots older than the configured age
+func (s *FileService) CleanHistory() error {
+	maxAge := s.getSettings().HistoryMaxAge
+	if maxAge <= 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	snapshots, err := s.queries.ListExpiredFileSnapshots(ctx, sql.NullTime{
+		Time:  time.Now().Add(-maxAge).UTC(),
+		Valid: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list expired snapshots: %w", err)
+	}
+
+	for _, snapshot := range snapshots {
+		if err := s.deleteSnapshot(ctx, snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotFile stores raw file content in the local history unless it matches the latest snapshot.
+// Failures are only logged, the history must never get in the way of saving.
+func (s *FileService) snapshotFile(path string, raw []byte) {
+	if len(raw) > maxSnapshotSize {
+		return
+	}
+
+	ctx := context.Background()
+	hash := contentHash(raw)
+
+	latest, err := s.queries.GetLatestFileSnapshot(ctx, path)
+	if err == nil && latest.Hash == hash {
+		return
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("[FileService] Failed to read file history: %v", err)
+		return
+	}
+
+	if err := s.writeSnapshotObject(hash, raw); err != nil {
+		log.Printf("[FileService] Failed to store snapshot of %s: %v", path, err)
+		return
+	}
+
+	_, err = s.queries.CreateFileSnapshot(ctx, db.CreateFileSnapshotParams{
+		ProjectPath: s.projectRoot(path),
+		Path:        path,
+		Hash:        hash,
+		Size:        int64(len(raw)),
+	})
+	if err != nil {
+		log.Printf("[FileService] Failed to record snapshot of %s: %v", path, err)
+		return
+	}
+
+	s.trimHistory(ctx, path)
+}
+
+// trimHistory deletes the oldest snapshots of a file above the configured count
+func (s *FileService) trimHistory(ctx context.Context, path string) {
+	maxEntries := s.getSettings().HistoryMaxEntries
+	if maxEntries <= 0 {
+		return
+	}
+
+	snapshots, err := s.queries.ListExcessFileSnapshots(ctx, db.ListExcessFileSnapshotsParams{
+		Path:   path,
+		Offset: int64(maxEntries),
+	})
+	if err != nil {
+		log.Printf("[FileService] Failed to trim file history: %v", err)
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		if err := s.deleteSnapshot(ctx, snapshot); err != nil {
+			log.Printf("[FileService] Failed to trim file history: %v", err)
+			return
+		}
+	}
+}
+
+// readSnapshot loads a snapshot and its raw content
+func (s *FileService) readSnapshot(id int64) (db.FileSnapshot, []byte, error) {
+	snapshot, err := s.queries.GetFileSnapshot(context.Background(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return snapshot, nil, fmt.Errorf("snapshot not found: %d", id)
+	}
+	if err != nil {
+		return snapshot, nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	raw, err := os.ReadFile(s.snapshotObjectPath(snapshot.Hash))
+	if err != nil {
+		return snapshot, nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return snapshot, raw, nil
+}
+
+// deleteSnapshot removes a snapshot and its content once no other snapshot shares it
+func (s *FileService) deleteSnapshot(ctx context.Context, snapshot db.FileSnapshot) error {
+	if err := s.queries.DeleteFileSnapshot(ctx, snapshot.ID); err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	count, err := s.queries.CountFileSnapshotsByHash(ctx, snapshot.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to count snapshots: %w", err)
+	}
+	if count == 0 {
+		if err := os.Remove(s.snapshotObjectPath(snapshot.Hash)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete snapshot content: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeSnapshotObject stores content under its hash, identical content is stored once
+func (s *FileService) writeSnapshotObject(hash string, raw []byte) error {
+	path := s.snapshotObjectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	if err := writeFileSync(tmp.Name(), raw, 0600); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// snapshotObjectPath returns where the content with the given hash is stored
+func (s *FileService) snapshotObjectPath(hash string) string {
+	return filepath.Join(s.dataDir, "history", hash[:2], hash[2:])
+}
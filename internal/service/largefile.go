@@ -0,0 +1,403 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLargeFileThreshold is the size above which files are opened in chunks.
+	// It stays disabled until the editor can page through a file with ReadLines.
+	defaultLargeFileThreshold = 0
+	// lineIndexStride is how many lines apart the line index keeps an offset.
+	// A sparse index keeps memory low for files with hundreds of millions of lines.
+	lineIndexStride = 1024
+	// maxLineRange is the maximum number of lines returned by a single ReadLines call
+	maxLineRange = 10000
+	// maxByteWindow is the maximum number of bytes returned by a single ReadBytes call
+	maxByteWindow = 4 << 20
+	// tailInterval is how often a tailed file is checked for growth
+	tailInterval = 500 * time.Millisecond
+)
+
+// errLargeFileClosed stops a scan after the file was closed
+var errLargeFileClosed = errors.New("large file closed")
+
+// LargeFileInfo describes a file opened in large file mode
+type LargeFileInfo struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`     // Current size of the file in bytes
+	Indexed  int64  `json:"indexed"`  // Bytes covered by the line index so far
+	Lines    int    `json:"lines"`    // Lines found so far
+	Complete bool   `json:"complete"` // Whether the whole file is indexed
+	Tailing  bool   `json:"tailing"`  // Whether the file is watched for growth
+	Reset    bool   `json:"reset"`    // The file shrank and was indexed again, e.g. after log rotation
+}
+
+// LineRange is a range of lines read from a large file
+type LineRange struct {
+	StartLine  int      `json:"startLine"`  // 1-based number of the first returned line
+	Lines      []string `json:"lines"`      // Line contents without line endings
+	TotalLines int      `json:"totalLines"` // Lines indexed so far
+	Complete   bool     `json:"complete"`   // Whether TotalLines is final
+}
+
+// ByteWindow is a window of raw bytes read from a large file
+type ByteWindow struct {
+	Offset  int64  `json:"offset"`
+	Length  int    `json:"length"`
+	Content string `json:"content"` // Bytes as text, invalid UTF-8 sequences are replaced
+	EOF     bool   `json:"eof"`
+}
+
+// largeFile holds the line index of a file opened in large file mode
+type largeFile struct {
+	path string
+
+	mu          sync.Mutex
+	changed     *sync.Cond // Signalled whenever the index grows or indexing stops
+	checkpoints []int64    // Offset of line i*lineIndexStride, checkpoints[0] is always 0
+	newlines    int        // Newlines found so far
+	lastNewline int64      // Offset right after the last newline found
+	indexed     int64      // Bytes scanned so far
+	indexing    bool
+	err         error
+	reset       bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	tailing  bool
+}
+
+// OpenLargeFile opens a file in large file mode and starts indexing its lines in the background
+func (s *FileService) OpenLargeFile(path string) (*LargeFileInfo, error) {
+	s.largeFilesLock.Lock()
+	defer s.largeFilesLock.Unlock()
+
+	if lf, ok := s.largeFiles[path]; ok {
+		return lf.info()
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	lf := &largeFile{
+		path:        path,
+		checkpoints: []int64{0},
+		stop:        make(chan struct{}),
+	}
+	lf.changed = sync.NewCond(&lf.mu)
+	s.largeFiles[path] = lf
+
+	lf.indexing = true
+	go lf.index()
+
+	return lf.info()
+}
+
+// GetLargeFileInfo returns the indexing progress of a large file
+func (s *FileService) GetLargeFileInfo(path string) (*LargeFileInfo, error) {
+	lf, err := s.getLargeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return lf.info()
+}
+
+// ReadLines returns count lines of a large file starting at the 1-based line start.
+// It waits until the index covers the requested range or the whole file is indexed.
+func (s *FileService) ReadLines(path string, start, count int) (*LineRange, error) {
+	if start < 1 {
+		return nil, fmt.Errorf("invalid start line: %d", start)
+	}
+	if count < 1 || count > maxLineRange {
+		return nil, fmt.Errorf("line count must be between 1 and %d", maxLineRange)
+	}
+
+	lf, err := s.getLargeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return lf.readLines(start, count)
+}
+
+// ReadBytes returns a window of raw bytes of a large file
+func (s *FileService) ReadBytes(path string, offset int64, length int) (*ByteWindow, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+	if length < 1 || length > maxByteWindow {
+		return nil, fmt.Errorf("length must be between 1 and %d", maxByteWindow)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, length)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &ByteWindow{
+		Offset:  offset,
+		Length:  n,
+		Content: strings.ToValidUTF8(string(buf[:n]), "�"),
+		EOF:     err == io.EOF,
+	}, nil
+}
+
+// TailLargeFile keeps indexing a large file as it grows and calls onChange after every change
+func (s *FileService) TailLargeFile(path string, onChange func(LargeFileInfo)) error {
+	lf, err := s.getLargeFile(path)
+	if err != nil {
+		return err
+	}
+
+	lf.mu.Lock()
+	if lf.tailing {
+		lf.mu.Unlock()
+		return nil
+	}
+	lf.tailing = true
+	lf.mu.Unlock()
+
+	go lf.tail(onChange)
+	return nil
+}
+
+// CloseLargeFile stops indexing and tailing a large file and drops its index
+func (s *FileService) CloseLargeFile(path string) {
+	s.largeFilesLock.Lock()
+	lf, ok := s.largeFiles[path]
+	delete(s.largeFiles, path)
+	s.largeFilesLock.Unlock()
+
+	if ok {
+		lf.close()
+	}
+}
+
+// getLargeFile returns an opened large file
+func (s *FileService) getLargeFile(path string) (*largeFile, error) {
+	s.largeFilesLock.Lock()
+	defer s.largeFilesLock.Unlock()
+
+	lf, ok := s.largeFiles[path]
+	if !ok {
+		return nil, fmt.Errorf("file is not open in large file mode: %s", path)
+	}
+	return lf, nil
+}
+
+// info returns the current state of the index
+func (lf *largeFile) info() (*LargeFileInfo, error) {
+	stat, err := os.Stat(lf.path)
+	if err != nil {
+		return nil, err
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.err != nil {
+		return nil, lf.err
+	}
+
+	return &LargeFileInfo{
+		Path:     lf.path,
+		Size:     stat.Size(),
+		Indexed:  lf.indexed,
+		Lines:    lf.lineCount(),
+		Complete: !lf.indexing && lf.indexed >= stat.Size(),
+		Tailing:  lf.tailing,
+		Reset:    lf.reset,
+	}, nil
+}
+
+// lineCount returns the number of lines indexed so far. Caller must hold mu.
+func (lf *largeFile) lineCount() int {
+	lines := lf.newlines
+	// A last line without a trailing newline still counts
+	if lf.indexed > lf.lastNewline {
+		lines++
+	}
+	return lines
+}
+
+// index scans the file from the indexed offset to its end
+func (lf *largeFile) index() {
+	err := lf.scan()
+
+	lf.mu.Lock()
+	lf.indexing = false
+	if err != nil && !errors.Is(err, errLargeFileClosed) {
+		lf.err = err
+	}
+	lf.changed.Broadcast()
+	lf.mu.Unlock()
+}
+
+// scan reads the file from the indexed offset and records line offsets
+func (lf *largeFile) scan() error {
+	f, err := os.Open(lf.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lf.mu.Lock()
+	offset := lf.indexed
+	lf.mu.Unlock()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 256<<10)
+	for {
+		select {
+		case <-lf.stop:
+			return errLargeFileClosed
+		default:
+		}
+
+		n, err := f.Read(buf)
+		if n > 0 {
+			lf.mu.Lock()
+			for i, b := range buf[:n] {
+				if b != '\n' {
+					continue
+				}
+				lf.newlines++
+				lf.lastNewline = offset + int64(i) + 1
+				if lf.newlines%lineIndexStride == 0 {
+					lf.checkpoints = append(lf.checkpoints, lf.lastNewline)
+				}
+			}
+			offset += int64(n)
+			lf.indexed = offset
+			lf.changed.Broadcast()
+			lf.mu.Unlock()
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readLines reads lines once the index covers them
+func (lf *largeFile) readLines(start, count int) (*LineRange, error) {
+	lf.mu.Lock()
+	for lf.indexing && lf.newlines < start-1+count {
+		lf.changed.Wait()
+	}
+	if lf.err != nil {
+		lf.mu.Unlock()
+		return nil, lf.err
+	}
+
+	total := lf.lineCount()
+	complete := !lf.indexing
+	result := &LineRange{StartLine: start, Lines: []string{}, TotalLines: total, Complete: complete}
+	// The index has no checkpoint past the last line
+	if start > total {
+		lf.mu.Unlock()
+		return result, nil
+	}
+	checkpoint := (start - 1) / lineIndexStride
+	offset := lf.checkpoints[checkpoint]
+	lf.mu.Unlock()
+
+	f, err := os.Open(lf.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReaderSize(f, 64<<10)
+	skip := start - 1 - checkpoint*lineIndexStride
+	for line := 0; line < skip+count; line++ {
+		text, err := reader.ReadString('\n')
+		if line >= skip && (text != "" || err == nil) {
+			text = strings.TrimSuffix(text, "\n")
+			text = strings.TrimSuffix(text, "\r")
+			result.Lines = append(result.Lines, strings.ToValidUTF8(text, "�"))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// tail polls the file for growth and indexes the new content
+func (lf *largeFile) tail(onChange func(LargeFileInfo)) {
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lf.stop:
+			return
+		case <-ticker.C:
+		}
+
+		stat, err := os.Stat(lf.path)
+		if err != nil {
+			continue
+		}
+
+		lf.mu.Lock()
+		if lf.indexing || stat.Size() == lf.indexed {
+			lf.mu.Unlock()
+			continue
+		}
+
+		// A shrinking file was truncated or rotated, so the index starts over
+		lf.reset = stat.Size() < lf.indexed
+		if lf.reset {
+			lf.checkpoints = []int64{0}
+			lf.newlines = 0
+			lf.lastNewline = 0
+			lf.indexed = 0
+		}
+		lf.indexing = true
+		lf.mu.Unlock()
+
+		lf.index()
+
+		if info, err := lf.info(); err == nil && onChange != nil {
+			onChange(*info)
+		}
+	}
+}
+
+// close stops background indexing and tailing
+func (lf *largeFile) close() {
+	lf.stopOnce.Do(func() {
+		close(lf.stop)
+	})
+}
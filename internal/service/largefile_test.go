@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openTestLargeFile writes a file with the given number of numbered lines and opens it in large file mode
+func openTestLargeFile(t *testing.T, lines int) (*FileService, string) {
+	t.Helper()
+	s := newTestFiles(t)
+
+	var content strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "big.log")
+	writeTestFile(t, path, content.String())
+
+	if _, err := s.OpenLargeFile(path); err != nil {
+		t.Fatalf("failed to open large file: %v", err)
+	}
+	t.Cleanup(func() { s.CloseLargeFile(path) })
+	return s, path
+}
+
+func TestReadLinesAcrossCheckpoints(t *testing.T) {
+	s, path := openTestLargeFile(t, 3*lineIndexStride)
+
+	result, err := s.ReadLines(path, lineIndexStride-1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		fmt.Sprintf("line %d", lineIndexStride-1),
+		fmt.Sprintf("line %d", lineIndexStride),
+		fmt.Sprintf("line %d", lineIndexStride+1),
+		fmt.Sprintf("line %d", lineIndexStride+2),
+	}
+	if strings.Join(result.Lines, ",") != strings.Join(want, ",") {
+		t.Errorf("lines = %q, want %q", result.Lines, want)
+	}
+	if result.TotalLines != 3*lineIndexStride || !result.Complete {
+		t.Errorf("total = %d (complete %v), want %d complete", result.TotalLines, result.Complete, 3*lineIndexStride)
+	}
+}
+
+func TestReadLinesPastEndOfFile(t *testing.T) {
+	s, path := openTestLargeFile(t, 100)
+
+	result, err := s.ReadLines(path, 5000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Lines) != 0 {
+		t.Errorf("lines = %q, want none", result.Lines)
+	}
+	if result.TotalLines != 100 {
+		t.Errorf("total = %d, want 100", result.TotalLines)
+	}
+}
+
+func TestReadLinesWithoutTrailingNewline(t *testing.T) {
+	s := newTestFiles(t)
+	path := filepath.Join(t.TempDir(), "file.txt")
+	writeTestFile(t, path, "one\r\ntwo\r\nthree")
+	if _, err := s.OpenLargeFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer s.CloseLargeFile(path)
+
+	result, err := s.ReadLines(path, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Lines, ",") != "two,three" {
+		t.Errorf("lines = %q, want [two three]", result.Lines)
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultDirectoryPageSize is the number of entries of a directory page without a limit
+const defaultDirectoryPageSize = 500
+
+// Sort orders of a directory listing, directories always come first
+const (
+	DirectorySortName  = "name"
+	DirectorySortMtime = "mtime"
+	DirectorySortSize  = "size"
+	DirectorySortType  = "type" // By extension, then by name
+)
+
+// DirectoryListOptions contains options for listing a directory page by page
+type DirectoryListOptions struct {
+	Cursor     string `json:"cursor"`     // NextCursor of the previous page, empty for the first page
+	Limit      int    `json:"limit"`      // Page size, defaultDirectoryPageSize if zero
+	Filter     string `json:"filter"`     // Only list entries whose name contains this text, ignoring case
+	SortBy     string `json:"sortBy"`     // One of the DirectorySort constants, name if empty
+	Descending bool   `json:"descending"` // Reverse the sort order
+}
+
+// DirectoryPage is a page of the entries of a directory
+type DirectoryPage struct {
+	Path       string      `json:"path"`
+	Entries    []*FileNode `json:"entries"`
+	Total      int         `json:"total"`      // Number of entries matching the filter
+	NextCursor string      `json:"nextCursor"` // Cursor of the next page, empty on the last page
+}
+
+// listEntry holds the sort keys of a directory entry
+type listEntry struct {
+	Name  string `json:"n"`
+	IsDir bool   `json:"d,omitempty"`
+	Mtime int64  `json:"m,omitempty"`
+	Size  int64  `json:"s,omitempty"`
+}
+
+// listCursor is the position after the last entry of a page, along with the options the page was listed with
+type listCursor struct {
+	After      listEntry `json:"a"`
+	SortBy     string    `json:"o"`
+	Descending bool      `json:"r,omitempty"`
+	Filter     string    `json:"f,omitempty"`
+}
+
+// ListDirectory returns a page of the entries of a directory.
+// Pages continue after the last entry of the previous page, so entries added or removed
+// between two requests don't shift the following pages.
+func (s *FileService) ListDirectory(dirPath string, opts DirectoryListOptions) (*DirectoryPage, error) {
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = DirectorySortName
+	case DirectorySortName, DirectorySortMtime, DirectorySortSize, DirectorySortType:
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s", opts.SortBy)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultDirectoryPageSize
+	}
+
+	filter := strings.ToLower(opts.Filter)
+	var after *listEntry
+	if opts.Cursor != "" {
+		cursor, err := decodeListCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Positions only make sense in the order they were taken from
+		if cursor.SortBy != opts.SortBy || cursor.Descending != opts.Descending || cursor.Filter != filter {
+			return nil, errors.New("the cursor was made with other sort or filter options, list from the first page")
+		}
+		after = &cursor.After
+	}
+
+	dirEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rootPath := s.projectRoot(dirPath)
+	// Times and sizes cost a stat per entry, they are only read when sorting by them
+	needInfo := opts.SortBy == DirectorySortMtime || opts.SortBy == DirectorySortSize
+
+	entries := make([]listEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if filter != "" && !strings.Contains(strings.ToLower(d.Name()), filter) {
+			continue
+		}
+		path := filepath.Join(dirPath, d.Name())
+		if !s.isShown(rootPath, path) {
+			continue
+		}
+
+		entry := listEntry{Name: d.Name(), IsDir: d.IsDir()}
+		if d.Type()&os.ModeSymlink != 0 {
+			// Links are grouped with what they point to, like in the tree
+			if info, err := os.Stat(path); err == nil {
+				entry.IsDir = info.IsDir()
+			}
+		}
+		if needInfo {
+			if info, err := d.Info(); err == nil {
+				entry.Mtime = info.ModTime().UnixNano()
+				entry.Size = info.Size()
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	less := func(a, b listEntry) bool {
+		return compareListEntries(a, b, opts.SortBy, opts.Descending) < 0
+	}
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(entries), func(i int) bool { return less(*after, entries[i]) })
+	}
+	end := start + opts.Limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	page := &DirectoryPage{
+		Path:    dirPath,
+		Entries: make([]*FileNode, 0, end-start),
+		Total:   len(entries),
+	}
+	for _, entry := range entries[start:end] {
+		// Entries removed since the directory was read are left out
+		if node := s.treeNode(rootPath, filepath.Join(dirPath, entry.Name)); node != nil {
+			page.Entries = append(page.Entries, node)
+		}
+	}
+	if end < len(entries) {
+		page.NextCursor = encodeListCursor(listCursor{
+			After:      entries[end-1],
+			SortBy:     opts.SortBy,
+			Descending: opts.Descending,
+			Filter:     filter,
+		})
+	}
+
+	return page, nil
+}
+
+// compareListEntries orders two entries of a directory, directories first.
+// Entries with the same sort key are ordered by name.
+func compareListEntries(a, b listEntry, sortBy string, descending bool) int {
+	if a.IsDir != b.IsDir {
+		if a.IsDir {
+			return -1
+		}
+		return 1
+	}
+
+	c := 0
+	switch sortBy {
+	case DirectorySortMtime:
+		c = compareInt64(a.Mtime, b.Mtime)
+	case DirectorySortSize:
+		c = compareInt64(a.Size, b.Size)
+	case DirectorySortType:
+		c = strings.Compare(strings.ToLower(filepath.Ext(a.Name)), strings.ToLower(filepath.Ext(b.Name)))
+	}
+	if c == 0 {
+		c = strings.Compare(a.Name, b.Name)
+	}
+
+	if descending {
+		return -c
+	}
+	return c
+}
+
+// compareInt64 returns -1, 0 or 1 as a is lower, equal or greater than b
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// encodeListCursor encodes the position after the last entry of a page
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor decodes a cursor created by encodeListCursor
+func decodeListCursor(encoded string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor: %s", encoded)
+	}
+	return cursor, nil
+}
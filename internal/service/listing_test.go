@@ -0,0 +1,59 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListDirectoryPages(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{
+		"b.txt": "b", "a.go": "a", "c.md": "c", "dir/x.txt": "x", "d.txt": "d",
+	})
+
+	var names []string
+	opts := DirectoryListOptions{Limit: 2}
+	for {
+		page, err := s.ListDirectory(root, opts)
+		if err != nil {
+			t.Fatalf("failed to list: %v", err)
+		}
+		if page.Total != 5 {
+			t.Fatalf("total = %d, want 5", page.Total)
+		}
+		for _, entry := range page.Entries {
+			names = append(names, entry.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if got := strings.Join(names, " "); got != "dir a.go b.txt c.md d.txt" {
+		t.Errorf("entries = %s, want the directory first then the files by name", got)
+	}
+}
+
+func TestListDirectoryRejectsCursorOfOtherOptions(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	page, err := s.ListDirectory(root, DirectoryListOptions{Limit: 1, Filter: "TXT"})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	cursor := page.NextCursor
+
+	// The filter ignores case, so does the check
+	if _, err := s.ListDirectory(root, DirectoryListOptions{Limit: 1, Filter: "txt", Cursor: cursor}); err != nil {
+		t.Errorf("the cursor was refused with the same options: %v", err)
+	}
+	for name, opts := range map[string]DirectoryListOptions{
+		"sort order": {SortBy: DirectorySortSize, Filter: "txt"},
+		"direction":  {Descending: true, Filter: "txt"},
+		"filter":     {Filter: "a"},
+	} {
+		opts.Cursor = cursor
+		if _, err := s.ListDirectory(root, opts); err == nil {
+			t.Errorf("a cursor was accepted with another %s", name)
+		}
+	}
+}
@@ -0,0 +1,945 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Types of conflicts
+const (
+	ConflictContent       = "content"         // Both sides changed the same lines
+	ConflictBothAdded     = "both added"      // Both sides added the file with different contents
+	ConflictDeletedByUs   = "deleted by us"   // Our side deleted the file, their side changed it
+	ConflictDeletedByThem = "deleted by them" // Their side deleted the file, our side changed it
+)
+
+// ConflictFile is a file that couldn't be merged automatically
+type ConflictFile struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`     // One of the Conflict constants
+	IsBinary bool   `json:"isBinary"` // The working copy holds our version instead of conflict markers
+}
+
+// Outcomes of a merge
+const (
+	MergeUpToDate    = "up to date"   // The branch was already merged
+	MergeFastForward = "fast-forward" // The current branch moved to the merged branch
+	MergeClean       = "clean"        // A merge commit was created
+	MergeConflicted  = "conflicted"   // The merge stopped on conflicts to resolve
+)
+
+// Files of the .git directory describing a merge in progress, shared with git
+const (
+	mergeHeadFile = "MERGE_HEAD"
+	mergeMsgFile  = "MERGE_MSG"
+	mergeModeFile = "MERGE_MODE"
+	origHeadFile  = "ORIG_HEAD"
+)
+
+// MergeOptions contains options for merging a branch
+type MergeOptions struct {
+	Message       string `json:"message"`       // Message of the merge commit, "Merge branch '<branch>'" if empty
+	NoFastForward bool   `json:"noFastForward"` // Create a merge commit even if the branch could be fast-forwarded
+}
+
+// MergeResult is the outcome of applying changes on top of the working tree
+type MergeResult struct {
+	Status    string         `json:"status"`    // One of the Merge constants
+	Commit    *CommitInfo    `json:"commit"`    // HEAD after the merge, nil if it stopped on conflicts or for a stash
+	Conflicts []ConflictFile `json:"conflicts"` // Empty when the changes applied cleanly
+}
+
+// MergeState describes the merge, cherry-pick, revert or rebase in progress and the conflicts left in the index
+type MergeState struct {
+	InProgress bool           `json:"inProgress"` // An operation stopped on conflicts and waits to be finished or aborted
+	Operation  string         `json:"operation"`  // One of the Operation constants, empty if none is in progress
+	MergeHead  string         `json:"mergeHead"`  // Commit being merged, cherry-picked, reverted or rebased
+	Message    string         `json:"message"`    // Prepared message of the commit
+	Conflicts  []ConflictFile `json:"conflicts"`  // Unresolved files, from a merge or a stash
+}
+
+// mergeConflict is a conflicting file of a tree merge, sides missing the file are nil
+type mergeConflict struct {
+	Type     string
+	Base     *treeFile
+	Ours     *treeFile
+	Theirs   *treeFile
+	Content  []byte // Working copy content, with conflict markers for text files
+	Mode     filemode.FileMode
+	IsBinary bool
+}
+
+// treeMerge is the result of a three-way merge of flattened trees
+type treeMerge struct {
+	files     map[string]treeFile // Cleanly merged files
+	conflicts map[string]*mergeConflict
+}
+
+// conflictList returns the conflicts of a merge sorted by path
+func (m *treeMerge) conflictList() []ConflictFile {
+	conflicts := make([]ConflictFile, 0, len(m.conflicts))
+	for path, c := range m.conflicts {
+		conflicts = append(conflicts, ConflictFile{Path: path, Type: c.Type, IsBinary: c.IsBinary})
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return conflicts
+}
+
+// MergeBranch merges a branch, a tag or a commit into the current branch.
+// Conflicts leave the merge in progress, to be finished with CommitMerge or cancelled with AbortMerge.
+func (s *GitService) MergeBranch(projectPath string, branch string, opts MergeOptions) (*MergeResult, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if operation, err := operationInProgress(repo); err != nil {
+		return nil, err
+	} else if operation != "" {
+		return nil, fmt.Errorf("a %s is in progress, finish or abort it first", operation)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("cannot merge without a commit: %w", err)
+	}
+	ours, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	theirs, err := resolveCommit(repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	indexFiles, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(changedFiles(headFiles, indexFiles)) > 0 {
+		return nil, errors.New("you have staged changes, commit or stash them first")
+	}
+
+	bases, err := ours.MergeBase(theirs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find merge base: %w", err)
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("refusing to merge unrelated histories of %s", branch)
+	}
+	base := bases[0]
+	if base.Hash == theirs.Hash {
+		return &MergeResult{Status: MergeUpToDate, Commit: commitInfo(ours), Conflicts: []ConflictFile{}}, nil
+	}
+
+	theirsFiles, err := commitFiles(repo, theirs.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.Hash == ours.Hash && !opts.NoFastForward {
+		fastForward := &treeMerge{files: theirsFiles, conflicts: map[string]*mergeConflict{}}
+		if err := applyTreeMerge(repo, headFiles, fastForward, true); err != nil {
+			return nil, err
+		}
+		if err := writeGitFile(repo, origHeadFile, ours.Hash.String()+"\n"); err != nil {
+			return nil, err
+		}
+		if err := updateHead(repo, head, theirs.Hash); err != nil {
+			return nil, err
+		}
+		return &MergeResult{Status: MergeFastForward, Commit: commitInfo(theirs), Conflicts: []ConflictFile{}}, nil
+	}
+
+	sig, err := gitSignature(repo)
+	if err != nil {
+		return nil, err
+	}
+	baseFiles, err := commitFiles(repo, base.Hash)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := mergeTrees(repo, baseFiles, headFiles, theirsFiles, "HEAD", branch)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTreeMerge(repo, headFiles, merge, true); err != nil {
+		return nil, err
+	}
+	if err := writeGitFile(repo, origHeadFile, ours.Hash.String()+"\n"); err != nil {
+		return nil, err
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = mergeMessage(repo, head, branch)
+	}
+
+	if conflicts := merge.conflictList(); len(conflicts) > 0 {
+		var sb strings.Builder
+		sb.WriteString(strings.TrimSpace(message) + "\n\n# Conflicts:\n")
+		for _, conflict := range conflicts {
+			sb.WriteString("#\t" + conflict.Path + "\n")
+		}
+		mode := ""
+		if opts.NoFastForward {
+			mode = "no-ff"
+		}
+		if err := writeGitFile(repo, mergeHeadFile, theirs.Hash.String()+"\n"); err != nil {
+			return nil, err
+		}
+		if err := writeGitFile(repo, mergeMsgFile, sb.String()); err != nil {
+			return nil, err
+		}
+		if err := writeGitFile(repo, mergeModeFile, mode); err != nil {
+			return nil, err
+		}
+		return &MergeResult{Status: MergeConflicted, Conflicts: conflicts}, nil
+	}
+
+	tree, err := writeTree(repo, merge.files)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := writeCommit(repo, tree, []plumbing.Hash{ours.Hash, theirs.Hash}, strings.TrimSpace(message)+"\n", sig, sig)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateHead(repo, head, hash); err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	return &MergeResult{Status: MergeClean, Commit: commitInfo(commit), Conflicts: []ConflictFile{}}, nil
+}
+
+// GetMergeState returns the merge, cherry-pick or revert in progress, if any, and the unresolved conflicts
+func (s *GitService) GetMergeState(projectPath string) (*MergeState, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	state := &MergeState{}
+	if state.Operation, err = operationInProgress(repo); err != nil {
+		return nil, err
+	}
+	headFile := map[string]string{
+		OperationMerge:      mergeHeadFile,
+		OperationCherryPick: cherryPickHeadFile,
+		OperationRevert:     revertHeadFile,
+		OperationRebase:     rebaseStoppedFile,
+	}[state.Operation]
+	if state.Operation != "" {
+		mergeHead, _, err := readGitFile(repo, headFile)
+		if err != nil {
+			return nil, err
+		}
+		state.InProgress = true
+		state.MergeHead = strings.TrimSpace(mergeHead)
+		message, _, err := readGitFile(repo, mergeMsgFile)
+		if err != nil {
+			return nil, err
+		}
+		state.Message = cleanMessage(message)
+	}
+
+	if state.Conflicts, err = indexConflicts(repo); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// CommitMerge creates the merge commit once every conflict is resolved, message defaults to the prepared one
+func (s *GitService) CommitMerge(projectPath string, message string) (*CommitInfo, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, found, err := readGitFile(repo, mergeHeadFile); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, errors.New("no merge in progress")
+	}
+
+	if message == "" {
+		prepared, _, err := readGitFile(repo, mergeMsgFile)
+		if err != nil {
+			return nil, err
+		}
+		message = cleanMessage(prepared)
+	}
+	return s.CommitWithOptions(projectPath, message, CommitOptions{})
+}
+
+// AbortMerge cancels the merge in progress, restoring the files it changed to HEAD.
+// Local changes made before the merge to files it didn't touch are kept.
+func (s *GitService) AbortMerge(projectPath string) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, found, err := readGitFile(repo, mergeHeadFile); err != nil {
+		return err
+	} else if !found {
+		return errors.New("no merge in progress")
+	}
+
+	if err := resetIndexChanges(repo); err != nil {
+		return err
+	}
+	return removeGitFiles(repo, mergeHeadFile, mergeMsgFile, mergeModeFile)
+}
+
+// mergeMessage returns the default message for merging a revision into the current branch, like git
+func mergeMessage(repo *git.Repository, head *plumbing.Reference, revision string) string {
+	var message string
+	switch {
+	case refExists(repo, plumbing.NewBranchReferenceName(revision)):
+		message = fmt.Sprintf("Merge branch '%s'", revision)
+	case refExists(repo, plumbing.ReferenceName("refs/remotes/"+revision)):
+		message = fmt.Sprintf("Merge remote-tracking branch '%s'", revision)
+	case refExists(repo, plumbing.NewTagReferenceName(revision)):
+		message = fmt.Sprintf("Merge tag '%s'", revision)
+	default:
+		message = fmt.Sprintf("Merge commit '%s'", revision)
+	}
+
+	if branch := head.Name().Short(); head.Name().IsBranch() && branch != "main" && branch != "master" {
+		message += " into " + branch
+	}
+	return message
+}
+
+// refExists reports whether a reference exists
+func refExists(repo *git.Repository, name plumbing.ReferenceName) bool {
+	_, err := repo.Reference(name, false)
+	return err == nil
+}
+
+// cleanMessage removes the comment lines and surrounding blank lines of a prepared commit message
+func cleanMessage(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// indexConflicts returns the files of the index with conflict stages, sorted by path
+func indexConflicts(repo *git.Repository) ([]ConflictFile, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	stages := make(map[string]map[index.Stage]*index.Entry)
+	for _, entry := range idx.Entries {
+		if entry.Stage == 0 {
+			continue
+		}
+		if stages[entry.Name] == nil {
+			stages[entry.Name] = make(map[index.Stage]*index.Entry)
+		}
+		stages[entry.Name][entry.Stage] = entry
+	}
+
+	conflicts := make([]ConflictFile, 0, len(stages))
+	for path, entries := range stages {
+		conflict := ConflictFile{Path: path, Type: conflictType(entries)}
+		for _, entry := range entries {
+			if !entry.Mode.IsRegular() {
+				conflict.IsBinary = true
+				continue
+			}
+			content, err := readBlob(repo, entry.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if isBinaryContent(content) {
+				conflict.IsBinary = true
+			}
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return conflicts, nil
+}
+
+// conflictType tells the kind of conflict from the stages of a file in the index
+func conflictType(stages map[index.Stage]*index.Entry) string {
+	_, hasBase := stages[index.AncestorMode]
+	_, hasOurs := stages[index.OurMode]
+	_, hasTheirs := stages[index.TheirMode]
+	switch {
+	case !hasBase:
+		return ConflictBothAdded
+	case !hasOurs:
+		return ConflictDeletedByUs
+	case !hasTheirs:
+		return ConflictDeletedByThem
+	default:
+		return ConflictContent
+	}
+}
+
+// resetIndexChanges restores the index entries differing from HEAD, conflicts included, and their working copies
+func resetIndexChanges(repo *git.Repository) error {
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	return resetIndexTo(repo, headFiles)
+}
+
+// resetIndexTo restores the index entries differing from files, conflicts included, and their working copies
+func resetIndexTo(repo *git.Repository, files map[string]treeFile) error {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	indexFiles := make(map[string]treeFile, len(idx.Entries))
+	var changed []string
+	for _, entry := range idx.Entries {
+		if entry.Stage == 0 {
+			indexFiles[entry.Name] = treeFile{Mode: entry.Mode, Hash: entry.Hash}
+		} else {
+			changed = append(changed, entry.Name)
+		}
+	}
+	changed = append(changed, changedFiles(files, indexFiles)...)
+	return restoreFiles(repo, files, changed)
+}
+
+// restoreHeadFiles restores the index entries and working copies of some paths to HEAD,
+// paths missing from HEAD are removed. Other files, untracked ones included, are left alone.
+func restoreHeadFiles(repo *git.Repository, paths []string) error {
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	return restoreFiles(repo, headFiles, paths)
+}
+
+// restoreFiles restores the index entries and working copies of some paths to files, paths missing from it are removed
+func restoreFiles(repo *git.Repository, files map[string]treeFile, paths []string) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	paths = append([]string(nil), paths...)
+	sort.Strings(paths)
+	for _, path := range uniqueSorted(paths) {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		file, found := files[path]
+		if !found {
+			removeIndexEntries(idx, path)
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			removeEmptyParents(root, filepath.Dir(fullPath))
+			continue
+		}
+
+		content, err := readBlob(repo, file.Hash)
+		if err != nil {
+			return err
+		}
+		if err := writeWorkingFile(fullPath, content, file.Mode); err != nil {
+			return err
+		}
+		setIndexEntry(idx, path, file, len(content))
+	}
+
+	sortIndex(idx)
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// mergeTrees merges the changes from base to ours and from base to theirs file by file.
+// Renames aren't detected, a renamed file is a deletion and an addition.
+func mergeTrees(repo *git.Repository, base, ours, theirs map[string]treeFile, oursLabel, theirsLabel string) (*treeMerge, error) {
+	result := &treeMerge{
+		files:     make(map[string]treeFile),
+		conflicts: make(map[string]*mergeConflict),
+	}
+
+	paths := make(map[string]bool, len(ours))
+	for _, files := range []map[string]treeFile{base, ours, theirs} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	for path := range paths {
+		b, hasBase := base[path]
+		o, hasOurs := ours[path]
+		t, hasTheirs := theirs[path]
+
+		switch {
+		case hasOurs == hasTheirs && o == t:
+			if hasOurs {
+				result.files[path] = o
+			}
+		case hasBase == hasOurs && b == o:
+			if hasTheirs {
+				result.files[path] = t
+			}
+		case hasBase == hasTheirs && b == t:
+			if hasOurs {
+				result.files[path] = o
+			}
+		case !hasOurs:
+			content, err := readBlob(repo, t.Hash)
+			if err != nil {
+				return nil, err
+			}
+			result.conflicts[path] = &mergeConflict{
+				Type: ConflictDeletedByUs, Base: &b, Theirs: &t,
+				Content: content, Mode: t.Mode,
+			}
+		case !hasTheirs:
+			content, err := readBlob(repo, o.Hash)
+			if err != nil {
+				return nil, err
+			}
+			result.conflicts[path] = &mergeConflict{
+				Type: ConflictDeletedByThem, Base: &b, Ours: &o,
+				Content: content, Mode: o.Mode,
+			}
+		default:
+			conflict, merged, err := mergeFile(repo, b, hasBase, o, t, oursLabel, theirsLabel)
+			if err != nil {
+				return nil, err
+			}
+			if conflict != nil {
+				result.conflicts[path] = conflict
+			} else {
+				result.files[path] = merged
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// mergeFile merges a file both sides changed, it returns either a conflict or the merged file
+func mergeFile(repo *git.Repository, b treeFile, hasBase bool, o, t treeFile, oursLabel, theirsLabel string) (*mergeConflict, treeFile, error) {
+	mode := o.Mode
+	if hasBase && o.Mode == b.Mode {
+		mode = t.Mode
+	}
+	if o.Hash == t.Hash {
+		// Only the mode changed
+		return nil, treeFile{Mode: mode, Hash: o.Hash}, nil
+	}
+
+	conflict := &mergeConflict{Type: ConflictContent, Ours: &o, Theirs: &t, Mode: mode}
+	if hasBase {
+		conflict.Base = &b
+	} else {
+		conflict.Type = ConflictBothAdded
+	}
+
+	ours, err := readBlob(repo, o.Hash)
+	if err != nil {
+		return nil, treeFile{}, err
+	}
+	theirs, err := readBlob(repo, t.Hash)
+	if err != nil {
+		return nil, treeFile{}, err
+	}
+	var baseContent []byte
+	if hasBase {
+		if baseContent, err = readBlob(repo, b.Hash); err != nil {
+			return nil, treeFile{}, err
+		}
+	}
+
+	if !o.Mode.IsRegular() || !t.Mode.IsRegular() || isBinaryContent(ours) || isBinaryContent(theirs) || isBinaryContent(baseContent) {
+		// Binary files, symlinks and submodules can't be merged by lines, ours is kept
+		conflict.Content = ours
+		conflict.Mode = o.Mode
+		conflict.IsBinary = true
+		return conflict, treeFile{}, nil
+	}
+
+	merged, conflicted := mergeText(string(baseContent), string(ours), string(theirs), oursLabel, theirsLabel)
+	if conflicted {
+		conflict.Content = []byte(merged)
+		return conflict, treeFile{}, nil
+	}
+
+	hash, err := writeBlob(repo, []byte(merged))
+	if err != nil {
+		return nil, treeFile{}, err
+	}
+	return nil, treeFile{Mode: mode, Hash: hash}, nil
+}
+
+// mergeHunk replaces the base lines from start to end with lines
+type mergeHunk struct {
+	start int
+	end   int
+	lines []string
+}
+
+// mergeText merges the changes from base to ours and from base to theirs line by line, like git merge-file.
+// Changes touching the same lines are kept between conflict markers, conflicted reports whether there were any.
+func mergeText(base, ours, theirs, oursLabel, theirsLabel string) (string, bool) {
+	baseLines := splitLinesKeepEOL(base)
+	oursHunks := mergeHunks(baseLines, splitLinesKeepEOL(ours))
+	theirsHunks := mergeHunks(baseLines, splitLinesKeepEOL(theirs))
+
+	// version applies some hunks to the base lines from start to end
+	version := func(hunks []mergeHunk, start, end int) []string {
+		var lines []string
+		pos := start
+		for _, h := range hunks {
+			lines = append(lines, baseLines[pos:h.start]...)
+			lines = append(lines, h.lines...)
+			pos = h.end
+		}
+		return append(lines, baseLines[pos:end]...)
+	}
+
+	var out strings.Builder
+	conflicted := false
+	pos, i, j := 0, 0, 0
+	for i < len(oursHunks) || j < len(theirsHunks) {
+		// Group the next hunk with every hunk of either side overlapping or touching it
+		var start int
+		if j >= len(theirsHunks) || (i < len(oursHunks) && oursHunks[i].start <= theirsHunks[j].start) {
+			start = oursHunks[i].start
+		} else {
+			start = theirsHunks[j].start
+		}
+		end := start
+		oi, tj := i, j
+		for {
+			if oi < len(oursHunks) && oursHunks[oi].start <= end {
+				if oursHunks[oi].end > end {
+					end = oursHunks[oi].end
+				}
+				oi++
+				continue
+			}
+			if tj < len(theirsHunks) && theirsHunks[tj].start <= end {
+				if theirsHunks[tj].end > end {
+					end = theirsHunks[tj].end
+				}
+				tj++
+				continue
+			}
+			break
+		}
+
+		out.WriteString(strings.Join(baseLines[pos:start], ""))
+		oursVersion := version(oursHunks[i:oi], start, end)
+		theirsVersion := version(theirsHunks[j:tj], start, end)
+		switch {
+		case tj == j:
+			out.WriteString(strings.Join(oursVersion, ""))
+		case oi == i:
+			out.WriteString(strings.Join(theirsVersion, ""))
+		case strings.Join(oursVersion, "") == strings.Join(theirsVersion, ""):
+			out.WriteString(strings.Join(oursVersion, ""))
+		default:
+			conflicted = true
+			out.WriteString("<<<<<<< " + oursLabel + "\n")
+			writeConflictSide(&out, oursVersion)
+			out.WriteString("=======\n")
+			writeConflictSide(&out, theirsVersion)
+			out.WriteString(">>>>>>> " + theirsLabel + "\n")
+		}
+
+		pos = end
+		i, j = oi, tj
+	}
+	out.WriteString(strings.Join(baseLines[pos:], ""))
+
+	return out.String(), conflicted
+}
+
+// writeConflictSide writes one side of a conflict, ending it with a line break so the next marker starts a line
+func writeConflictSide(out *strings.Builder, lines []string) {
+	text := strings.Join(lines, "")
+	out.WriteString(text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		out.WriteString("\n")
+	}
+}
+
+// splitLinesKeepEOL splits a content into lines that keep their line break
+func splitLinesKeepEOL(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// mergeHunks returns the changes from base to other as replaced base ranges
+func mergeHunks(base, other []string) []mergeHunk {
+	ops := diffLineOps(diffText{lines: base}, diffText{lines: other}, WhitespaceKeep)
+
+	var hunks []mergeHunk
+	var current *mergeHunk
+	for _, op := range ops {
+		if op.kind == ' ' {
+			current = nil
+			continue
+		}
+		if current == nil {
+			hunks = append(hunks, mergeHunk{start: op.oldIndex, end: op.oldIndex})
+			current = &hunks[len(hunks)-1]
+		}
+		if op.kind == '-' {
+			current.end++
+		} else {
+			current.lines = append(current.lines, other[op.newIndex])
+		}
+	}
+	return hunks
+}
+
+// treeMergeChanges returns the sorted files whose working copy a tree merge changes
+func treeMergeChanges(ours map[string]treeFile, merge *treeMerge) []string {
+	var changed []string
+	for path, file := range merge.files {
+		if current, ok := ours[path]; !ok || current != file {
+			changed = append(changed, path)
+		}
+	}
+	for path := range ours {
+		if _, ok := merge.files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	for path := range merge.conflicts {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+	return uniqueSorted(changed)
+}
+
+// checkUntrackedFiles fails if any of the given files is untracked, it would be overwritten
+func checkUntrackedFiles(status git.Status, paths []string) error {
+	for _, path := range paths {
+		if fileStatus, ok := status[path]; ok && fileStatus.Worktree == git.Untracked {
+			return fmt.Errorf("untracked file %s would be overwritten", path)
+		}
+	}
+	return nil
+}
+
+// applyTreeMerge writes a merge into the working tree and the index, ours being the files of the index before it.
+// With stageAll, clean changes are staged as well; otherwise only additions and deletions are, like git stash apply.
+// Conflicts are recorded in the index as stages 1, 2 and 3.
+// It fails without changing anything if local changes or untracked files would be overwritten.
+func applyTreeMerge(repo *git.Repository, ours map[string]treeFile, merge *treeMerge, stageAll bool) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	changed := treeMergeChanges(ours, merge)
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if err := checkUntrackedFiles(status, changed); err != nil {
+		return err
+	}
+	for _, path := range changed {
+		if fileStatus, ok := status[path]; ok && fileStatus.Worktree != git.Unmodified {
+			return fmt.Errorf("local changes to %s would be overwritten", path)
+		}
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	for _, path := range changed {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		file, merged := merge.files[path]
+		conflict := merge.conflicts[path]
+		_, inOurs := ours[path]
+
+		switch {
+		case merged:
+			content, err := readBlob(repo, file.Hash)
+			if err != nil {
+				return err
+			}
+			if err := writeWorkingFile(fullPath, content, file.Mode); err != nil {
+				return err
+			}
+			if stageAll || !inOurs {
+				setIndexEntry(idx, path, file, len(content))
+			}
+		case conflict != nil:
+			// Conflict markers, or the version of the side that kept the file
+			if err := writeWorkingFile(fullPath, conflict.Content, conflict.Mode); err != nil {
+				return err
+			}
+		default:
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			removeEmptyParents(root, filepath.Dir(fullPath))
+			idx.Remove(path)
+		}
+	}
+
+	for path, conflict := range merge.conflicts {
+		removeIndexEntries(idx, path)
+		for i, side := range []*treeFile{conflict.Base, conflict.Ours, conflict.Theirs} {
+			if side != nil {
+				entry := idx.Add(path)
+				entry.Hash = side.Hash
+				entry.Mode = side.Mode
+				entry.Stage = index.AncestorMode + index.Stage(i)
+			}
+		}
+	}
+
+	sortIndex(idx)
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// sortIndex sorts the index by path then stage, the encoder only sorts by path
+func sortIndex(idx *index.Index) {
+	sort.Slice(idx.Entries, func(i, j int) bool {
+		if idx.Entries[i].Name != idx.Entries[j].Name {
+			return idx.Entries[i].Name < idx.Entries[j].Name
+		}
+		return idx.Entries[i].Stage < idx.Entries[j].Stage
+	})
+}
+
+// setIndexEntry stages a file, replacing any entry of its path
+func setIndexEntry(idx *index.Index, path string, file treeFile, size int) {
+	removeIndexEntries(idx, path)
+	entry := idx.Add(path)
+	entry.Hash = file.Hash
+	entry.Mode = file.Mode
+	entry.Size = uint32(size)
+	// A zero time makes git compare the entry with the working copy again
+	entry.ModifiedAt = time.Time{}
+}
+
+// removeIndexEntries removes every entry of a path, including conflict stages
+func removeIndexEntries(idx *index.Index, path string) {
+	entries := idx.Entries[:0]
+	for _, entry := range idx.Entries {
+		if entry.Name != path {
+			entries = append(entries, entry)
+		}
+	}
+	idx.Entries = entries
+}
+
+// writeWorkingFile writes a file of the working tree with the permissions or link of a git file mode
+func writeWorkingFile(fullPath string, content []byte, mode filemode.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	if mode == filemode.Symlink {
+		os.Remove(fullPath)
+		if err := os.Symlink(filepath.FromSlash(string(content)), fullPath); err != nil {
+			return fmt.Errorf("failed to create link: %w", err)
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0644)
+	if mode == filemode.Executable {
+		perm = 0755
+	}
+	if info, err := os.Lstat(fullPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		os.Remove(fullPath)
+	}
+	if err := os.WriteFile(fullPath, content, perm); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return os.Chmod(fullPath, perm)
+}
+
+// removeEmptyParents removes empty directories from dir up to root, root excluded
+func removeEmptyParents(root, dir string) {
+	for dir != root && isSubPath(root, dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// uniqueSorted removes the duplicates of a sorted slice
+func uniqueSorted(values []string) []string {
+	unique := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// headTreeFiles returns the files of HEAD as a flattened tree, none without a commit
+func headTreeFiles(repo *git.Repository) (map[string]treeFile, error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return map[string]treeFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+	return treeFiles(tree)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// branchHash returns the hash a branch points to
+func (r *testRepo) branchHash(name string) string {
+	r.t.Helper()
+	ref, err := r.repo.Reference(plumbing.NewBranchReferenceName(name), true)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return ref.Hash().String()
+}
+
+func TestMergeBranchFastForward(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "file.txt", "two\n")
+
+	result, err := NewGitService().MergeBranch(r.dir, "feature", MergeOptions{})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.Status != MergeFastForward {
+		t.Fatalf("merge status = %s, want %s", result.Status, MergeFastForward)
+	}
+	if got, want := r.headHash(), r.branchHash("feature"); got != want {
+		t.Errorf("HEAD = %s, want the feature commit %s", got, want)
+	}
+	if got := r.headBranch(); got != "master" {
+		t.Errorf("HEAD is on %s, want master", got)
+	}
+	if content, _ := r.read("file.txt"); content != "two\n" {
+		t.Errorf("file.txt = %q, want %q", content, "two\n")
+	}
+
+	result, err = NewGitService().MergeBranch(r.dir, "feature", MergeOptions{})
+	if err != nil {
+		t.Fatalf("failed to merge again: %v", err)
+	}
+	if result.Status != MergeUpToDate {
+		t.Errorf("second merge status = %s, want %s", result.Status, MergeUpToDate)
+	}
+}
+
+func TestMergeBranchNoFastForward(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "file.txt", "two\n")
+	master := r.headHash()
+
+	result, err := NewGitService().MergeBranch(r.dir, "feature", MergeOptions{NoFastForward: true})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.Status != MergeClean {
+		t.Fatalf("merge status = %s, want %s", result.Status, MergeClean)
+	}
+	parents := result.Commit.ParentHashes
+	if len(parents) != 2 || parents[0] != master || parents[1] != r.branchHash("feature") {
+		t.Errorf("parents = %v, want master and feature", parents)
+	}
+	if result.Commit.Message != "Merge branch 'feature'\n" {
+		t.Errorf("message = %q, want the default merge message", result.Commit.Message)
+	}
+	if content, _ := r.read("file.txt"); content != "two\n" {
+		t.Errorf("file.txt = %q, want %q", content, "two\n")
+	}
+}
+
+func TestMergeBranchThreeWay(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\ntwo\nthree\n", "other.txt": "other\n"})
+	r.branch("feature", "file.txt", "one\ntwo\nTHREE\n")
+	r.write("file.txt", "ONE\ntwo\nthree\n")
+	r.write("added.txt", "added\n")
+	r.add("file.txt")
+	r.add("added.txt")
+	r.commit("ours")
+
+	result, err := NewGitService().MergeBranch(r.dir, "feature", MergeOptions{Message: "merge feature"})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.Status != MergeClean || len(result.Conflicts) != 0 {
+		t.Fatalf("merge status = %s with conflicts %v, want a clean merge", result.Status, result.Conflicts)
+	}
+	if len(result.Commit.ParentHashes) != 2 || result.Commit.Message != "merge feature\n" {
+		t.Errorf("commit = %+v, want a merge commit with the given message", result.Commit)
+	}
+	for name, want := range map[string]string{"file.txt": "ONE\ntwo\nTHREE\n", "added.txt": "added\n", "other.txt": "other\n"} {
+		if content, _ := r.read(name); content != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+		if got := r.status(name); got != "  " {
+			t.Errorf("%s status = %q, want it committed", name, got)
+		}
+	}
+}
+
+func TestMergeBranchDeleteModifyConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		deleteOn string // Side deleting the file, the other one changes it
+		wantType string
+	}{
+		{"deleted by us", "master", ConflictDeletedByUs},
+		{"deleted by them", "feature", ConflictDeletedByThem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t, map[string]string{"file.txt": "one\n", "keep.txt": "keep\n"})
+			s := NewGitService()
+			if _, err := s.CreateBranch(r.dir, "feature", ""); err != nil {
+				t.Fatalf("failed to create branch: %v", err)
+			}
+
+			// Each side commits its change on its own branch
+			for _, branch := range []string{"feature", "master"} {
+				if err := s.CheckoutBranch(r.dir, branch, CheckoutOptions{}); err != nil {
+					t.Fatalf("failed to checkout %s: %v", branch, err)
+				}
+				if branch == tt.deleteOn {
+					worktree, err := r.repo.Worktree()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if _, err := worktree.Remove("file.txt"); err != nil {
+						t.Fatalf("failed to remove file.txt: %v", err)
+					}
+				} else {
+					r.write("file.txt", "changed\n")
+					r.add("file.txt")
+				}
+				r.commit("change on " + branch)
+			}
+
+			result, err := s.MergeBranch(r.dir, "feature", MergeOptions{})
+			if err != nil {
+				t.Fatalf("failed to merge: %v", err)
+			}
+			if result.Status != MergeConflicted {
+				t.Fatalf("merge status = %s, want %s", result.Status, MergeConflicted)
+			}
+			if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "file.txt" || result.Conflicts[0].Type != tt.wantType {
+				t.Fatalf("conflicts = %+v, want file.txt %s", result.Conflicts, tt.wantType)
+			}
+			// The working copy keeps the changed version
+			if content, found := r.read("file.txt"); content != "changed\n" {
+				t.Errorf("file.txt = %q (exists %v), want %q", content, found, "changed\n")
+			}
+
+			state, err := s.GetMergeState(r.dir)
+			if err != nil {
+				t.Fatalf("failed to get merge state: %v", err)
+			}
+			if !state.InProgress || len(state.Conflicts) != 1 || state.Conflicts[0].Type != tt.wantType {
+				t.Errorf("merge state = %+v, want the conflict in progress", state)
+			}
+		})
+	}
+}
+
+func TestAbortMergeKeepsLocalChanges(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n", "notes.txt": "notes\n"})
+	r.branch("feature", "file.txt", "theirs\n")
+	r.write("file.txt", "ours\n")
+	r.add("file.txt")
+	r.commit("ours")
+	r.write("notes.txt", "local notes\n")
+	r.write("untracked.txt", "untracked\n")
+	s := NewGitService()
+
+	result, err := s.MergeBranch(r.dir, "feature", MergeOptions{})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.Status != MergeConflicted {
+		t.Fatalf("merge status = %s, want %s", result.Status, MergeConflicted)
+	}
+
+	if err := s.AbortMerge(r.dir); err != nil {
+		t.Fatalf("failed to abort: %v", err)
+	}
+	if content, _ := r.read("file.txt"); content != "ours\n" {
+		t.Errorf("file.txt = %q, want it back to %q", content, "ours\n")
+	}
+	if got := r.status("file.txt"); got != "  " {
+		t.Errorf("file.txt status = %q, want it unmodified", got)
+	}
+	if content, _ := r.read("notes.txt"); content != "local notes\n" {
+		t.Errorf("notes.txt = %q, want the local change kept", content)
+	}
+	if content, _ := r.read("untracked.txt"); content != "untracked\n" {
+		t.Errorf("untracked.txt = %q, want it kept", content)
+	}
+	state, err := s.GetMergeState(r.dir)
+	if err != nil {
+		t.Fatalf("failed to get merge state: %v", err)
+	}
+	if state.InProgress || len(state.Conflicts) != 0 {
+		t.Errorf("merge state = %+v, want no merge in progress", state)
+	}
+}
+
+func TestMergeText(t *testing.T) {
+	tests := []struct {
+		name       string
+		base       string
+		ours       string
+		theirs     string
+		want       string
+		conflicted bool
+	}{
+		{"only ours", "a\nb\n", "A\nb\n", "a\nb\n", "A\nb\n", false},
+		{"only theirs", "a\nb\n", "a\nb\n", "a\nB\n", "a\nB\n", false},
+		{"separate lines", "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n", "A\nb\nC\n", false},
+		{"same change", "a\nb\n", "a\nB\n", "a\nB\n", "a\nB\n", false},
+		{"both insert at the end", "a\n", "a\nours\n", "a\ntheirs\n", "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n", true},
+		{"same line", "a\nb\nc\n", "a\nours\nc\n", "a\ntheirs\nc\n", "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nc\n", true},
+		{"adjacent lines", "a\nb\n", "A\nb\n", "a\nB\n", "<<<<<<< ours\nA\nb\n=======\na\nB\n>>>>>>> theirs\n", true},
+		{"delete and change", "a\nb\nc\n", "a\nc\n", "a\nB\nc\n", "a\n<<<<<<< ours\n=======\nB\n>>>>>>> theirs\nc\n", true},
+		{"no final newline", "a\nb", "a\nours", "a\ntheirs", "a\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n", true},
+		{"empty base", "", "ours\n", "ours\n", "ours\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicted := mergeText(tt.base, tt.ours, tt.theirs, "ours", "theirs")
+			if got != tt.want || conflicted != tt.conflicted {
+				t.Errorf("mergeText = %q (conflicted %v), want %q (conflicted %v)", got, conflicted, tt.want, tt.conflicted)
+			}
+		})
+	}
+}
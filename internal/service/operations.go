@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+// maxFileOperations is the number of operations kept per project for undo
+const maxFileOperations = 100
+
+// Kinds of recorded file operations
+const (
+	FileOperationCreateFile      = "createFile"
+	FileOperationCreateDirectory = "createDirectory"
+	FileOperationRename          = "rename"
+	FileOperationDelete          = "delete"
+	FileOperationCopy            = "copy"
+)
+
+// FileOperation is an explorer operation recorded for undo and redo
+type FileOperation struct {
+	ID          int64     `json:"id"`
+	ProjectPath string    `json:"projectPath"`
+	Kind        string    `json:"kind"`       // One of the FileOperation constants
+	Path        string    `json:"path"`       // Created, renamed, deleted or copied path
+	TargetPath  string    `json:"targetPath"` // New path of a rename or a copy
+	IsDir       bool      `json:"isDir"`
+	Undone      bool      `json:"undone"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// newFileOperation converts a database row into a FileOperation
+func newFileOperation(op db.FileOperation) FileOperation {
+	return FileOperation{
+		ID:          op.ID,
+		ProjectPath: op.ProjectPath,
+		Kind:        op.Kind,
+		Path:        op.Path,
+		TargetPath:  op.TargetPath,
+		IsDir:       op.IsDir,
+		Undone:      op.Undone,
+		CreatedAt:   op.CreatedAt.Time,
+	}
+}
+
+// ListFileOperations returns the recorded operations of a project, most recent first
+func (s *FileService) ListFileOperations(projectPath string) ([]FileOperation, error) {
+	rows, err := s.queries.ListFileOperations(context.Background(), projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file operations: %w", err)
+	}
+
+	ops := make([]FileOperation, 0, len(rows))
+	for _, row := range rows {
+		ops = append(ops, newFileOperation(row))
+	}
+	return ops, nil
+}
+
+// UndoFileOperation reverts the last operation of a project.
+// It returns nil if there is nothing to undo.
+func (s *FileService) UndoFileOperation(projectPath string) (*FileOperation, error) {
+	s.operationsLock.Lock()
+	defer s.operationsLock.Unlock()
+
+	ctx := context.Background()
+	op, err := s.queries.GetLastDoneFileOperation(ctx, projectPath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file operation: %w", err)
+	}
+
+	if err := s.undoFileOperation(&op); err != nil {
+		return nil, err
+	}
+
+	op.Undone = true
+	if err := s.updateFileOperation(ctx, op); err != nil {
+		return nil, err
+	}
+
+	result := newFileOperation(op)
+	return &result, nil
+}
+
+// RedoFileOperation applies the last undone operation of a project again.
+// It returns nil if there is nothing to redo.
+func (s *FileService) RedoFileOperation(projectPath string) (*FileOperation, error) {
+	s.operationsLock.Lock()
+	defer s.operationsLock.Unlock()
+
+	ctx := context.Background()
+	op, err := s.queries.GetFirstUndoneFileOperation(ctx, projectPath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file operation: %w", err)
+	}
+
+	if err := s.redoFileOperation(&op); err != nil {
+		return nil, err
+	}
+
+	op.Undone = false
+	if err := s.updateFileOperation(ctx, op); err != nil {
+		return nil, err
+	}
+
+	result := newFileOperation(op)
+	return &result, nil
+}
+
+// undoFileOperation runs the inverse of an operation and updates the trash items now holding its files.
+// Created files are moved to the trash instead of being deleted so the undo can be redone.
+func (s *FileService) undoFileOperation(op *db.FileOperation) error {
+	switch op.Kind {
+	case FileOperationCreateFile, FileOperationCreateDirectory:
+		if err := checkPathState(op.Path, true, op.IsDir); err != nil {
+			return fmt.Errorf("can't undo create: %w", err)
+		}
+		item, err := s.moveToTrash(op.Path)
+		if err != nil {
+			return err
+		}
+		op.TrashItemID = sql.NullInt64{Int64: item.ID, Valid: true}
+		return nil
+
+	case FileOperationCopy:
+		if err := checkPathState(op.TargetPath, true, op.IsDir); err != nil {
+			return fmt.Errorf("can't undo copy: %w", err)
+		}
+		replaced, err := s.replacedTrashItem(op)
+		if err != nil {
+			return fmt.Errorf("can't undo copy: %w", err)
+		}
+		item, err := s.moveToTrash(op.TargetPath)
+		if err != nil {
+			return err
+		}
+		if err := s.restoreReplaced(op, replaced); err != nil {
+			if restoreErr := s.restoreTrashItem(item); restoreErr != nil {
+				log.Printf("[FileService] Failed to restore %s: %v", op.TargetPath, restoreErr)
+			}
+			return fmt.Errorf("can't undo copy: %w", err)
+		}
+		op.TrashItemID = sql.NullInt64{Int64: item.ID, Valid: true}
+		return nil
+
+	case FileOperationRename:
+		if err := checkPathState(op.TargetPath, true, op.IsDir); err != nil {
+			return fmt.Errorf("can't undo rename: %w", err)
+		}
+		if err := checkPathState(op.Path, false, op.IsDir); err != nil {
+			return fmt.Errorf("can't undo rename: %w", err)
+		}
+		replaced, err := s.replacedTrashItem(op)
+		if err != nil {
+			return fmt.Errorf("can't undo rename: %w", err)
+		}
+		if err := s.renamePath(op.TargetPath, op.Path); err != nil {
+			return err
+		}
+		if err := s.restoreReplaced(op, replaced); err != nil {
+			if renameErr := s.renamePath(op.Path, op.TargetPath); renameErr != nil {
+				log.Printf("[FileService] Failed to move %s back: %v", op.Path, renameErr)
+			}
+			return fmt.Errorf("can't undo rename: %w", err)
+		}
+		return nil
+
+	case FileOperationDelete:
+		if err := s.restoreOperationTrash(*op); err != nil {
+			return fmt.Errorf("can't undo delete: %w", err)
+		}
+		op.TrashItemID = sql.NullInt64{}
+		return nil
+	}
+
+	return fmt.Errorf("unknown file operation: %s", op.Kind)
+}
+
+// redoFileOperation runs an undone operation again and updates the trash items now holding its files
+func (s *FileService) redoFileOperation(op *db.FileOperation) error {
+	switch op.Kind {
+	case FileOperationCreateFile, FileOperationCreateDirectory, FileOperationCopy:
+		if !op.TrashItemID.Valid {
+			return fmt.Errorf("can't redo %s: no trashed copy of %s", op.Kind, op.Path)
+		}
+		// The entry the copy overwrote goes back to the trash first
+		if err := s.trashReplaced(op); err != nil {
+			return fmt.Errorf("can't redo %s: %w", op.Kind, err)
+		}
+		if err := s.restoreOperationTrash(*op); err != nil {
+			s.untrashReplaced(op)
+			return fmt.Errorf("can't redo %s: %w", op.Kind, err)
+		}
+		op.TrashItemID = sql.NullInt64{}
+		return nil
+
+	case FileOperationRename:
+		if err := checkPathState(op.Path, true, op.IsDir); err != nil {
+			return fmt.Errorf("can't redo rename: %w", err)
+		}
+		if err := s.trashReplaced(op); err != nil {
+			return fmt.Errorf("can't redo rename: %w", err)
+		}
+		if err := checkPathState(op.TargetPath, false, op.IsDir); err != nil {
+			s.untrashReplaced(op)
+			return fmt.Errorf("can't redo rename: %w", err)
+		}
+		if err := s.renamePath(op.Path, op.TargetPath); err != nil {
+			s.untrashReplaced(op)
+			return err
+		}
+		return nil
+
+	case FileOperationDelete:
+		if err := checkPathState(op.Path, true, op.IsDir); err != nil {
+			return fmt.Errorf("can't redo delete: %w", err)
+		}
+		item, err := s.moveToTrash(op.Path)
+		if err != nil {
+			return err
+		}
+		op.TrashItemID = sql.NullInt64{Int64: item.ID, Valid: true}
+		return nil
+	}
+
+	return fmt.Errorf("unknown file operation: %s", op.Kind)
+}
+
+// restoreOperationTrash restores the trashed files of an operation
+func (s *FileService) restoreOperationTrash(op db.FileOperation) error {
+	if !op.TrashItemID.Valid {
+		return fmt.Errorf("no trashed copy of %s", op.Path)
+	}
+	item, err := s.getTrashItem(context.Background(), op.TrashItemID.Int64)
+	if err != nil {
+		return err
+	}
+	return s.restoreTrashItem(item)
+}
+
+// replacedTrashItem loads the trashed entry an overwriting copy or move replaced, before the undo touches any file
+func (s *FileService) replacedTrashItem(op *db.FileOperation) (*db.TrashItem, error) {
+	if !op.Overwrite {
+		return nil, nil
+	}
+	if !op.ReplacedTrashItemID.Valid {
+		return nil, fmt.Errorf("no trashed copy of the replaced %s", op.TargetPath)
+	}
+	item, err := s.getTrashItem(context.Background(), op.ReplacedTrashItemID.Int64)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// restoreReplaced puts the entry an overwriting copy or move replaced back at its target
+func (s *FileService) restoreReplaced(op *db.FileOperation, replaced *db.TrashItem) error {
+	if replaced == nil {
+		return nil
+	}
+	if err := s.restoreTrashItem(*replaced); err != nil {
+		return err
+	}
+	op.ReplacedTrashItemID = sql.NullInt64{}
+	return nil
+}
+
+// trashReplaced moves the entry an overwriting copy or move replaces to the trash again
+func (s *FileService) trashReplaced(op *db.FileOperation) error {
+	if !op.Overwrite {
+		return nil
+	}
+	item, err := s.moveToTrash(op.TargetPath)
+	if err != nil {
+		return err
+	}
+	op.ReplacedTrashItemID = sql.NullInt64{Int64: item.ID, Valid: true}
+	return nil
+}
+
+// untrashReplaced reverts trashReplaced after a failed redo
+func (s *FileService) untrashReplaced(op *db.FileOperation) {
+	replaced, err := s.replacedTrashItem(op)
+	if err == nil {
+		err = s.restoreReplaced(op, replaced)
+	}
+	if err != nil {
+		log.Printf("[FileService] Failed to restore %s: %v", op.TargetPath, err)
+	}
+}
+
+// renamePath renames a file or directory for undo and redo, creating missing parents
+func (s *FileService) renamePath(oldPath, newPath string) error {
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := movePath(oldPath, newPath, nil); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+
+	s.InvalidateCache(filepath.Dir(oldPath))
+	s.InvalidateCache(filepath.Dir(newPath))
+	return nil
+}
+
+// checkPathState verifies that a path exists or is absent as an operation expects
+func checkPathState(path string, exists, isDir bool) error {
+	info, err := os.Lstat(path)
+	if !exists {
+		if err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s no longer exists", path)
+	}
+	if info.IsDir() != isDir {
+		return fmt.Errorf("%s was replaced on disk", path)
+	}
+	return nil
+}
+
+// updateFileOperation stores the undo state of an operation
+func (s *FileService) updateFileOperation(ctx context.Context, op db.FileOperation) error {
+	err := s.queries.UpdateFileOperationState(ctx, db.UpdateFileOperationStateParams{
+		Undone:              op.Undone,
+		TrashItemID:         op.TrashItemID,
+		ReplacedTrashItemID: op.ReplacedTrashItemID,
+		ID:                  op.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update file operation: %w", err)
+	}
+	return nil
+}
+
+// recordFileOperation adds a completed operation to the journal of its project.
+// A new operation discards the operations that were undone before it.
+func (s *FileService) recordFileOperation(kind, path, targetPath string, isDir bool, trashItemID int64) {
+	s.recordOverwrite(kind, path, targetPath, isDir, trashItemID, nil)
+}
+
+// recordOverwrite records a copy or move like recordFileOperation, along with the trashed entry it replaced, if any
+func (s *FileService) recordOverwrite(kind, path, targetPath string, isDir bool, trashItemID int64, replaced *db.TrashItem) {
+	s.operationsLock.Lock()
+	defer s.operationsLock.Unlock()
+
+	ctx := context.Background()
+	// Operations belong to the project they end up in
+	projectPath := s.projectRoot(firstNonEmpty(targetPath, path))
+
+	if err := s.queries.DeleteUndoneFileOperations(ctx, projectPath); err != nil {
+		log.Printf("[FileService] Failed to discard undone file operations: %v", err)
+		return
+	}
+
+	params := db.CreateFileOperationParams{
+		ProjectPath: projectPath,
+		Kind:        kind,
+		Path:        path,
+		TargetPath:  targetPath,
+		IsDir:       isDir,
+		TrashItemID: sql.NullInt64{Int64: trashItemID, Valid: trashItemID != 0},
+	}
+	if replaced != nil {
+		params.Overwrite = true
+		params.ReplacedTrashItemID = sql.NullInt64{Int64: replaced.ID, Valid: true}
+	}
+	_, err := s.queries.CreateFileOperation(ctx, params)
+	if err != nil {
+		log.Printf("[FileService] Failed to record file operation: %v", err)
+		return
+	}
+
+	err = s.queries.TrimFileOperations(ctx, db.TrimFileOperationsParams{
+		ProjectPath:   projectPath,
+		ProjectPath_2: projectPath,
+		Limit:         maxFileOperations,
+	})
+	if err != nil {
+		log.Printf("[FileService] Failed to trim file operations: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// checkUndo runs UndoFileOperation or RedoFileOperation and checks the kind of the operation it reverted
+func checkUndo(t *testing.T, undo func(string) (*FileOperation, error), root, kind string) {
+	t.Helper()
+	op, err := undo(root)
+	if err != nil {
+		t.Fatalf("failed to undo or redo %s: %v", kind, err)
+	}
+	if op == nil || op.Kind != kind {
+		t.Fatalf("got operation %+v, want %s", op, kind)
+	}
+}
+
+func TestUndoRedoFileOperations(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a", "old.txt": "old", "gone.txt": "gone"})
+	path := func(name string) string { return filepath.Join(root, name) }
+
+	if err := s.CreateFile(path("new.txt")); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := s.CreateDirectory(path("dir")); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := s.RenameFile(path("old.txt"), path("renamed.txt")); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+	if err := s.DeleteFile(path("gone.txt")); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	// Operations are undone from the most recent one
+	checkUndo(t, s.UndoFileOperation, root, FileOperationDelete)
+	checkTestFiles(t, root, map[string]string{"gone.txt": "gone"})
+	checkUndo(t, s.UndoFileOperation, root, FileOperationRename)
+	checkTestFiles(t, root, map[string]string{"old.txt": "old", "renamed.txt": ""})
+	checkUndo(t, s.UndoFileOperation, root, FileOperationCreateDirectory)
+	if _, err := os.Stat(path("dir")); !os.IsNotExist(err) {
+		t.Errorf("dir still exists after undoing its creation")
+	}
+	checkUndo(t, s.UndoFileOperation, root, FileOperationCreateFile)
+	if _, err := os.Stat(path("new.txt")); !os.IsNotExist(err) {
+		t.Errorf("new.txt still exists after undoing its creation")
+	}
+	if op, err := s.UndoFileOperation(root); err != nil || op != nil {
+		t.Fatalf("undo with nothing left returned %+v, %v", op, err)
+	}
+
+	// and redone from the oldest undone one
+	checkUndo(t, s.RedoFileOperation, root, FileOperationCreateFile)
+	if _, err := os.Stat(path("new.txt")); err != nil {
+		t.Errorf("new.txt is missing after redoing its creation: %v", err)
+	}
+	checkUndo(t, s.RedoFileOperation, root, FileOperationCreateDirectory)
+	if info, err := os.Stat(path("dir")); err != nil || !info.IsDir() {
+		t.Errorf("dir is missing after redoing its creation: %v", err)
+	}
+	checkUndo(t, s.RedoFileOperation, root, FileOperationRename)
+	checkTestFiles(t, root, map[string]string{"old.txt": "", "renamed.txt": "old"})
+	checkUndo(t, s.RedoFileOperation, root, FileOperationDelete)
+	checkTestFiles(t, root, map[string]string{"gone.txt": ""})
+	if op, err := s.RedoFileOperation(root); err != nil || op != nil {
+		t.Fatalf("redo with nothing left returned %+v, %v", op, err)
+	}
+}
+
+func TestNewFileOperationDiscardsRedo(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a"})
+	if err := s.RenameFile(filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+	checkUndo(t, s.UndoFileOperation, root, FileOperationRename)
+
+	if err := s.CreateFile(filepath.Join(root, "c.txt")); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if op, err := s.RedoFileOperation(root); err != nil || op != nil {
+		t.Fatalf("redo after a new operation returned %+v, %v, want nothing to redo", op, err)
+	}
+	ops, err := s.ListFileOperations(root)
+	if err != nil {
+		t.Fatalf("failed to list operations: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Kind != FileOperationCreateFile {
+		t.Errorf("operations = %+v, want only the create", ops)
+	}
+}
+
+func TestUndoRejectsChangedFiles(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a"})
+	if err := s.RenameFile(filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+	// Something else took the old name in the meantime
+	writeTestFile(t, filepath.Join(root, "a.txt"), "other")
+
+	if _, err := s.UndoFileOperation(root); err == nil {
+		t.Fatal("undoing a rename over an existing file succeeded")
+	}
+	checkTestFiles(t, root, map[string]string{"a.txt": "other", "b.txt": "a"})
+
+	// The operation stays in the journal for a later attempt
+	if err := os.Remove(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	checkUndo(t, s.UndoFileOperation, root, FileOperationRename)
+	checkTestFiles(t, root, map[string]string{"a.txt": "a", "b.txt": ""})
+}
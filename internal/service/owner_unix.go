@@ -0,0 +1,24 @@
+//go:build !windows
+
+package service
+
+import (
+	"os"
+	"syscall"
+)
+
+// copyOwner gives f the owner and group of the file described by info.
+// Changing the owner needs privileges, so only the group is kept when that fails.
+func copyOwner(f *os.File, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := f.Chown(int(stat.Uid), int(stat.Gid)); err != nil {
+		if err := f.Chown(-1, int(stat.Gid)); err != nil && !os.IsPermission(err) {
+			return err
+		}
+	}
+	return nil
+}
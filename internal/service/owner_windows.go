@@ -0,0 +1,10 @@
+//go:build windows
+
+package service
+
+import "os"
+
+// copyOwner is a no-op on Windows, where files inherit the ACL of their directory
+func copyOwner(f *os.File, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Operations that can stop on conflicts
+const (
+	OperationMerge      = "merge"
+	OperationCherryPick = "cherry-pick"
+	OperationRevert     = "revert"
+	OperationRebase     = "rebase"
+)
+
+// Files of the .git directory describing a cherry-pick or revert in progress, shared with git
+const (
+	cherryPickHeadFile = "CHERRY_PICK_HEAD"
+	revertHeadFile     = "REVERT_HEAD"
+	sequencerTodoFile  = "sequencer/todo"
+	sequencerHeadFile  = "sequencer/head"
+	sequencerOptsFile  = "sequencer/opts"
+	sequencerIndexFile = "sequencer/index" // Tree of the index before the picks, restored on abort
+)
+
+// PickOptions contains options for cherry-picking or reverting commits
+type PickOptions struct {
+	NoCommit bool `json:"noCommit"` // Leave the changes staged instead of committing them
+}
+
+// pickStep is a commit to cherry-pick or revert
+type pickStep struct {
+	revert bool
+	hash   plumbing.Hash
+}
+
+// CherryPick applies the changes of commits onto the current branch in the given order, keeping their authors.
+// It stops on the first conflict, to be resumed with ContinuePick or cancelled with AbortPick.
+func (s *GitService) CherryPick(projectPath string, hashes []string, opts PickOptions) (*MergeResult, error) {
+	return s.startPicks(projectPath, hashes, false, opts)
+}
+
+// Revert creates commits undoing the changes of commits, in the given order.
+// It stops on the first conflict, to be resumed with ContinuePick or cancelled with AbortPick.
+func (s *GitService) Revert(projectPath string, hashes []string, opts PickOptions) (*MergeResult, error) {
+	return s.startPicks(projectPath, hashes, true, opts)
+}
+
+// ContinuePick commits the resolved commit of a stopped cherry-pick or revert and applies the remaining ones
+func (s *GitService) ContinuePick(projectPath string) (*MergeResult, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	operation, err := operationInProgress(repo)
+	if err != nil {
+		return nil, err
+	}
+	if operation != OperationCherryPick && operation != OperationRevert {
+		return nil, errors.New("no cherry-pick or revert in progress")
+	}
+
+	headFile := cherryPickHeadFile
+	if operation == OperationRevert {
+		headFile = revertHeadFile
+	}
+	picked, found, err := readGitFile(repo, headFile)
+	if err != nil {
+		return nil, err
+	}
+	optsFile, _, err := readGitFile(repo, sequencerOptsFile)
+	if err != nil {
+		return nil, err
+	}
+	noCommit := strings.Contains(optsFile, "no-commit = true")
+
+	files, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if found && !noCommit {
+		commit, err := repo.CommitObject(plumbing.NewHash(strings.TrimSpace(picked)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		message, _, err := readGitFile(repo, mergeMsgFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := commitPick(repo, files, commit, operation == OperationRevert, cleanMessage(message)); err != nil {
+			return nil, err
+		}
+	}
+	if err := removeGitFiles(repo, headFile, mergeMsgFile); err != nil {
+		return nil, err
+	}
+
+	todo, _, err := readGitFile(repo, sequencerTodoFile)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := parsePickTodo(todo)
+	if err != nil {
+		return nil, err
+	}
+	return runPicks(repo, steps, noCommit)
+}
+
+// AbortPick cancels a stopped cherry-pick or revert, going back to the commit it started from
+func (s *GitService) AbortPick(projectPath string) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	operation, err := operationInProgress(repo)
+	if err != nil {
+		return err
+	}
+	if operation != OperationCherryPick && operation != OperationRevert {
+		return errors.New("no cherry-pick or revert in progress")
+	}
+
+	original, found, err := readGitFile(repo, sequencerHeadFile)
+	if err != nil {
+		return err
+	}
+	if found {
+		head, err := repo.Head()
+		if err != nil {
+			return fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		if err := updateHead(repo, head, plumbing.NewHash(strings.TrimSpace(original))); err != nil {
+			return err
+		}
+	}
+
+	// Files changed by the picked commits differ between the index and the one the picks started from,
+	// changes staged before a no-commit pick are kept
+	files, err := headTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	if tree, found, err := readGitFile(repo, sequencerIndexFile); err != nil {
+		return err
+	} else if found {
+		indexTree, err := repo.TreeObject(plumbing.NewHash(strings.TrimSpace(tree)))
+		if err != nil {
+			return fmt.Errorf("failed to get tree: %w", err)
+		}
+		if files, err = treeFiles(indexTree); err != nil {
+			return err
+		}
+	}
+	if err := resetIndexTo(repo, files); err != nil {
+		return err
+	}
+	return clearPickState(repo)
+}
+
+// startPicks checks the repository can cherry-pick or revert, then applies the commits
+func (s *GitService) startPicks(projectPath string, hashes []string, revert bool, opts PickOptions) (*MergeResult, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if len(hashes) == 0 {
+		return nil, errors.New("no commits given")
+	}
+	if operation, err := operationInProgress(repo); err != nil {
+		return nil, err
+	} else if operation != "" {
+		return nil, fmt.Errorf("a %s is in progress, finish or abort it first", operation)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("cannot cherry-pick or revert without a commit: %w", err)
+	}
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	indexFiles, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.NoCommit && len(changedFiles(headFiles, indexFiles)) > 0 {
+		return nil, errors.New("you have staged changes, commit or stash them first")
+	}
+	indexTree, err := writeTree(repo, indexFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make([]pickStep, 0, len(hashes))
+	for _, hash := range hashes {
+		commit, err := resolveCommit(repo, hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(commit.ParentHashes) > 1 {
+			return nil, fmt.Errorf("%s is a merge commit, it can't be cherry-picked or reverted", commit.Hash.String()[:7])
+		}
+		steps = append(steps, pickStep{revert: revert, hash: commit.Hash})
+	}
+
+	if err := writeGitFile(repo, origHeadFile, head.Hash().String()+"\n"); err != nil {
+		return nil, err
+	}
+	if err := writeGitFile(repo, sequencerHeadFile, head.Hash().String()+"\n"); err != nil {
+		return nil, err
+	}
+	if err := writeGitFile(repo, sequencerIndexFile, indexTree.String()+"\n"); err != nil {
+		return nil, err
+	}
+	optsFile := "[options]\n"
+	if opts.NoCommit {
+		optsFile += "\tno-commit = true\n"
+	}
+	if err := writeGitFile(repo, sequencerOptsFile, optsFile); err != nil {
+		return nil, err
+	}
+	result, err := runPicks(repo, steps, opts.NoCommit)
+	if err != nil {
+		clearPickState(repo)
+		return nil, err
+	}
+	return result, nil
+}
+
+// runPicks applies commits one after the other on top of the index, committing each unless noCommit.
+// It stops on the first conflict and records the remaining commits in the sequencer.
+func runPicks(repo *git.Repository, steps []pickStep, noCommit bool) (*MergeResult, error) {
+	for i, step := range steps {
+		commit, err := repo.CommitObject(step.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		ours, err := stagedFiles(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		merge, err := mergePick(repo, commit, step.revert, ours)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyTreeMerge(repo, ours, merge, true); err != nil {
+			return nil, err
+		}
+
+		message := pickMessage(commit, step.revert)
+		if conflicts := merge.conflictList(); len(conflicts) > 0 {
+			if err := savePickConflict(repo, commit, step.revert, message, conflicts, steps[i+1:]); err != nil {
+				return nil, err
+			}
+			return &MergeResult{Status: MergeConflicted, Conflicts: conflicts}, nil
+		}
+
+		if !noCommit {
+			if _, err := commitPick(repo, merge.files, commit, step.revert, message); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := clearPickState(repo); err != nil {
+		return nil, err
+	}
+	result := &MergeResult{Status: MergeClean, Conflicts: []ConflictFile{}}
+	if !noCommit {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		result.Commit = commitInfo(commit)
+	}
+	return result, nil
+}
+
+// mergePick merges the changes of a commit, or their reverse, into ours
+func mergePick(repo *git.Repository, commit *object.Commit, revert bool, ours map[string]treeFile) (*treeMerge, error) {
+	parentFiles := map[string]treeFile{}
+	if len(commit.ParentHashes) > 0 {
+		var err error
+		if parentFiles, err = commitFiles(repo, commit.ParentHashes[0]); err != nil {
+			return nil, err
+		}
+	}
+	commitTree, err := commitFiles(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	label := fmt.Sprintf("%s (%s)", commit.Hash.String()[:7], commitSubject(commit))
+	if revert {
+		return mergeTrees(repo, commitTree, ours, parentFiles, "HEAD", "parent of "+label)
+	}
+	return mergeTrees(repo, parentFiles, ours, commitTree, "HEAD", label)
+}
+
+// commitPick commits the index as the result of cherry-picking or reverting a commit.
+// A cherry-pick keeps the author of the original commit. Picks that change nothing create no commit.
+func commitPick(repo *git.Repository, files map[string]treeFile, picked *object.Commit, revert bool, message string) (plumbing.Hash, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := writeTree(repo, files)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if tree == headCommit.TreeHash {
+		return head.Hash(), nil
+	}
+
+	sig, err := gitSignature(repo)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	author := sig
+	if !revert {
+		author = &picked.Author
+	}
+	hash, err := writeCommit(repo, tree, []plumbing.Hash{head.Hash()}, strings.TrimSpace(message)+"\n", author, sig)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := updateHead(repo, head, hash); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return hash, nil
+}
+
+// savePickConflict records a cherry-pick or revert stopped on conflicts, like git does
+func savePickConflict(repo *git.Repository, commit *object.Commit, revert bool, message string, conflicts []ConflictFile, remaining []pickStep) error {
+	headFile := cherryPickHeadFile
+	if revert {
+		headFile = revertHeadFile
+	}
+	if err := writeGitFile(repo, headFile, commit.Hash.String()+"\n"); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	msg.WriteString(strings.TrimSpace(message) + "\n\n# Conflicts:\n")
+	for _, conflict := range conflicts {
+		msg.WriteString("#\t" + conflict.Path + "\n")
+	}
+	if err := writeGitFile(repo, mergeMsgFile, msg.String()); err != nil {
+		return err
+	}
+
+	var todo strings.Builder
+	for _, step := range remaining {
+		command := "pick"
+		if step.revert {
+			command = "revert"
+		}
+		subject := ""
+		if c, err := repo.CommitObject(step.hash); err == nil {
+			subject = commitSubject(c)
+		}
+		fmt.Fprintf(&todo, "%s %s %s\n", command, step.hash, subject)
+	}
+	return writeGitFile(repo, sequencerTodoFile, todo.String())
+}
+
+// clearPickState removes the files of a cherry-pick or revert in progress
+func clearPickState(repo *git.Repository) error {
+	return removeGitFiles(repo, cherryPickHeadFile, revertHeadFile, mergeMsgFile,
+		sequencerTodoFile, sequencerHeadFile, sequencerOptsFile, sequencerIndexFile, "sequencer")
+}
+
+// parsePickTodo parses the commits left in the sequencer, "pick <hash> <subject>" or "revert <hash> <subject>"
+func parsePickTodo(todo string) ([]pickStep, error) {
+	var steps []pickStep
+	for _, line := range strings.Split(todo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "pick", "p":
+			steps = append(steps, pickStep{hash: plumbing.NewHash(fields[1])})
+		case "revert":
+			steps = append(steps, pickStep{revert: true, hash: plumbing.NewHash(fields[1])})
+		default:
+			return nil, fmt.Errorf("unknown sequencer command %q", fields[0])
+		}
+	}
+	return steps, nil
+}
+
+// pickMessage returns the message of the commit cherry-picking or reverting a commit, like git
+func pickMessage(commit *object.Commit, revert bool) string {
+	if !revert {
+		return commit.Message
+	}
+	return fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.\n", commitSubject(commit), commit.Hash)
+}
+
+// commitSubject returns the first line of a commit message
+func commitSubject(commit *object.Commit) string {
+	return strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+}
+
+// operationInProgress returns the operation stopped on conflicts, empty if none
+func operationInProgress(repo *git.Repository) (string, error) {
+	for _, op := range []struct{ file, name string }{
+		{mergeHeadFile, OperationMerge},
+		{cherryPickHeadFile, OperationCherryPick},
+		{revertHeadFile, OperationRevert},
+		{sequencerHeadFile, OperationCherryPick},
+		{rebaseHeadNameFile, OperationRebase},
+	} {
+		_, found, err := readGitFile(repo, op.file)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return op.name, nil
+		}
+	}
+	return "", nil
+}
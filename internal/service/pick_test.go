@@ -0,0 +1,57 @@
+package service
+
+import "testing"
+
+// headHash returns the hash of the commit HEAD points to
+func (r *testRepo) headHash() string {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return head.Hash().String()
+}
+
+func TestAbortNoCommitPickKeepsStagedChanges(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "one\n"})
+	r.branch("feature", "b.txt", "theirs\n")
+	feature, err := r.repo.Reference("refs/heads/feature", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.write("b.txt", "ours\n")
+	r.add("b.txt")
+	r.commit("ours")
+	head := r.headHash()
+
+	r.write("a.txt", "staged\n")
+	r.add("a.txt")
+	s := NewGitService()
+
+	result, err := s.CherryPick(r.dir, []string{feature.Hash().String()}, PickOptions{NoCommit: true})
+	if err != nil {
+		t.Fatalf("failed to cherry-pick: %v", err)
+	}
+	if result.Status != MergeConflicted {
+		t.Fatalf("cherry-pick status = %s, want %s", result.Status, MergeConflicted)
+	}
+	if err := s.AbortPick(r.dir); err != nil {
+		t.Fatalf("failed to abort: %v", err)
+	}
+
+	if got := r.headHash(); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if content, _ := r.staged("a.txt"); content != "staged\n" {
+		t.Errorf("index of a.txt = %q, want the staged change %q", content, "staged\n")
+	}
+	if content, _ := r.read("a.txt"); content != "staged\n" {
+		t.Errorf("a.txt = %q, want %q", content, "staged\n")
+	}
+	if got := r.status("b.txt"); got != "  " {
+		t.Errorf("status of b.txt = %q, want it back to HEAD", got)
+	}
+	if content, _ := r.read("b.txt"); content != "ours\n" {
+		t.Errorf("b.txt = %q, want %q", content, "ours\n")
+	}
+}
@@ -50,3 +50,9 @@ func (s *ProjectsService) AddProject(name, path string) (*db.Project, error) {
 
 	return &proj, nil
 }
+
+// HasProject reports whether a project with the given path was added before
+func (s *ProjectsService) HasProject(path string) bool {
+	_, err := s.queries.GetProject(context.Background(), path)
+	return err == nil
+}
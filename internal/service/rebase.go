@@ -0,0 +1,781 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Actions of a rebase step
+const (
+	RebasePick   = "pick"
+	RebaseReword = "reword" // Pick with a new message
+	RebaseEdit   = "edit"   // Pick then pause to amend the commit
+	RebaseSquash = "squash" // Meld into the previous commit, joining the messages
+	RebaseFixup  = "fixup"  // Meld into the previous commit, keeping its message
+	RebaseDrop   = "drop"
+)
+
+// Outcomes of running a rebase
+const (
+	RebaseFinished   = "finished"   // Every step ran, the branch points to the rewritten commits
+	RebaseConflicted = "conflicted" // Paused on conflicts to resolve
+	RebaseEditing    = "editing"    // Paused after an edit step
+)
+
+// The rebase state lives in the .git directory like git's interactive rebase, so it survives a restart
+const (
+	rebaseDir          = "rebase-merge"
+	rebaseHeadNameFile = rebaseDir + "/head-name"
+	rebaseOntoFile     = rebaseDir + "/onto"
+	rebaseOrigHeadFile = rebaseDir + "/orig-head"
+	rebaseTodoFile     = rebaseDir + "/git-rebase-todo"
+	rebaseDoneFile     = rebaseDir + "/done"
+	rebaseStoppedFile  = rebaseDir + "/stopped-sha"
+	rebaseAmendFile    = rebaseDir + "/amend"
+	rebaseRunningFile  = rebaseDir + "/running"  // HEAD and commit of the step being replayed, until it is committed
+	rebaseMessagesDir  = rebaseDir + "/messages" // New messages of reword and squash steps, by commit hash
+)
+
+// RebaseStep is a line of a rebase plan
+type RebaseStep struct {
+	Action  string `json:"action"` // One of the Rebase action constants
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"` // First line of the original message
+	Message string `json:"message"` // New message of a reword or squash step, the default one if empty
+}
+
+// RebasePlan is the list of commits to replay onto a commit, oldest first
+type RebasePlan struct {
+	Onto  string       `json:"onto"` // Commit the steps are replayed onto
+	Steps []RebaseStep `json:"steps"`
+}
+
+// RebaseState describes a rebase in progress or just finished
+type RebaseState struct {
+	InProgress bool           `json:"inProgress"`
+	Status     string         `json:"status"` // One of the Rebase outcome constants, empty if no rebase ran
+	Branch     string         `json:"branch"` // Branch being rebased, empty for a detached HEAD
+	Onto       string         `json:"onto"`
+	Current    *RebaseStep    `json:"current"` // Step the rebase paused on
+	Done       []RebaseStep   `json:"done"`
+	Todo       []RebaseStep   `json:"todo"`
+	Conflicts  []ConflictFile `json:"conflicts"`
+	Commit     *CommitInfo    `json:"commit"` // HEAD
+}
+
+// GetRebasePlan returns the default plan replaying the commits of the current branch since upstream onto it.
+// Upstream may be any revision, e.g. "main" or "HEAD~3". Like git, the plan lists every commit reachable
+// from HEAD and not from upstream, parents first, and leaves merge commits out.
+func (s *GitService) GetRebasePlan(projectPath string, upstream string) (*RebasePlan, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := resolveCommit(repo, "")
+	if err != nil {
+		return nil, err
+	}
+	onto, err := resolveCommit(repo, upstream)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, _, err := exclusiveCommits(repo, head.Hash, onto.Hash)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[plumbing.Hash]bool, len(hashes))
+	for _, hash := range hashes {
+		pending[hash] = true
+	}
+
+	// Depth-first from HEAD, a commit is listed once all of its parents are
+	plan := &RebasePlan{Onto: onto.Hash.String(), Steps: make([]RebaseStep, 0, len(hashes))}
+	var visit func(hash plumbing.Hash) error
+	visit = func(hash plumbing.Hash) error {
+		if !pending[hash] {
+			return nil
+		}
+		delete(pending, hash)
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get commit: %w", err)
+		}
+		for _, parent := range commit.ParentHashes {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		if len(commit.ParentHashes) <= 1 {
+			plan.Steps = append(plan.Steps, RebaseStep{
+				Action:  RebasePick,
+				Hash:    commit.Hash.String(),
+				Subject: commitSubject(commit),
+			})
+		}
+		return nil
+	}
+	if err := visit(head.Hash); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// StartRebase runs an edited rebase plan until it finishes or pauses on conflicts or an edit step
+func (s *GitService) StartRebase(projectPath string, plan RebasePlan) (*RebaseState, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if operation, err := operationInProgress(repo); err != nil {
+		return nil, err
+	} else if operation != "" {
+		return nil, fmt.Errorf("a %s is in progress, finish or abort it first", operation)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if dirty, err := isDirty(worktree); err != nil {
+		return nil, err
+	} else if dirty {
+		return nil, errDirtyWorktree
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("cannot rebase without a commit: %w", err)
+	}
+	onto, err := resolveCommit(repo, plan.Onto)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := validateRebasePlan(repo, plan.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	headName := "detached HEAD"
+	if head.Name().IsBranch() {
+		headName = head.Name().String()
+	}
+	files := map[string]string{
+		rebaseHeadNameFile:         headName + "\n",
+		rebaseOntoFile:             onto.Hash.String() + "\n",
+		rebaseOrigHeadFile:         head.Hash().String() + "\n",
+		rebaseDir + "/interactive": "",
+		rebaseDoneFile:             "",
+		rebaseTodoFile:             formatRebaseTodo(steps),
+		origHeadFile:               head.Hash().String() + "\n",
+	}
+	for name, content := range files {
+		if err := writeGitFile(repo, name, content); err != nil {
+			return nil, err
+		}
+	}
+	for _, step := range steps {
+		if step.Message != "" {
+			if err := writeGitFile(repo, rebaseMessagesDir+"/"+step.Hash, step.Message); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Replay from a detached HEAD on onto, the branch moves once every step ran
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+	ontoFiles, err := commitFiles(repo, onto.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTreeMerge(repo, headFiles, &treeMerge{files: ontoFiles, conflicts: map[string]*mergeConflict{}}, true); err != nil {
+		removeGitDir(repo, rebaseDir)
+		return nil, err
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, onto.Hash)); err != nil {
+		return nil, fmt.Errorf("failed to detach HEAD: %w", err)
+	}
+
+	return runRebase(repo)
+}
+
+// ContinueRebase resumes a paused rebase. After conflicts, the resolved files are committed for the current step;
+// after an edit step, staged changes are amended into the edited commit.
+func (s *GitService) ContinueRebase(projectPath string) (*RebaseState, error) {
+	repo, err := openRebase(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, editing, err := readGitFile(repo, rebaseAmendFile); err != nil {
+		return nil, err
+	} else if editing {
+		if err := amendHead(repo, files); err != nil {
+			return nil, err
+		}
+	} else if _, stopped, err := readGitFile(repo, rebaseStoppedFile); err != nil {
+		return nil, err
+	} else if stopped {
+		done, err := readRebaseSteps(repo, rebaseDoneFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(done) == 0 {
+			return nil, errors.New("the rebase state is corrupted, abort it")
+		}
+		step := done[len(done)-1]
+		commit, err := repo.CommitObject(plumbing.NewHash(step.Hash))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		if err := commitRebaseStep(repo, step, commit, files); err != nil {
+			return nil, err
+		}
+		if step.Action == RebaseEdit {
+			return pauseRebaseForEdit(repo)
+		}
+	}
+
+	if err := removeGitFiles(repo, rebaseStoppedFile, rebaseAmendFile); err != nil {
+		return nil, err
+	}
+	return runRebase(repo)
+}
+
+// SkipRebaseStep drops the step a rebase paused on, discarding its changes, and resumes the rebase
+func (s *GitService) SkipRebaseStep(projectPath string) (*RebaseState, error) {
+	repo, err := openRebase(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := resetIndexChanges(repo); err != nil {
+		return nil, err
+	}
+	if err := removeGitFiles(repo, rebaseStoppedFile, rebaseAmendFile); err != nil {
+		return nil, err
+	}
+	return runRebase(repo)
+}
+
+// AbortRebase cancels a rebase, putting the branch and the working tree back as they were before it
+func (s *GitService) AbortRebase(projectPath string) error {
+	repo, err := openRebase(projectPath)
+	if err != nil {
+		return err
+	}
+	headName, _, err := readGitFile(repo, rebaseHeadNameFile)
+	if err != nil {
+		return err
+	}
+	origHead, _, err := readGitFile(repo, rebaseOrigHeadFile)
+	if err != nil {
+		return err
+	}
+
+	if err := restoreRebaseHead(repo, strings.TrimSpace(headName), plumbing.NewHash(strings.TrimSpace(origHead))); err != nil {
+		return err
+	}
+	if err := resetIndexChanges(repo); err != nil {
+		return err
+	}
+	return removeGitDir(repo, rebaseDir)
+}
+
+// GetRebaseState returns the rebase in progress, InProgress is false if there is none
+func (s *GitService) GetRebaseState(projectPath string) (*RebaseState, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, found, err := readGitFile(repo, rebaseHeadNameFile); err != nil {
+		return nil, err
+	} else if !found {
+		return &RebaseState{Done: []RebaseStep{}, Todo: []RebaseStep{}, Conflicts: []ConflictFile{}}, nil
+	}
+	return rebaseState(repo, "")
+}
+
+// openRebase opens a repository with a rebase in progress
+func openRebase(projectPath string) (*git.Repository, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if _, found, err := readGitFile(repo, rebaseHeadNameFile); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, errors.New("no rebase in progress")
+	}
+	return repo, nil
+}
+
+// runRebase runs the remaining steps of the rebase until the end or a pause
+func runRebase(repo *git.Repository) (*RebaseState, error) {
+	if paused, err := recoverRebaseStep(repo); err != nil {
+		return nil, err
+	} else if paused {
+		return pauseRebaseForEdit(repo)
+	}
+
+	for {
+		todo, err := readRebaseSteps(repo, rebaseTodoFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(todo) == 0 {
+			return finishRebase(repo)
+		}
+
+		step := todo[0]
+		done, err := readRebaseSteps(repo, rebaseDoneFile)
+		if err != nil {
+			return nil, err
+		}
+		head, err := repo.Head()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+
+		// The step moves to done before it runs, like git. It stays marked as running until it is
+		// committed, so an editor exit in between replays it instead of losing it.
+		if step.Action != RebaseDrop {
+			if err := writeGitFile(repo, rebaseRunningFile, head.Hash().String()+" "+step.Hash+"\n"); err != nil {
+				return nil, err
+			}
+		}
+		if err := writeRebaseSteps(repo, append(done, step), todo[1:]); err != nil {
+			return nil, err
+		}
+		if step.Action == RebaseDrop {
+			continue
+		}
+
+		commit, err := repo.CommitObject(plumbing.NewHash(step.Hash))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		ours, err := stagedFiles(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		// A commit already based on HEAD is kept as is
+		if (step.Action == RebasePick || step.Action == RebaseEdit) && len(commit.ParentHashes) == 1 && commit.ParentHashes[0] == head.Hash() {
+			theirs, err := commitFiles(repo, commit.Hash)
+			if err != nil {
+				return nil, err
+			}
+			if err := applyTreeMerge(repo, ours, &treeMerge{files: theirs, conflicts: map[string]*mergeConflict{}}, true); err != nil {
+				return nil, err
+			}
+			if err := updateHead(repo, head, commit.Hash); err != nil {
+				return nil, err
+			}
+		} else {
+			merge, err := mergePick(repo, commit, false, ours)
+			if err != nil {
+				return nil, err
+			}
+			if err := applyTreeMerge(repo, ours, merge, true); err != nil {
+				return nil, err
+			}
+			if len(merge.conflicts) > 0 {
+				if err := writeGitFile(repo, rebaseStoppedFile, commit.Hash.String()+"\n"); err != nil {
+					return nil, err
+				}
+				// From here on ContinueRebase commits the step once the conflicts are resolved
+				if err := removeGitFiles(repo, rebaseRunningFile); err != nil {
+					return nil, err
+				}
+				return rebaseState(repo, RebaseConflicted)
+			}
+			if err := commitRebaseStep(repo, step, commit, merge.files); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := removeGitFiles(repo, rebaseRunningFile); err != nil {
+			return nil, err
+		}
+		if step.Action == RebaseEdit {
+			return pauseRebaseForEdit(repo)
+		}
+	}
+}
+
+// recoverRebaseStep handles a step interrupted by an editor exit. A step that didn't get to commit
+// goes back to the todo list with its partial changes discarded, so it is replayed from scratch.
+// paused is true for a committed edit step, which still has to pause.
+func recoverRebaseStep(repo *git.Repository) (paused bool, err error) {
+	running, found, err := readGitFile(repo, rebaseRunningFile)
+	if err != nil || !found {
+		return false, err
+	}
+	stepHead, stepHash, _ := strings.Cut(strings.TrimSpace(running), " ")
+
+	done, err := readRebaseSteps(repo, rebaseDoneFile)
+	if err != nil {
+		return false, err
+	}
+	// The exit may also have come before the step moved to done
+	if len(done) > 0 && done[len(done)-1].Hash == stepHash {
+		step := done[len(done)-1]
+		head, err := repo.Head()
+		if err != nil {
+			return false, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+
+		if head.Hash().String() != stepHead {
+			paused = step.Action == RebaseEdit
+		} else {
+			changes, err := rebaseStepChanges(repo, step)
+			if err != nil {
+				return false, err
+			}
+			if err := restoreHeadFiles(repo, changes); err != nil {
+				return false, err
+			}
+			todo, err := readRebaseSteps(repo, rebaseTodoFile)
+			if err != nil {
+				return false, err
+			}
+			if err := writeRebaseSteps(repo, done[:len(done)-1], append([]RebaseStep{step}, todo...)); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return paused, removeGitFiles(repo, rebaseRunningFile)
+}
+
+// rebaseStepChanges returns the paths an interrupted step may have left changed: every local change,
+// since the rebase started from a clean worktree, and the files of the step, which may be written
+// without being staged yet. The untracked file check made sure none of them was someone else's.
+func rebaseStepChanges(repo *git.Repository, step RebaseStep) ([]string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	changes, err := localChanges(worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.CommitObject(plumbing.NewHash(step.Hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	files, err := commitFiles(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	parentFiles := map[string]treeFile{}
+	if commit.NumParents() > 0 {
+		if parentFiles, err = commitFiles(repo, commit.ParentHashes[0]); err != nil {
+			return nil, err
+		}
+	}
+	return append(changes, changedFiles(parentFiles, files)...), nil
+}
+
+// pauseRebaseForEdit pauses the rebase after an edit step so the user can amend the commit
+func pauseRebaseForEdit(repo *git.Repository) (*RebaseState, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if err := removeGitFiles(repo, rebaseStoppedFile); err != nil {
+		return nil, err
+	}
+	if err := writeGitFile(repo, rebaseAmendFile, head.Hash().String()+"\n"); err != nil {
+		return nil, err
+	}
+	return rebaseState(repo, RebaseEditing)
+}
+
+// commitRebaseStep commits the replayed files of a step. Squash and fixup steps amend the previous commit,
+// other steps keep the author of the original commit. A step left without changes creates no commit.
+func commitRebaseStep(repo *git.Repository, step RebaseStep, picked *object.Commit, files map[string]treeFile) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := writeTree(repo, files)
+	if err != nil {
+		return err
+	}
+	sig, err := gitSignature(repo)
+	if err != nil {
+		return err
+	}
+	message, _, err := readGitFile(repo, rebaseMessagesDir+"/"+picked.Hash.String())
+	if err != nil {
+		return err
+	}
+
+	author := &picked.Author
+	parents := []plumbing.Hash{head.Hash()}
+	switch step.Action {
+	case RebaseSquash, RebaseFixup:
+		author = &headCommit.Author
+		parents = headCommit.ParentHashes
+		if message == "" {
+			message = headCommit.Message
+			if step.Action == RebaseSquash {
+				message = strings.TrimSpace(headCommit.Message) + "\n\n" + picked.Message
+			}
+		}
+	default:
+		if tree == headCommit.TreeHash {
+			return nil
+		}
+		if message == "" {
+			message = picked.Message
+		}
+	}
+
+	hash, err := writeCommit(repo, tree, parents, strings.TrimSpace(message)+"\n", author, sig)
+	if err != nil {
+		return err
+	}
+	return updateHead(repo, head, hash)
+}
+
+// amendHead replaces HEAD with a commit of the given files, keeping its message, author and parents
+func amendHead(repo *git.Repository, files map[string]treeFile) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := writeTree(repo, files)
+	if err != nil {
+		return err
+	}
+	if tree == headCommit.TreeHash {
+		return nil
+	}
+	sig, err := gitSignature(repo)
+	if err != nil {
+		return err
+	}
+	hash, err := writeCommit(repo, tree, headCommit.ParentHashes, headCommit.Message, &headCommit.Author, sig)
+	if err != nil {
+		return err
+	}
+	return updateHead(repo, head, hash)
+}
+
+// finishRebase points the rebased branch at the new commits and removes the rebase state
+func finishRebase(repo *git.Repository) (*RebaseState, error) {
+	headName, _, err := readGitFile(repo, rebaseHeadNameFile)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	state, err := rebaseState(repo, RebaseFinished)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restoreRebaseHead(repo, strings.TrimSpace(headName), head.Hash()); err != nil {
+		return nil, err
+	}
+	if err := removeGitDir(repo, rebaseDir); err != nil {
+		return nil, err
+	}
+	state.InProgress = false
+	state.Current = nil
+	return state, nil
+}
+
+// restoreRebaseHead points HEAD back at the rebased branch, moving the branch to a commit
+func restoreRebaseHead(repo *git.Repository, headName string, hash plumbing.Hash) error {
+	if headName == "detached HEAD" {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, hash)); err != nil {
+			return fmt.Errorf("failed to update HEAD: %w", err)
+		}
+		return nil
+	}
+
+	branch := plumbing.ReferenceName(headName)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+		return fmt.Errorf("failed to update %s: %w", branch.Short(), err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch)); err != nil {
+		return fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return nil
+}
+
+// rebaseState reads the state of the rebase in progress
+func rebaseState(repo *git.Repository, status string) (*RebaseState, error) {
+	state := &RebaseState{InProgress: true, Status: status}
+
+	headName, _, err := readGitFile(repo, rebaseHeadNameFile)
+	if err != nil {
+		return nil, err
+	}
+	if name := plumbing.ReferenceName(strings.TrimSpace(headName)); name.IsBranch() {
+		state.Branch = name.Short()
+	}
+	onto, _, err := readGitFile(repo, rebaseOntoFile)
+	if err != nil {
+		return nil, err
+	}
+	state.Onto = strings.TrimSpace(onto)
+
+	if state.Done, err = readRebaseSteps(repo, rebaseDoneFile); err != nil {
+		return nil, err
+	}
+	if state.Todo, err = readRebaseSteps(repo, rebaseTodoFile); err != nil {
+		return nil, err
+	}
+	if state.Conflicts, err = indexConflicts(repo); err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		_, stopped, err := readGitFile(repo, rebaseStoppedFile)
+		if err != nil {
+			return nil, err
+		}
+		_, editing, err := readGitFile(repo, rebaseAmendFile)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case stopped:
+			state.Status = RebaseConflicted
+		case editing:
+			state.Status = RebaseEditing
+		}
+	}
+	if state.Status != RebaseFinished && len(state.Done) > 0 {
+		state.Current = &state.Done[len(state.Done)-1]
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	state.Commit = commitInfo(commit)
+	return state, nil
+}
+
+// validateRebasePlan checks the actions and commits of a plan and fills in the subjects
+func validateRebasePlan(repo *git.Repository, steps []RebaseStep) ([]RebaseStep, error) {
+	valid := make([]RebaseStep, 0, len(steps))
+	picked := false
+	for _, step := range steps {
+		switch step.Action {
+		case RebasePick, RebaseReword, RebaseEdit:
+			picked = true
+		case RebaseSquash, RebaseFixup:
+			if !picked {
+				return nil, fmt.Errorf("cannot %s without a previous commit", step.Action)
+			}
+		case RebaseDrop:
+		default:
+			return nil, fmt.Errorf("unknown rebase action %q", step.Action)
+		}
+
+		commit, err := resolveCommit(repo, step.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(commit.ParentHashes) > 1 {
+			return nil, fmt.Errorf("%s is a merge commit, it can't be rebased", commit.Hash.String()[:7])
+		}
+		step.Hash = commit.Hash.String()
+		step.Subject = commitSubject(commit)
+		if step.Action != RebaseReword && step.Action != RebaseSquash && step.Action != RebaseFixup {
+			step.Message = ""
+		}
+		valid = append(valid, step)
+	}
+	return valid, nil
+}
+
+// readRebaseSteps parses a todo or done file of the rebase, "<action> <hash> <subject>" per line.
+// The new messages of the steps aren't read, only the commits need them.
+func readRebaseSteps(repo *git.Repository, name string) ([]RebaseStep, error) {
+	content, _, err := readGitFile(repo, name)
+	if err != nil {
+		return nil, err
+	}
+
+	short := map[string]string{"p": RebasePick, "r": RebaseReword, "e": RebaseEdit, "s": RebaseSquash, "f": RebaseFixup, "d": RebaseDrop}
+	steps := []RebaseStep{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid rebase step %q", line)
+		}
+		step := RebaseStep{Action: fields[0], Hash: fields[1]}
+		if action, ok := short[step.Action]; ok {
+			step.Action = action
+		}
+		if len(fields) == 3 {
+			step.Subject = fields[2]
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// writeRebaseSteps writes the done and todo files of the rebase, with the progress counters git shows
+func writeRebaseSteps(repo *git.Repository, done, todo []RebaseStep) error {
+	files := map[string]string{
+		rebaseDoneFile:        formatRebaseTodo(done),
+		rebaseTodoFile:        formatRebaseTodo(todo),
+		rebaseDir + "/msgnum": strconv.Itoa(len(done)) + "\n",
+		rebaseDir + "/end":    strconv.Itoa(len(done)+len(todo)) + "\n",
+	}
+	for name, content := range files {
+		if err := writeGitFile(repo, name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatRebaseTodo formats steps like git's rebase todo list
+func formatRebaseTodo(steps []RebaseStep) string {
+	var sb strings.Builder
+	for _, step := range steps {
+		fmt.Fprintf(&sb, "%s %s %s\n", step.Action, step.Hash, step.Subject)
+	}
+	return sb.String()
+}
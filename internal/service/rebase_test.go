@@ -0,0 +1,117 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestRebasePlanIncludesMergedCommits(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("side", "side.txt", "side\n")
+	r.branch("feature", "feature.txt", "feature\n")
+	s := NewGitService()
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout feature: %v", err)
+	}
+	if _, err := s.MergeBranch(r.dir, "side", MergeOptions{NoFastForward: true}); err != nil {
+		t.Fatalf("failed to merge side: %v", err)
+	}
+
+	// master moves on so the plan has something to rebase onto
+	if err := s.CheckoutBranch(r.dir, "master", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout master: %v", err)
+	}
+	r.write("master.txt", "master\n")
+	r.add("master.txt")
+	r.commit("master commit")
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout feature: %v", err)
+	}
+
+	plan, err := s.GetRebasePlan(r.dir, "master")
+	if err != nil {
+		t.Fatalf("failed to get plan: %v", err)
+	}
+	var subjects []string
+	for _, step := range plan.Steps {
+		subjects = append(subjects, step.Subject)
+	}
+	if len(subjects) != 2 || subjects[0] != "change feature.txt" || subjects[1] != "change side.txt" {
+		t.Fatalf("plan = %q, want the feature and side commits without the merge", subjects)
+	}
+
+	state, err := s.StartRebase(r.dir, *plan)
+	if err != nil {
+		t.Fatalf("failed to rebase: %v", err)
+	}
+	if state.Status != RebaseFinished {
+		t.Fatalf("rebase status = %s, want %s", state.Status, RebaseFinished)
+	}
+	for _, file := range []string{"side.txt", "feature.txt", "master.txt"} {
+		if _, ok := r.read(file); !ok {
+			t.Errorf("%s is missing after the rebase", file)
+		}
+	}
+}
+
+func TestContinueRebaseReplaysInterruptedStep(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.branch("feature", "feature.txt", "feature\n")
+	s := NewGitService()
+	if err := s.CheckoutBranch(r.dir, "feature", CheckoutOptions{}); err != nil {
+		t.Fatalf("failed to checkout feature: %v", err)
+	}
+	r.write("second.txt", "second\n")
+	r.add("second.txt")
+	r.commit("add second.txt")
+
+	plan, err := s.GetRebasePlan(r.dir, "master")
+	if err != nil {
+		t.Fatalf("failed to get plan: %v", err)
+	}
+	plan.Steps[0].Action = RebaseEdit
+	state, err := s.StartRebase(r.dir, *plan)
+	if err != nil {
+		t.Fatalf("failed to rebase: %v", err)
+	}
+	if state.Status != RebaseEditing {
+		t.Fatalf("rebase status = %s, want %s", state.Status, RebaseEditing)
+	}
+
+	// The editor exits while the second step is applied but not committed yet
+	repo, err := git.PlainOpen(r.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := state.Todo[0]
+	if err := removeGitFiles(repo, rebaseAmendFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeGitFile(repo, rebaseRunningFile, head.Hash().String()+" "+step.Hash+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRebaseSteps(repo, append(state.Done, step), nil); err != nil {
+		t.Fatal(err)
+	}
+	r.write("second.txt", "sec")
+
+	state, err = s.ContinueRebase(r.dir)
+	if err != nil {
+		t.Fatalf("failed to continue: %v", err)
+	}
+	if state.Status != RebaseFinished {
+		t.Fatalf("rebase status = %s, want %s", state.Status, RebaseFinished)
+	}
+	if content, _ := r.read("second.txt"); content != "second\n" {
+		t.Errorf("second.txt = %q, want the replayed content", content)
+	}
+	if state.Commit == nil || strings.TrimSpace(state.Commit.Message) != "add second.txt" {
+		t.Errorf("HEAD = %+v, want the replayed commit", state.Commit)
+	}
+}
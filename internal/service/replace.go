@@ -0,0 +1,288 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// ReplaceOptions contains options for a project-wide search and replace
+type ReplaceOptions struct {
+	Search      SearchOptions `json:"search"`      // Which matches to replace
+	Replacement string        `json:"replacement"` // Replacement text, may reference capture groups ($1, ${name}) in regex mode
+}
+
+// ReplaceEdit is a single substitution inside a file
+type ReplaceEdit struct {
+	Index       int    `json:"index"`       // Position of the edit in its file, used to exclude it
+	Line        int    `json:"line"`        // 1-based line number
+	Column      int    `json:"column"`      // 1-based column of the match start, in characters
+	Length      int    `json:"length"`      // Length of the match, in characters
+	Original    string `json:"original"`    // Matched text
+	Replacement string `json:"replacement"` // Text the match is replaced with
+	Preview     string `json:"preview"`     // Whole line after the substitution
+}
+
+// FileReplacePreview lists the substitutions planned for a file
+type FileReplacePreview struct {
+	Path    string        `json:"path"`
+	Version string        `json:"version"` // Version of the file the preview was computed from, like FileContent.Version
+	Edits   []ReplaceEdit `json:"edits"`
+}
+
+// ReplacePreview contains every planned substitution of a search and replace
+type ReplacePreview struct {
+	Files []FileReplacePreview `json:"files"`
+	Edits int                  `json:"edits"`
+}
+
+// ReplaceFileSelection is the part of a preview the caller wants to apply to a file
+type ReplaceFileSelection struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`  // Version from the preview, the file must still match it
+	Excluded []int  `json:"excluded"` // Indexes of edits that must not be applied
+}
+
+// ReplaceResult describes an applied search and replace
+type ReplaceResult struct {
+	FilesChanged int `json:"filesChanged"`
+	Edits        int `json:"edits"`
+}
+
+// contentHash returns a stable hash of file content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// PreviewReplace computes every substitution a search and replace would make, without writing anything
+func (s *FileService) PreviewReplace(ctx context.Context, dirPath string, opts ReplaceOptions) (*ReplacePreview, error) {
+	re, err := compileSearchPattern(opts.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	_, err = s.SearchContent(ctx, dirPath, opts.Search, func(batch SearchBatch) {
+		for _, result := range batch.Results {
+			paths = append(paths, result.Path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	preview := &ReplacePreview{Files: []FileReplacePreview{}}
+	for _, path := range paths {
+		content, version, err := readFileVersion(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		edits := planReplaceEdits(re, content, opts)
+		if len(edits) == 0 {
+			continue
+		}
+
+		preview.Files = append(preview.Files, FileReplacePreview{
+			Path:    path,
+			Version: version,
+			Edits:   previewReplaceEdits(content, edits),
+		})
+		preview.Edits += len(edits)
+	}
+
+	return preview, nil
+}
+
+// ApplyReplace applies a previewed search and replace to the selected files as one batch.
+// Nothing is written if any file changed since the preview, a FileConflictError is returned instead.
+func (s *FileService) ApplyReplace(opts ReplaceOptions, files []ReplaceFileSelection) (*ReplaceResult, error) {
+	re, err := compileSearchPattern(opts.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := newFileTransaction(s.journalDir())
+	result := &ReplaceResult{}
+	var history []replaceHistory
+
+	for _, file := range files {
+		// Write through symlinks instead of replacing them
+		path, err := filepath.EvalSymlinks(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", file.Path, err)
+		}
+
+		// Read before checking, a change in between then fails the check instead of being edited unseen
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+		if err := checkFileVersion(path, file.Version); err != nil {
+			var conflict *FileConflictError
+			if errors.As(err, &conflict) {
+				return nil, &FileConflictError{Path: file.Path}
+			}
+			return nil, err
+		}
+
+		excluded := make(map[int]bool, len(file.Excluded))
+		for _, index := range file.Excluded {
+			excluded[index] = true
+		}
+
+		var selected []plannedEdit
+		for _, edit := range planReplaceEdits(re, content, opts) {
+			if !excluded[edit.index] {
+				selected = append(selected, edit)
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+
+		// The transaction checks the content again when it backs it up
+		replaced := applyPlannedEdits(content, selected)
+		txn.Write(path, replaced, contentHash(content))
+		history = append(history, replaceHistory{path: file.Path, original: content, replaced: replaced})
+		result.FilesChanged++
+		result.Edits += len(selected)
+	}
+
+	if err := txn.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Keep both versions in the local history, like a save does
+	for _, h := range history {
+		s.snapshotFile(h.path, h.original)
+		s.snapshotFile(h.path, h.replaced)
+		s.invalidateSavedFile(h.path)
+	}
+
+	return result, nil
+}
+
+// replaceHistory is the content of a file before and after a replace
+type replaceHistory struct {
+	path     string
+	original []byte
+	replaced []byte
+}
+
+// readFileVersion reads a file along with its version
+func readFileVersion(path string) ([]byte, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, fileVersion(info, content), nil
+}
+
+// journalDir returns the directory holding rollback journals of multi-file writes
+func (s *FileService) journalDir() string {
+	return filepath.Join(s.dataDir, "journal")
+}
+
+// plannedEdit is a substitution expressed in byte offsets of the original content
+type plannedEdit struct {
+	index       int
+	start, end  int
+	replacement []byte
+}
+
+// planReplaceEdits finds every match in content and expands its replacement.
+// Lines are matched one by one like findMatches does, so a match never spans a line break.
+func planReplaceEdits(re *regexp.Regexp, content []byte, opts ReplaceOptions) []plannedEdit {
+	var edits []plannedEdit
+	for offset := 0; offset < len(content); {
+		end, next := len(content), len(content)
+		if i := bytes.IndexByte(content[offset:], '\n'); i >= 0 {
+			end, next = offset+i, offset+i+1
+		}
+		line := bytes.TrimSuffix(content[offset:end], []byte("\r"))
+
+		for _, loc := range re.FindAllSubmatchIndex(line, -1) {
+			// Empty matches are skipped by the search as well
+			if loc[0] == loc[1] {
+				continue
+			}
+
+			replacement := []byte(opts.Replacement)
+			if opts.Search.IsRegex {
+				replacement = re.Expand(nil, replacement, line, loc)
+			}
+
+			edits = append(edits, plannedEdit{
+				index:       len(edits),
+				start:       offset + loc[0],
+				end:         offset + loc[1],
+				replacement: replacement,
+			})
+		}
+		offset = next
+	}
+	return edits
+}
+
+// applyPlannedEdits returns content with the given edits applied
+func applyPlannedEdits(content []byte, edits []plannedEdit) []byte {
+	var out bytes.Buffer
+	last := 0
+	for _, edit := range edits {
+		out.Write(content[last:edit.start])
+		out.Write(edit.replacement)
+		last = edit.end
+	}
+	out.Write(content[last:])
+	return out.Bytes()
+}
+
+// previewReplaceEdits converts planned edits into line based previews
+func previewReplaceEdits(content []byte, edits []plannedEdit) []ReplaceEdit {
+	result := make([]ReplaceEdit, 0, len(edits))
+	for _, edit := range edits {
+		lineStart := bytes.LastIndexByte(content[:edit.start], '\n') + 1
+		lineEnd := len(content)
+		if i := bytes.IndexByte(content[edit.end:], '\n'); i >= 0 {
+			lineEnd = edit.end + i
+		}
+
+		var preview bytes.Buffer
+		preview.Write(content[lineStart:edit.start])
+		preview.Write(edit.replacement)
+		preview.Write(content[edit.end:lineEnd])
+
+		result = append(result, ReplaceEdit{
+			Index:       edit.index,
+			Line:        bytes.Count(content[:edit.start], []byte("\n")) + 1,
+			Column:      utf8.RuneCount(content[lineStart:edit.start]) + 1,
+			Length:      utf8.RuneCount(content[edit.start:edit.end]),
+			Original:    string(content[edit.start:edit.end]),
+			Replacement: string(edit.replacement),
+			Preview:     string(bytes.TrimRight(preview.Bytes(), "\r")),
+		})
+	}
+	return result
+}
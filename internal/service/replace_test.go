@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// previewTestReplace previews replacing old with new in dir and selects every planned edit
+func previewTestReplace(t *testing.T, s *FileService, dir, old, new string) (ReplaceOptions, []ReplaceFileSelection) {
+	t.Helper()
+	opts := ReplaceOptions{Search: SearchOptions{Query: old, CaseSensitive: true}, Replacement: new}
+	preview, err := s.PreviewReplace(context.Background(), dir, opts)
+	if err != nil {
+		t.Fatalf("failed to preview: %v", err)
+	}
+	var files []ReplaceFileSelection
+	for _, file := range preview.Files {
+		files = append(files, ReplaceFileSelection{Path: file.Path, Version: file.Version})
+	}
+	return opts, files
+}
+
+func TestApplyReplace(t *testing.T) {
+	s := newTestFiles(t)
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	writeTestFile(t, a, "old one\nold two\n")
+	writeTestFile(t, b, "keep old\n")
+
+	opts, files := previewTestReplace(t, s, dir, "old", "new")
+	if len(files) != 2 {
+		t.Fatalf("preview has %d files, want 2", len(files))
+	}
+
+	// The preview hands out the same version as opening the file in the editor
+	file, err := s.GetFileContent(a)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	for _, f := range files {
+		if f.Path == a && f.Version != file.Version {
+			t.Errorf("preview version = %q, want the editor version %q", f.Version, file.Version)
+		}
+	}
+
+	// Touching a file without editing it keeps the preview valid
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(b, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.ApplyReplace(opts, files)
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if result.FilesChanged != 2 || result.Edits != 3 {
+		t.Errorf("result = %+v, want 2 files and 3 edits", result)
+	}
+	checkTestFiles(t, dir, map[string]string{"a.txt": "new one\nnew two\n", "b.txt": "keep new\n"})
+
+	// Both the original and the replaced content are in the local history
+	snapshots, err := s.ListFileHistory(a)
+	if err != nil {
+		t.Fatalf("failed to list history: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("history of a replaced file has %d snapshots, want 2", len(snapshots))
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Hash != contentHash([]byte("old one\nold two\n")) && snapshot.Hash != contentHash([]byte("new one\nnew two\n")) {
+			t.Errorf("unexpected snapshot %+v", snapshot)
+		}
+	}
+}
+
+func TestApplyReplaceRejectsChangedFile(t *testing.T) {
+	s := newTestFiles(t)
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.txt")
+	writeTestFile(t, a, "old\n")
+	writeTestFile(t, b, "old\n")
+
+	opts, files := previewTestReplace(t, s, dir, "old", "new")
+	writeTestFile(t, b, "old, edited outside\n")
+
+	if _, err := s.ApplyReplace(opts, files); !errors.As(err, new(*FileConflictError)) {
+		t.Fatalf("applying over an external edit returned %v, want a conflict", err)
+	}
+	// Nothing is written when any file conflicts
+	checkTestFiles(t, dir, map[string]string{"a.txt": "old\n", "b.txt": "old, edited outside\n"})
+	if snapshots, err := s.ListFileHistory(a); err != nil || len(snapshots) != 0 {
+		t.Errorf("history after a rejected replace = %v, %v, want it empty", snapshots, err)
+	}
+}
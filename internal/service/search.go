@@ -0,0 +1,475 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// maxSearchFileSize is the largest file the content search will read
+	maxSearchFileSize = 10 << 20
+	// maxPreviewLength is the maximum number of characters in a match preview
+	maxPreviewLength = 200
+	// searchBatchSize is the number of matches collected before a batch is streamed
+	searchBatchSize = 50
+	// binaryCheckSize is how many leading bytes are checked for NUL when detecting binary files
+	binaryCheckSize = 8000
+)
+
+// SearchOptions contains options for a content search
+type SearchOptions struct {
+	ID            string   `json:"id"`            // Caller provided ID, echoed in streamed batches
+	Query         string   `json:"query"`         // Text or regular expression to search for
+	IsRegex       bool     `json:"isRegex"`       // Treat the query as a regular expression
+	CaseSensitive bool     `json:"caseSensitive"` // Match case exactly
+	WholeWord     bool     `json:"wholeWord"`     // Only match whole words
+	Include       []string `json:"include"`       // Glob patterns of files to search, all files if empty
+	Exclude       []string `json:"exclude"`       // Glob patterns of files to skip
+	MaxResults    int      `json:"maxResults"`    // Stop after this many matches, unlimited if zero
+}
+
+// SearchMatch represents a single match inside a file
+type SearchMatch struct {
+	Line          int    `json:"line"`          // 1-based line number
+	Column        int    `json:"column"`        // 1-based column of the match start, in characters
+	Length        int    `json:"length"`        // Length of the match, in characters
+	Preview       string `json:"preview"`       // Line content around the match
+	PreviewColumn int    `json:"previewColumn"` // 0-based offset of the match inside the preview, in characters
+}
+
+// FileSearchResult contains every match found in a file
+type FileSearchResult struct {
+	Path    string        `json:"path"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// SearchBatch is a group of results streamed while a search is running
+type SearchBatch struct {
+	ID      string             `json:"id"`
+	Results []FileSearchResult `json:"results"`
+}
+
+// SearchSummary describes a finished content search
+type SearchSummary struct {
+	ID           string `json:"id"`
+	FilesMatched int    `json:"filesMatched"`
+	Matches      int    `json:"matches"`
+	Truncated    bool   `json:"truncated"` // MaxResults was reached before the search finished
+}
+
+// compileSearchPattern builds the regular expression used to find matches
+func compileSearchPattern(opts SearchOptions) (*regexp.Regexp, error) {
+	if opts.Query == "" {
+		return nil, errors.New("search query is empty")
+	}
+
+	pattern := opts.Query
+	if !opts.IsRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	// Anchors match at line boundaries, the same way per-line search and whole-file replace see them
+	pattern = "(?m)" + pattern
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return re, nil
+}
+
+// globFilter matches relative paths against include and exclude glob patterns
+type globFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// newGlobFilter compiles include and exclude glob patterns
+func newGlobFilter(include, exclude []string) (*globFilter, error) {
+	f := &globFilter{}
+	for _, pattern := range include {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range exclude {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	return f, nil
+}
+
+// matches reports whether a slash separated relative path passes the filter
+func (f *globFilter) matches(relPath string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(relPath) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesDir reports whether a whole directory can be skipped
+func (f *globFilter) excludesDir(relPath string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob converts a glob pattern into a regular expression.
+// "**" matches any number of directories and patterns without a slash match at any depth.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "./")
+	if pattern == "" {
+		return nil, errors.New("empty glob pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		sb.WriteString("(?:.*/)?")
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				sb.WriteString(`\{`)
+				continue
+			}
+			alternatives := strings.Split(pattern[i+1:i+end], ",")
+			for j, alt := range alternatives {
+				alternatives[j] = regexp.QuoteMeta(alt)
+			}
+			sb.WriteString("(?:" + strings.Join(alternatives, "|") + ")")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	// A pattern naming a directory also matches everything inside it
+	sb.WriteString("(?:/.*)?$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// isBinaryContent checks for a NUL byte at the start of the content, like git does
+func isBinaryContent(content []byte) bool {
+	if len(content) > binaryCheckSize {
+		content = content[:binaryCheckSize]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// findMatches returns every match of re in content
+func findMatches(re *regexp.Regexp, content []byte) []SearchMatch {
+	var matches []SearchMatch
+
+	lineNum := 0
+	for len(content) > 0 {
+		lineNum++
+		var line []byte
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			line, content = content[:i], content[i+1:]
+		} else {
+			line, content = content, nil
+		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		for _, loc := range re.FindAllIndex(line, -1) {
+			// Skip empty matches, they can't be highlighted or replaced in a useful way
+			if loc[0] == loc[1] {
+				continue
+			}
+			matches = append(matches, newSearchMatch(line, lineNum, loc[0], loc[1]))
+		}
+	}
+
+	return matches
+}
+
+// newSearchMatch builds a match with a preview centered around the matched bytes
+func newSearchMatch(line []byte, lineNum, start, end int) SearchMatch {
+	column := utf8.RuneCount(line[:start])
+	length := utf8.RuneCount(line[start:end])
+
+	// Keep some context before the match and cut the rest of the line if it is too long
+	previewStart := 0
+	if column > maxPreviewLength/4 {
+		previewStart = column - maxPreviewLength/4
+	}
+	runes := []rune(string(line))
+	previewEnd := previewStart + maxPreviewLength
+	if previewEnd > len(runes) {
+		previewEnd = len(runes)
+	}
+
+	return SearchMatch{
+		Line:          lineNum,
+		Column:        column + 1,
+		Length:        length,
+		Preview:       string(runes[previewStart:previewEnd]),
+		PreviewColumn: column - previewStart,
+	}
+}
+
+// SearchContent searches the contents of every file in a directory.
+// Results are streamed to onBatch while the search runs; the returned summary covers the whole search.
+func (s *FileService) SearchContent(ctx context.Context, dirPath string, opts SearchOptions, onBatch func(SearchBatch)) (*SearchSummary, error) {
+	re, err := compileSearchPattern(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newGlobFilter(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make(chan string, 128)
+	results := make(chan FileSearchResult, 16)
+
+	// Read and match files in parallel
+	var workers sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				if result, ok := searchFile(re, path); ok {
+					select {
+					case results <- result:
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+
+	// Walk the directory tree
+	walkErr := make(chan error, 1)
+	go func() {
+		defer close(paths)
+		walkErr <- filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable entries are skipped instead of failing the search
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if path == dirPath {
+				return nil
+			}
+
+			if s.isIgnored(dirPath, path) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			relPath, err := filepath.Rel(dirPath, path)
+			if err != nil {
+				return nil
+			}
+			relPath = filepath.ToSlash(relPath)
+
+			if d.IsDir() {
+				if filter.excludesDir(relPath) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() || !filter.matches(relPath) {
+				return nil
+			}
+
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			return nil
+		})
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	summary := &SearchSummary{ID: opts.ID}
+	var batch []FileSearchResult
+	batchMatches := 0
+
+	flushBatch := func() {
+		if len(batch) > 0 && onBatch != nil {
+			onBatch(SearchBatch{ID: opts.ID, Results: batch})
+		}
+		batch = nil
+		batchMatches = 0
+	}
+
+	for result := range results {
+		if summary.Truncated {
+			continue
+		}
+
+		// Reaching MaxResults exactly isn't truncation, only a further match is
+		if opts.MaxResults > 0 && summary.Matches+len(result.Matches) > opts.MaxResults {
+			result.Matches = result.Matches[:opts.MaxResults-summary.Matches]
+			summary.Truncated = true
+			cancel()
+			if len(result.Matches) == 0 {
+				continue
+			}
+		}
+
+		summary.FilesMatched++
+		summary.Matches += len(result.Matches)
+		batch = append(batch, result)
+		batchMatches += len(result.Matches)
+
+		if batchMatches >= searchBatchSize {
+			flushBatch()
+		}
+	}
+	flushBatch()
+
+	// The parent context being cancelled means a newer search replaced this one
+	if err := <-walkErr; err != nil && !errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil && !summary.Truncated {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// SearchWorkspaceContent searches the contents of the files in every workspace root.
+// MaxResults applies to the matches of all roots together.
+func (s *FileService) SearchWorkspaceContent(ctx context.Context, roots []WorkspaceRoot, opts SearchOptions, onBatch func(SearchBatch)) (*SearchSummary, error) {
+	summary := &SearchSummary{ID: opts.ID}
+	maxResults := opts.MaxResults
+
+	for _, root := range roots {
+		full := maxResults > 0 && summary.Matches >= maxResults
+		batches := onBatch
+		if full {
+			// The remaining roots only tell whether a further match exists
+			opts.MaxResults = 1
+			batches = nil
+		} else if maxResults > 0 {
+			opts.MaxResults = maxResults - summary.Matches
+		}
+
+		rootSummary, err := s.SearchContent(ctx, root.Path, opts, batches)
+		if err != nil {
+			return nil, err
+		}
+
+		if full {
+			if rootSummary.Matches > 0 {
+				summary.Truncated = true
+				break
+			}
+			continue
+		}
+
+		summary.FilesMatched += rootSummary.FilesMatched
+		summary.Matches += rootSummary.Matches
+		if rootSummary.Truncated {
+			summary.Truncated = true
+			break
+		}
+	}
+
+	return summary, nil
+}
+
+// searchFile reads a file and collects its matches, skipping binary and oversized files
+func searchFile(re *regexp.Regexp, path string) (FileSearchResult, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxSearchFileSize {
+		return FileSearchResult{}, false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || isBinaryContent(content) {
+		return FileSearchResult{}, false
+	}
+
+	matches := findMatches(re, content)
+	if len(matches) == 0 {
+		return FileSearchResult{}, false
+	}
+
+	return FileSearchResult{Path: path, Matches: matches}, true
+}
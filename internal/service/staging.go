@@ -0,0 +1,351 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DiffSelection selects changes of a file diff returned by GetFileDiff or GetFileDiffWithOptions
+type DiffSelection struct {
+	Hash    string          `json:"hash"`    // Hash of the diff the selection was made on, rejected if the file changed since
+	Options DiffOptions     `json:"options"` // Options the diff was computed with, hunk indexes depend on them
+	Hunks   []int           `json:"hunks"`   // Whole hunks, by index
+	Lines   []DiffLineRange `json:"lines"`   // Lines of hunks, context lines are ignored
+}
+
+// DiffLineRange is a range of lines inside a hunk
+type DiffLineRange struct {
+	Hunk  int `json:"hunk"`  // Index of the hunk
+	Start int `json:"start"` // Index of the first line in the hunk
+	End   int `json:"end"`   // Index after the last line
+}
+
+// diffPlan is an edit script with the changes to apply
+type diffPlan struct {
+	oldText  diffText
+	newText  diffText
+	ops      []diffOp
+	selected []bool
+}
+
+// StageLines adds the selected changes of the unstaged diff of a file to the index
+func (s *GitService) StageLines(projectPath string, file string, sel DiffSelection) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	oldContent, newContent, err := s.diffSides(repo, projectPath, file, false)
+	if err != nil {
+		return err
+	}
+	plan, err := planSelection(oldContent, newContent, sel)
+	if err != nil {
+		return err
+	}
+
+	return writeIndexFile(repo, file, plan.apply(false), s.fileMode(repo, projectPath, file))
+}
+
+// UnstageLines removes the selected changes of the staged diff of a file from the index, the working copy is kept
+func (s *GitService) UnstageLines(projectPath string, file string, sel DiffSelection) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	oldContent, newContent, err := s.diffSides(repo, projectPath, file, true)
+	if err != nil {
+		return err
+	}
+	plan, err := planSelection(oldContent, newContent, sel)
+	if err != nil {
+		return err
+	}
+
+	return writeIndexFile(repo, file, plan.apply(true), s.fileMode(repo, projectPath, file))
+}
+
+// DiscardLines reverts the selected changes of the unstaged diff of a file in the working copy
+func (s *GitService) DiscardLines(projectPath string, file string, sel DiffSelection) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	oldContent, newContent, err := s.diffSides(repo, projectPath, file, false)
+	if err != nil {
+		return err
+	}
+	plan, err := planSelection(oldContent, newContent, sel)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(projectPath, file), []byte(plan.apply(true))); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// diffSides returns the contents GetFileDiff compares for a file.
+// The staged diff goes from HEAD to the index, the unstaged one from the index, or HEAD, to the working copy.
+func (s *GitService) diffSides(repo *git.Repository, projectPath, file string, staged bool) (string, string, error) {
+	headContent, _, inHead, err := headFile(repo, file)
+	if err != nil {
+		return "", "", err
+	}
+	indexContent, inIndex, err := stagedFile(repo, file)
+	if err != nil {
+		return "", "", err
+	}
+
+	if staged {
+		return headContent, indexContent, nil
+	}
+
+	oldContent := ""
+	if inIndex {
+		oldContent = indexContent
+	} else if inHead {
+		oldContent = headContent
+	}
+
+	newContent, err := s.getFileContents(filepath.Join(projectPath, file))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", "", err
+	}
+	return oldContent, newContent, nil
+}
+
+// fileMode returns the mode a file gets in the index: its current index mode, else the mode
+// of the working copy, else its mode in HEAD
+func (s *GitService) fileMode(repo *git.Repository, projectPath, file string) filemode.FileMode {
+	if idx, err := repo.Storer.Index(); err == nil {
+		if entry, err := idx.Entry(file); err == nil {
+			return entry.Mode
+		}
+	}
+	if info, err := os.Lstat(filepath.Join(projectPath, file)); err == nil {
+		if mode, err := filemode.NewFromOSFileMode(info.Mode()); err == nil {
+			return mode
+		}
+	}
+	if _, mode, found, err := headFile(repo, file); err == nil && found {
+		return mode
+	}
+	return filemode.Regular
+}
+
+// planSelection recomputes the diff between two contents and marks the selected changes.
+// It fails if the diff no longer matches the hash of the selection.
+func planSelection(oldContent, newContent string, sel DiffSelection) (*diffPlan, error) {
+	if isBinaryContent([]byte(oldContent)) || isBinaryContent([]byte(newContent)) {
+		return nil, errors.New("cannot apply part of a binary file")
+	}
+
+	current, err := buildDiff(oldContent, newContent, "", "", sel.Options)
+	if err != nil {
+		return nil, err
+	}
+	if current.Hash != sel.Hash {
+		return nil, errors.New("the diff is out of date, reload it and try again")
+	}
+
+	plan := &diffPlan{
+		oldText: splitDiffText(oldContent),
+		newText: splitDiffText(newContent),
+	}
+	plan.ops = diffLineOps(plan.oldText, plan.newText, sel.Options.Whitespace)
+	plan.selected = make([]bool, len(plan.ops))
+	spans := hunkSpans(plan.ops, diffContext(sel.Options))
+
+	selectOps := func(start, end int) {
+		for i := start; i < end; i++ {
+			plan.selected[i] = plan.ops[i].kind != ' '
+		}
+	}
+	for _, hunk := range sel.Hunks {
+		if hunk < 0 || hunk >= len(spans) {
+			return nil, fmt.Errorf("hunk out of range: %d", hunk)
+		}
+		selectOps(spans[hunk][0], spans[hunk][1])
+	}
+	for _, r := range sel.Lines {
+		if r.Hunk < 0 || r.Hunk >= len(spans) {
+			return nil, fmt.Errorf("hunk out of range: %d", r.Hunk)
+		}
+		span := spans[r.Hunk]
+		if r.Start < 0 || r.Start >= r.End || span[0]+r.End > span[1] {
+			return nil, fmt.Errorf("invalid line range %d-%d in hunk %d", r.Start, r.End, r.Hunk)
+		}
+		selectOps(span[0]+r.Start, span[0]+r.End)
+	}
+
+	for _, selected := range plan.selected {
+		if selected {
+			return plan, nil
+		}
+	}
+	return nil, errors.New("no changes selected")
+}
+
+// apply returns the old content with the selected changes applied.
+// In reverse, it returns the new content with the selected changes reverted.
+func (p *diffPlan) apply(reverse bool) string {
+	var lines []string
+	lastNoEOL := false
+	emit := func(text diffText, i int) {
+		lines = append(lines, text.lines[i])
+		lastNoEOL = text.noEOL && i == len(text.lines)-1
+	}
+
+	for i, op := range p.ops {
+		switch op.kind {
+		case ' ':
+			if reverse {
+				emit(p.newText, op.newIndex)
+			} else {
+				emit(p.oldText, op.oldIndex)
+			}
+		case '-':
+			// A deletion is kept when it isn't applied, or restored when it is reverted
+			if p.selected[i] == reverse {
+				emit(p.oldText, op.oldIndex)
+			}
+		case '+':
+			if p.selected[i] != reverse {
+				emit(p.newText, op.newIndex)
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	content := strings.Join(lines, "\n")
+	if !lastNoEOL {
+		content += "\n"
+	}
+	return content
+}
+
+// headFile returns the content and mode of a file in HEAD, found is false without HEAD or without the file
+func headFile(repo *git.Repository, file string) (content string, mode filemode.FileMode, found bool, err error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return "", 0, false, nil
+	}
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	treeFile, err := tree.File(filepath.ToSlash(file))
+	if err != nil {
+		return "", 0, false, nil
+	}
+	content, err = treeFile.Contents()
+	if err != nil {
+		return "", 0, false, fmt.Errorf("failed to get HEAD file contents: %w", err)
+	}
+	return content, treeFile.Mode, true, nil
+}
+
+// headTreeEntry returns the entry of a file in the tree of HEAD, nil without HEAD or without the file
+func headTreeEntry(repo *git.Repository, file string) (*object.TreeEntry, error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+
+	entry, err := tree.FindEntry(filepath.ToSlash(file))
+	if err == object.ErrEntryNotFound || err == object.ErrDirectoryNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find file in tree: %w", err)
+	}
+	return entry, nil
+}
+
+// stagedFile returns the content of a file in the index, found is false if the file isn't staged
+func stagedFile(repo *git.Repository, file string) (content string, found bool, err error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get index: %w", err)
+	}
+	entry, err := idx.Entry(file)
+	if err == index.ErrEntryNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get index entry: %w", err)
+	}
+
+	data, err := readBlob(repo, entry.Hash)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// writeIndexFile stores content as the staged version of a file, adding it to the index if needed
+func writeIndexFile(repo *git.Repository, file, content string, mode filemode.FileMode) error {
+	hash, err := writeBlob(repo, []byte(content))
+	if err != nil {
+		return err
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+	entry, err := idx.Entry(file)
+	if err == index.ErrEntryNotFound {
+		entry = idx.Add(file)
+		entry.Mode = mode
+	} else if err != nil {
+		return fmt.Errorf("failed to get index entry: %w", err)
+	}
+
+	entry.Hash = hash
+	entry.Size = uint32(len(content))
+	// The staged content no longer matches the working copy, a zero time makes git compare them again
+	entry.ModifiedAt = time.Time{}
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const stagingBase = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+
+// stagingRepo returns a repository whose file.txt has two separate unstaged hunks:
+// line 2 replaced and two lines added after line 9
+func stagingRepo(t *testing.T) *testRepo {
+	t.Helper()
+	r := newTestRepo(t, map[string]string{"file.txt": stagingBase})
+	r.write("file.txt", "1\ntwo\n3\n4\n5\n6\n7\n8\n9\nnine and a half\nnine and three quarters\n10\n")
+	return r
+}
+
+// fileDiff returns the diff of file.txt and checks its number of hunks
+func (r *testRepo) fileDiff(staged bool, hunks int) *FileDiff {
+	r.t.Helper()
+	diff, err := NewGitService().GetFileDiff(r.dir, "file.txt", staged)
+	if err != nil {
+		r.t.Fatalf("failed to get diff: %v", err)
+	}
+	if len(diff.Hunks) != hunks {
+		r.t.Fatalf("diff has %d hunks, want %d:\n%s", len(diff.Hunks), hunks, diff.Content)
+	}
+	return diff
+}
+
+// lineIndex returns the index in a hunk of the line with the given type and content
+func lineIndex(t *testing.T, hunk DiffHunk, lineType, content string) int {
+	t.Helper()
+	for i, line := range hunk.Lines {
+		if line.Type == lineType && line.Content == content {
+			return i
+		}
+	}
+	t.Fatalf("no %s line %q in hunk %s", lineType, content, hunk.Header)
+	return 0
+}
+
+func TestStageHunk(t *testing.T) {
+	r := stagingRepo(t)
+	diff := r.fileDiff(false, 2)
+
+	if err := NewGitService().StageLines(r.dir, "file.txt", DiffSelection{Hash: diff.Hash, Hunks: []int{0}}); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	if content, _ := r.staged("file.txt"); content != strings.Replace(stagingBase, "2\n", "two\n", 1) {
+		t.Errorf("index = %q, want only the first hunk staged", content)
+	}
+	r.fileDiff(false, 1)
+}
+
+func TestStageLines(t *testing.T) {
+	r := stagingRepo(t)
+	diff := r.fileDiff(false, 2)
+
+	// Only the first of the two added lines
+	line := lineIndex(t, diff.Hunks[1], DiffLineAdd, "nine and a half")
+	sel := DiffSelection{Hash: diff.Hash, Lines: []DiffLineRange{{Hunk: 1, Start: line, End: line + 1}}}
+	if err := NewGitService().StageLines(r.dir, "file.txt", sel); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	if content, _ := r.staged("file.txt"); content != strings.Replace(stagingBase, "9\n", "9\nnine and a half\n", 1) {
+		t.Errorf("index = %q, want only the selected line staged", content)
+	}
+
+	// Selecting a deletion alone removes the line without adding its replacement
+	diff = r.fileDiff(false, 2)
+	line = lineIndex(t, diff.Hunks[0], DiffLineDelete, "2")
+	sel = DiffSelection{Hash: diff.Hash, Lines: []DiffLineRange{{Hunk: 0, Start: line, End: line + 1}}}
+	if err := NewGitService().StageLines(r.dir, "file.txt", sel); err != nil {
+		t.Fatalf("failed to stage: %v", err)
+	}
+	if content, _ := r.staged("file.txt"); content != "1\n3\n4\n5\n6\n7\n8\n9\nnine and a half\n10\n" {
+		t.Errorf("index = %q, want the deletion staged too", content)
+	}
+}
+
+func TestUnstageLines(t *testing.T) {
+	r := stagingRepo(t)
+	r.add("file.txt")
+	diff := r.fileDiff(true, 2)
+
+	line := lineIndex(t, diff.Hunks[1], DiffLineAdd, "nine and three quarters")
+	sel := DiffSelection{Hash: diff.Hash, Hunks: []int{0}, Lines: []DiffLineRange{{Hunk: 1, Start: line, End: line + 1}}}
+	if err := NewGitService().UnstageLines(r.dir, "file.txt", sel); err != nil {
+		t.Fatalf("failed to unstage: %v", err)
+	}
+	if content, _ := r.staged("file.txt"); content != strings.Replace(stagingBase, "9\n", "9\nnine and a half\n", 1) {
+		t.Errorf("index = %q, want only the unselected line left staged", content)
+	}
+	if content, _ := r.read("file.txt"); !strings.Contains(content, "two\n") || !strings.Contains(content, "nine and three quarters\n") {
+		t.Errorf("working copy = %q, want it unchanged", content)
+	}
+}
+
+func TestDiscardLines(t *testing.T) {
+	r := stagingRepo(t)
+	path := filepath.Join(r.dir, "file.txt")
+	if err := os.Chmod(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	diff := r.fileDiff(false, 2)
+
+	line := lineIndex(t, diff.Hunks[1], DiffLineAdd, "nine and a half")
+	sel := DiffSelection{Hash: diff.Hash, Hunks: []int{0}, Lines: []DiffLineRange{{Hunk: 1, Start: line, End: line + 1}}}
+	if err := NewGitService().DiscardLines(r.dir, "file.txt", sel); err != nil {
+		t.Fatalf("failed to discard: %v", err)
+	}
+	if content, _ := r.read("file.txt"); content != strings.Replace(stagingBase, "9\n", "9\nnine and three quarters\n", 1) {
+		t.Errorf("working copy = %q, want only the unselected line left", content)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("mode after discard = %v (%v), want 0755 kept", info.Mode(), err)
+	}
+	if content, _ := r.staged("file.txt"); content != stagingBase {
+		t.Errorf("index = %q, want it unchanged", content)
+	}
+}
+
+func TestStageLinesRejectsStaleDiff(t *testing.T) {
+	r := stagingRepo(t)
+	diff := r.fileDiff(false, 2)
+	s := NewGitService()
+
+	// The file changes after the diff was shown
+	r.write("file.txt", "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n")
+	sel := DiffSelection{Hash: diff.Hash, Hunks: []int{0}}
+	if err := s.StageLines(r.dir, "file.txt", sel); err == nil {
+		t.Error("staged lines of an outdated diff")
+	}
+	if err := s.DiscardLines(r.dir, "file.txt", sel); err == nil {
+		t.Error("discarded lines of an outdated diff")
+	}
+	if content, _ := r.staged("file.txt"); content != stagingBase {
+		t.Errorf("index = %q, want it unchanged", content)
+	}
+	if content, _ := r.read("file.txt"); content != "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n" {
+		t.Errorf("working copy = %q, want it unchanged", content)
+	}
+
+	// Options are part of the hash, hunk indexes depend on them
+	diff = r.fileDiff(false, 2)
+	sel = DiffSelection{Hash: diff.Hash, Options: DiffOptions{Context: 10}, Hunks: []int{0}}
+	if err := s.StageLines(r.dir, "file.txt", sel); err == nil {
+		t.Error("staged lines with other diff options than the diff was made with")
+	}
+}
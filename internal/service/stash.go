@@ -0,0 +1,740 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// stashRef is the reference pointing at the latest stash, older ones are in its reflog
+const stashRef = plumbing.ReferenceName("refs/stash")
+
+// stashLogPath is the reflog of refs/stash, relative to the .git directory
+const stashLogPath = "logs/refs/stash"
+
+// StashOptions contains options for stashing local changes
+type StashOptions struct {
+	Message          string `json:"message"`          // Description of the stash, "WIP on <branch>" if empty
+	IncludeUntracked bool   `json:"includeUntracked"` // Also stash untracked files and remove them
+	StagedOnly       bool   `json:"stagedOnly"`       // Only stash the staged changes, like git stash --staged
+}
+
+// StashInfo describes a stash
+type StashInfo struct {
+	Index   int                  `json:"index"` // n in stash@{n}, 0 is the latest stash
+	Hash    string               `json:"hash"`
+	Message string               `json:"message"`
+	Branch  string               `json:"branch"` // Branch the stash was made on
+	Date    time.Time            `json:"date"`
+	Files   []DirectoryDiffEntry `json:"files"` // Stashed files, untracked ones included
+	Stats   DiffStats            `json:"stats"` // Totals of every file
+}
+
+// stashEntry is a line of the stash reflog
+type stashEntry struct {
+	hash    plumbing.Hash
+	who     string // Identity and time of the line, e.g. "Jane <jane@example.com> 1700000000 +0100"
+	message string
+}
+
+// treeFileDiff is a file that differs between two trees
+type treeFileDiff struct {
+	entry DirectoryDiffEntry
+	diff  *FileDiff
+}
+
+// PushStash saves local changes in a new stash and removes them from the working tree
+func (s *GitService) PushStash(projectPath string, opts StashOptions) (*StashInfo, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if err := stashPush(repo, opts); err != nil {
+		return nil, err
+	}
+
+	stashes, err := s.ListStashes(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	return &stashes[0], nil
+}
+
+// ListStashes returns the stashes of a repository, latest first
+func (s *GitService) ListStashes(projectPath string) ([]StashInfo, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	entries, err := readStashes(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	stashes := make([]StashInfo, 0, len(entries))
+	for i, entry := range entries {
+		commit, err := repo.CommitObject(entry.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stash@{%d}: %w", i, err)
+		}
+		diffs, err := stashDiffs(repo, commit, DiffOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		info := StashInfo{
+			Index:   i,
+			Hash:    entry.hash.String(),
+			Message: entry.message,
+			Branch:  stashBranch(entry.message),
+			Date:    commit.Committer.When,
+			Files:   make([]DirectoryDiffEntry, 0, len(diffs)),
+		}
+		for _, d := range diffs {
+			info.Files = append(info.Files, d.entry)
+			info.Stats.Added += d.entry.Stats.Added
+			info.Stats.Deleted += d.entry.Stats.Deleted
+			info.Stats.Modified += d.entry.Stats.Modified
+		}
+		stashes = append(stashes, info)
+	}
+	return stashes, nil
+}
+
+// GetStashDiff returns the diff of every file of a stash against the commit it was made on
+func (s *GitService) GetStashDiff(projectPath string, index int, opts DiffOptions) ([]FileDiff, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	entry, err := stashAt(repo, index)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(entry.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stash@{%d}: %w", index, err)
+	}
+
+	diffs, err := stashDiffs(repo, commit, opts)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]FileDiff, 0, len(diffs))
+	for _, d := range diffs {
+		files = append(files, *d.diff)
+	}
+	return files, nil
+}
+
+// ApplyStash applies a stash on top of the working tree and keeps it.
+// Conflicting files are left with conflict markers and listed in the result.
+func (s *GitService) ApplyStash(projectPath string, index int) (*MergeResult, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return applyStash(repo, index)
+}
+
+// PopStash applies a stash and drops it, a stash that conflicts is kept like with git stash pop
+func (s *GitService) PopStash(projectPath string, index int) (*MergeResult, error) {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	result, err := applyStash(repo, index)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Conflicts) == 0 {
+		if err := dropStash(repo, index); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// DropStash deletes a stash
+func (s *GitService) DropStash(projectPath string, index int) error {
+	repo, err := git.PlainOpen(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	return dropStash(repo, index)
+}
+
+// stashPush saves local changes in a new stash like git stash push, then removes them from the working tree.
+// The stash is a commit of the working tree whose parents are HEAD, a commit of the index and,
+// with untracked files, a commit of those.
+func stashPush(repo *git.Repository, opts StashOptions) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("cannot stash without a commit: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get commit: %w", err)
+	}
+	headFiles, err := headTreeFiles(repo)
+	if err != nil {
+		return err
+	}
+	indexFiles, err := stagedFiles(repo)
+	if err != nil {
+		return err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	var modified, untracked []string
+	for file, fileStatus := range status {
+		switch {
+		case fileStatus.Worktree == git.Untracked:
+			untracked = append(untracked, file)
+		case fileStatus.Worktree != git.Unmodified:
+			modified = append(modified, file)
+		}
+	}
+	staged := changedFiles(headFiles, indexFiles)
+	sort.Strings(untracked)
+
+	switch {
+	case opts.StagedOnly && len(staged) == 0:
+		return errors.New("no staged changes to save")
+	case !opts.StagedOnly && len(staged) == 0 && len(modified) == 0 && (!opts.IncludeUntracked || len(untracked) == 0):
+		return errors.New("no local changes to save")
+	}
+
+	sig, err := gitSignature(repo)
+	if err != nil {
+		return err
+	}
+	root := worktree.Filesystem.Root()
+	label := stashLabel(head, headCommit)
+
+	// Staged changes leave the working copy, unstaged changes made on top of them stay
+	var restored map[string][]byte
+	if opts.StagedOnly {
+		if restored, err = unstagedContents(repo, root, staged, headFiles, indexFiles, status); err != nil {
+			return err
+		}
+	}
+
+	indexTree, err := writeTree(repo, indexFiles)
+	if err != nil {
+		return err
+	}
+	indexCommit, err := writeCommit(repo, indexTree, []plumbing.Hash{head.Hash()}, "index on "+label+"\n", sig, sig)
+	if err != nil {
+		return err
+	}
+	parents := []plumbing.Hash{head.Hash(), indexCommit}
+
+	worktreeTree := indexTree
+	if !opts.StagedOnly {
+		worktreeFiles := make(map[string]treeFile, len(indexFiles))
+		for name, file := range indexFiles {
+			worktreeFiles[name] = file
+		}
+		for _, name := range modified {
+			file, err := workingTreeFile(repo, root, name)
+			if os.IsNotExist(err) {
+				delete(worktreeFiles, name)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			worktreeFiles[name] = file
+		}
+		if worktreeTree, err = writeTree(repo, worktreeFiles); err != nil {
+			return err
+		}
+
+		if opts.IncludeUntracked && len(untracked) > 0 {
+			untrackedFiles := make(map[string]treeFile, len(untracked))
+			for _, name := range untracked {
+				if untrackedFiles[name], err = workingTreeFile(repo, root, name); err != nil {
+					return err
+				}
+			}
+			untrackedTree, err := writeTree(repo, untrackedFiles)
+			if err != nil {
+				return err
+			}
+			untrackedCommit, err := writeCommit(repo, untrackedTree, nil, "untracked files on "+label+"\n", sig, sig)
+			if err != nil {
+				return err
+			}
+			parents = append(parents, untrackedCommit)
+		}
+	}
+
+	message := "WIP on " + label
+	if opts.Message != "" {
+		message = "On " + strings.SplitN(label, ":", 2)[0] + ": " + opts.Message
+	}
+	stash, err := writeCommit(repo, worktreeTree, parents, message+"\n", sig, sig)
+	if err != nil {
+		return err
+	}
+	if err := pushStashRef(repo, stash, sig, message); err != nil {
+		return err
+	}
+
+	if opts.StagedOnly {
+		return resetStaged(repo, root, headFiles, restored)
+	}
+
+	// Only the stashed files go back to HEAD, a hard reset would delete the untracked files left out
+	if err := restoreHeadFiles(repo, append(staged, modified...)); err != nil {
+		return fmt.Errorf("failed to reset changes: %w", err)
+	}
+	if opts.IncludeUntracked {
+		for _, name := range untracked {
+			fullPath := filepath.Join(root, filepath.FromSlash(name))
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+			removeEmptyParents(root, filepath.Dir(fullPath))
+		}
+	}
+	return nil
+}
+
+// unstagedContents computes the working copy of staged files once their staged changes are removed.
+// A file without other changes goes back to HEAD, unstaged changes are merged on top of HEAD.
+// A nil content stands for a file missing from HEAD.
+func unstagedContents(repo *git.Repository, root string, staged []string, headFiles, indexFiles map[string]treeFile, status git.Status) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(staged))
+	for _, name := range staged {
+		var headContent []byte
+		if file, ok := headFiles[name]; ok {
+			content, err := readBlob(repo, file.Hash)
+			if err != nil {
+				return nil, err
+			}
+			headContent = content
+		}
+
+		fileStatus, ok := status[name]
+		if !ok || fileStatus.Worktree == git.Unmodified {
+			contents[name] = headContent
+			continue
+		}
+
+		var indexContent []byte
+		if file, ok := indexFiles[name]; ok {
+			content, err := readBlob(repo, file.Hash)
+			if err != nil {
+				return nil, err
+			}
+			indexContent = content
+		}
+		working, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		merged, conflicted := mergeText(string(indexContent), string(working), string(headContent), "", "")
+		if conflicted || isBinaryContent(working) {
+			return nil, fmt.Errorf("cannot stash the staged changes of %s, unstaged changes overlap them", name)
+		}
+		contents[name] = []byte(merged)
+		if headContent == nil && merged == "" {
+			contents[name] = nil
+		}
+	}
+	return contents, nil
+}
+
+// resetStaged resets the index entries of staged files to HEAD and writes their new working copies
+func resetStaged(repo *git.Repository, root string, headFiles map[string]treeFile, contents map[string][]byte) error {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	for name, content := range contents {
+		fullPath := filepath.Join(root, filepath.FromSlash(name))
+		file, inHead := headFiles[name]
+		if !inHead {
+			removeIndexEntries(idx, name)
+			if content == nil {
+				if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to remove %s: %w", name, err)
+				}
+				continue
+			}
+			if err := writeWorkingFile(fullPath, content, filemode.Regular); err != nil {
+				return err
+			}
+			continue
+		}
+
+		blob, err := repo.BlobObject(file.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to get blob object: %w", err)
+		}
+		setIndexEntry(idx, name, file, int(blob.Size))
+		if err := writeWorkingFile(fullPath, content, file.Mode); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// applyStash merges the changes of a stash into the working tree, restoring its untracked files
+func applyStash(repo *git.Repository, index int) (*MergeResult, error) {
+	entry, err := stashAt(repo, index)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(entry.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stash@{%d}: %w", index, err)
+	}
+	if len(commit.ParentHashes) < 2 {
+		return nil, fmt.Errorf("stash@{%d} is not a stash", index)
+	}
+
+	base, err := commitFiles(repo, commit.ParentHashes[0])
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := commitFiles(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	ours, err := stagedFiles(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	var untracked map[string]treeFile
+	if len(commit.ParentHashes) > 2 {
+		if untracked, err = commitFiles(repo, commit.ParentHashes[2]); err != nil {
+			return nil, err
+		}
+		for name := range untracked {
+			if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); err == nil {
+				return nil, fmt.Errorf("%s already exists, the stash was not applied", name)
+			}
+		}
+	}
+
+	merge, err := mergeTrees(repo, base, ours, theirs, "Updated upstream", "Stashed changes")
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTreeMerge(repo, ours, merge, false); err != nil {
+		return nil, err
+	}
+
+	for name, file := range untracked {
+		content, err := readBlob(repo, file.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeWorkingFile(filepath.Join(root, filepath.FromSlash(name)), content, file.Mode); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &MergeResult{Status: MergeClean, Conflicts: merge.conflictList()}
+	if len(result.Conflicts) > 0 {
+		result.Status = MergeConflicted
+	}
+	return result, nil
+}
+
+// stashDiffs compares a stash with the commit it was made on, untracked files count as added
+func stashDiffs(repo *git.Repository, commit *object.Commit, opts DiffOptions) ([]treeFileDiff, error) {
+	if len(commit.ParentHashes) == 0 {
+		return nil, fmt.Errorf("%s is not a stash", commit.Hash)
+	}
+	base, err := commitFiles(repo, commit.ParentHashes[0])
+	if err != nil {
+		return nil, err
+	}
+	stashed, err := commitFiles(repo, commit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(commit.ParentHashes) > 2 {
+		untracked, err := commitFiles(repo, commit.ParentHashes[2])
+		if err != nil {
+			return nil, err
+		}
+		for name, file := range untracked {
+			stashed[name] = file
+		}
+	}
+	return diffTreeFiles(repo, base, stashed, opts)
+}
+
+// diffTreeFiles compares two flattened trees file by file, sorted by path
+func diffTreeFiles(repo *git.Repository, oldFiles, newFiles map[string]treeFile, opts DiffOptions) ([]treeFileDiff, error) {
+	var diffs []treeFileDiff
+	for _, name := range changedFiles(oldFiles, newFiles) {
+		oldFile, inOld := oldFiles[name]
+		newFile, inNew := newFiles[name]
+
+		entry := DirectoryDiffEntry{Path: name, Status: "M"}
+		var oldContent, newContent []byte
+		var err error
+		if inOld {
+			if oldContent, err = readBlob(repo, oldFile.Hash); err != nil {
+				return nil, err
+			}
+		} else {
+			entry.Status = "A"
+		}
+		if inNew {
+			if newContent, err = readBlob(repo, newFile.Hash); err != nil {
+				return nil, err
+			}
+		} else {
+			entry.Status = "D"
+		}
+
+		diff := &FileDiff{Path: name, IsBinary: true}
+		if !isBinaryContent(oldContent) && !isBinaryContent(newContent) {
+			if diff, err = buildDiff(string(oldContent), string(newContent), name, name, opts); err != nil {
+				return nil, err
+			}
+		}
+		entry.IsBinary = diff.IsBinary
+		entry.Stats = diff.Stats
+		diffs = append(diffs, treeFileDiff{entry: entry, diff: diff})
+	}
+	return diffs, nil
+}
+
+// changedFiles returns the sorted paths whose file differs between two flattened trees
+func changedFiles(oldFiles, newFiles map[string]treeFile) []string {
+	var changed []string
+	for name, file := range oldFiles {
+		if newFile, ok := newFiles[name]; !ok || newFile != file {
+			changed = append(changed, name)
+		}
+	}
+	for name := range newFiles {
+		if _, ok := oldFiles[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// commitFiles returns the files of a commit as a flattened tree
+func commitFiles(repo *git.Repository, hash plumbing.Hash) (map[string]treeFile, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+	return treeFiles(tree)
+}
+
+// stashLabel describes the commit a stash is made on, e.g. "main: 1a2b3c4 Fix typo"
+func stashLabel(head *plumbing.Reference, commit *object.Commit) string {
+	branch := "(no branch)"
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	return fmt.Sprintf("%s: %s %s", branch, commit.Hash.String()[:7], commitSubject(commit))
+}
+
+// stashBranch extracts the branch from a stash message like "WIP on main: ..." or "On main: ..."
+func stashBranch(message string) string {
+	for _, prefix := range []string{"WIP on ", "On "} {
+		if strings.HasPrefix(message, prefix) {
+			return strings.SplitN(strings.TrimPrefix(message, prefix), ":", 2)[0]
+		}
+	}
+	return ""
+}
+
+// stagedFiles returns the files of the index, it fails on unresolved conflicts
+func stagedFiles(repo *git.Repository) (map[string]treeFile, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index: %w", err)
+	}
+
+	files := make(map[string]treeFile, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Stage != 0 {
+			return nil, fmt.Errorf("%s has unresolved conflicts", entry.Name)
+		}
+		files[entry.Name] = treeFile{Mode: entry.Mode, Hash: entry.Hash}
+	}
+	return files, nil
+}
+
+// workingTreeFile stores the working copy of a file as a blob, symlinks are stored by target
+func workingTreeFile(repo *git.Repository, root, name string) (treeFile, error) {
+	fullPath := filepath.Join(root, filepath.FromSlash(name))
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return treeFile{}, err
+	}
+
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(fullPath)
+		if err != nil {
+			return treeFile{}, err
+		}
+		content = []byte(filepath.ToSlash(target))
+	} else if content, err = os.ReadFile(fullPath); err != nil {
+		return treeFile{}, err
+	}
+
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		return treeFile{}, fmt.Errorf("unsupported file mode for %s: %w", name, err)
+	}
+	hash, err := writeBlob(repo, content)
+	if err != nil {
+		return treeFile{}, err
+	}
+	return treeFile{Mode: mode, Hash: hash}, nil
+}
+
+// readStashes returns the entries of the stash reflog, latest first
+func readStashes(repo *git.Repository) ([]stashEntry, error) {
+	log, found, err := readGitFile(repo, stashLogPath)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	var entries []stashEntry
+	for _, line := range strings.Split(log, "\n") {
+		// <old hash> <new hash> <name> <<email>> <time> <zone>\t<message>
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		who, message, _ := strings.Cut(fields[2], "\t")
+		entries = append(entries, stashEntry{
+			hash:    plumbing.NewHash(fields[1]),
+			who:     who,
+			message: message,
+		})
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// stashAt returns the stash@{index} entry
+func stashAt(repo *git.Repository, index int) (*stashEntry, error) {
+	entries, err := readStashes(repo)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(entries) {
+		return nil, fmt.Errorf("stash@{%d} does not exist", index)
+	}
+	return &entries[index], nil
+}
+
+// pushStashRef points refs/stash at a new stash and records it in the reflog, which is where git keeps the list of stashes
+func pushStashRef(repo *git.Repository, stash plumbing.Hash, sig *object.Signature, message string) error {
+	entries, err := readStashes(repo)
+	if err != nil {
+		return err
+	}
+	_, offset := sig.When.Zone()
+	entry := stashEntry{
+		hash:    stash,
+		who:     fmt.Sprintf("%s <%s> %d %s", sig.Name, sig.Email, sig.When.Unix(), formatZone(offset)),
+		message: strings.SplitN(message, "\n", 2)[0],
+	}
+	return writeStashes(repo, append([]stashEntry{entry}, entries...))
+}
+
+// dropStash removes stash@{index} from the reflog, moving refs/stash to the next stash
+func dropStash(repo *git.Repository, index int) error {
+	entries, err := readStashes(repo)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(entries) {
+		return fmt.Errorf("stash@{%d} does not exist", index)
+	}
+	return writeStashes(repo, append(entries[:index], entries[index+1:]...))
+}
+
+// writeStashes rewrites the stash reflog from its entries, latest first, and points refs/stash at the latest one
+func writeStashes(repo *git.Repository, entries []stashEntry) error {
+	if len(entries) == 0 {
+		if err := removeGitFiles(repo, stashLogPath); err != nil {
+			return err
+		}
+		if err := repo.Storer.RemoveReference(stashRef); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", stashRef, err)
+		}
+		return nil
+	}
+
+	var sb strings.Builder
+	previous := plumbing.ZeroHash
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		fmt.Fprintf(&sb, "%s %s %s\t%s\n", previous, entry.hash, entry.who, entry.message)
+		previous = entry.hash
+	}
+	if err := writeGitFile(repo, stashLogPath, sb.String()); err != nil {
+		return err
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(stashRef, entries[0].hash)); err != nil {
+		return fmt.Errorf("failed to update %s: %w", stashRef, err)
+	}
+	return nil
+}
+
+// formatZone formats a UTC offset in seconds like git, e.g. "+0200"
+func formatZone(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+}
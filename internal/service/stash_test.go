@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+func TestPushStashKeepsUntrackedFiles(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("file.txt", "two\n")
+	r.write("notes.txt", "notes\n")
+
+	if _, err := NewGitService().PushStash(r.dir, StashOptions{}); err != nil {
+		t.Fatalf("failed to stash: %v", err)
+	}
+
+	if content, _ := r.read("file.txt"); content != "one\n" {
+		t.Errorf("stashed file = %q, want the HEAD content %q", content, "one\n")
+	}
+	if content, ok := r.read("notes.txt"); !ok || content != "notes\n" {
+		t.Errorf("untracked file = %q (exists %v), want %q", content, ok, "notes\n")
+	}
+	if got := r.status("notes.txt"); got != "??" {
+		t.Errorf("status of untracked file = %q, want %q", got, "??")
+	}
+}
+
+func TestPushStashRemovesStagedAdditions(t *testing.T) {
+	r := newTestRepo(t, map[string]string{"file.txt": "one\n"})
+	r.write("added.txt", "added\n")
+	r.add("added.txt")
+	r.write("notes.txt", "notes\n")
+
+	if _, err := NewGitService().PushStash(r.dir, StashOptions{}); err != nil {
+		t.Fatalf("failed to stash: %v", err)
+	}
+
+	if _, ok := r.read("added.txt"); ok {
+		t.Error("staged addition is still in the working copy")
+	}
+	if _, found := r.staged("added.txt"); found {
+		t.Error("staged addition is still in the index")
+	}
+	if _, ok := r.read("notes.txt"); !ok {
+		t.Error("untracked file was deleted")
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalFileName is the name of the rollback journal inside a transaction directory
+const journalFileName = "journal.json"
+
+// fileTransaction writes a set of files so that either all of them or none are changed.
+// Before any file is replaced its original content is copied into a journal directory;
+// if the process dies in the middle of a commit, RecoverFileTransactions rolls the files back.
+type fileTransaction struct {
+	journalRoot string
+	writes      []*transactionWrite
+}
+
+// transactionWrite is a single file replaced by a transaction
+type transactionWrite struct {
+	Path   string      `json:"path"`   // File being replaced
+	Backup string      `json:"backup"` // Copy of the original content inside the journal directory
+	Temp   string      `json:"temp"`   // Temporary file holding the new content
+	Mode   os.FileMode `json:"mode"`   // Original file mode
+
+	content  []byte
+	expected string // Content hash the file must still have, empty to skip the check
+	info     os.FileInfo
+}
+
+// transactionJournal is the on-disk record of a running transaction
+type transactionJournal struct {
+	Started time.Time           `json:"started"`
+	Writes  []*transactionWrite `json:"writes"`
+}
+
+// newFileTransaction creates a transaction keeping its journals under journalRoot
+func newFileTransaction(journalRoot string) *fileTransaction {
+	return &fileTransaction{journalRoot: journalRoot}
+}
+
+// Write schedules new content for an existing file. If expected isn't empty, the commit fails
+// with a FileConflictError unless the file still has that content hash.
+func (t *fileTransaction) Write(path string, content []byte, expected string) {
+	t.writes = append(t.writes, &transactionWrite{Path: path, content: content, expected: expected})
+}
+
+// Commit replaces every scheduled file, restoring the originals if any step fails
+func (t *fileTransaction) Commit() error {
+	if len(t.writes) == 0 {
+		return nil
+	}
+
+	dir, err := t.prepare()
+	if err != nil {
+		return err
+	}
+
+	for _, w := range t.writes {
+		if err := os.Rename(w.Temp, w.Path); err != nil {
+			rollbackErr := rollbackJournal(&transactionJournal{Writes: t.writes})
+			t.cleanup(dir)
+			if rollbackErr != nil {
+				return fmt.Errorf("failed to replace %s: %v (rollback failed: %w)", w.Path, err, rollbackErr)
+			}
+			return fmt.Errorf("failed to replace %s: %w", w.Path, err)
+		}
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// prepare backs up the original files, writes the new content to temporary files and journals
+// every write before any file is replaced. It returns the journal directory.
+func (t *fileTransaction) prepare() (string, error) {
+	if err := os.MkdirAll(t.journalRoot, 0755); err != nil {
+		return "", fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(t.journalRoot, "txn-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	journal := &transactionJournal{Started: time.Now(), Writes: t.writes}
+
+	// Prepare everything that can fail before touching the original files
+	for i, w := range t.writes {
+		info, err := os.Stat(w.Path)
+		if err != nil {
+			t.cleanup(dir)
+			return "", fmt.Errorf("failed to stat %s: %w", w.Path, err)
+		}
+		w.Mode = info.Mode().Perm()
+		w.info = info
+
+		original, err := os.ReadFile(w.Path)
+		if err != nil {
+			t.cleanup(dir)
+			return "", fmt.Errorf("failed to read %s: %w", w.Path, err)
+		}
+		if w.expected != "" && contentHash(original) != w.expected {
+			t.cleanup(dir)
+			return "", &FileConflictError{Path: w.Path}
+		}
+
+		w.Backup = filepath.Join(dir, fmt.Sprintf("%d.bak", i))
+		if err := writeFileSync(w.Backup, original, 0600); err != nil {
+			t.cleanup(dir)
+			return "", fmt.Errorf("failed to back up %s: %w", w.Path, err)
+		}
+
+		w.Temp, err = writeTempFile(w.Path, w.content, w.info)
+		if err != nil {
+			t.cleanup(dir)
+			return "", fmt.Errorf("failed to write %s: %w", w.Path, err)
+		}
+	}
+
+	if err := writeJournal(dir, journal); err != nil {
+		t.cleanup(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// cleanup removes leftover temporary files and the journal directory
+func (t *fileTransaction) cleanup(dir string) {
+	for _, w := range t.writes {
+		if w.Temp != "" {
+			os.Remove(w.Temp)
+		}
+	}
+	os.RemoveAll(dir)
+}
+
+// writeJournal atomically replaces the journal file of a transaction directory
+func writeJournal(dir string, journal *transactionJournal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	tmp := filepath.Join(dir, journalFileName+".tmp")
+	if err := writeFileSync(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, journalFileName)); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// rollbackJournal restores the original content of every applied write.
+// The rename consumes the temporary file, so a write whose temporary file is gone was applied,
+// even when the process died right after the rename.
+func rollbackJournal(journal *transactionJournal) error {
+	var errs []error
+	for _, w := range journal.Writes {
+		if _, err := os.Lstat(w.Temp); err == nil {
+			os.Remove(w.Temp)
+			continue
+		}
+
+		original, err := os.ReadFile(w.Backup)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read backup of %s: %w", w.Path, err))
+			continue
+		}
+		if err := writeFileSync(w.Path, original, w.Mode); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", w.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RecoverFileTransactions rolls back transactions interrupted by a crash
+func RecoverFileTransactions(journalRoot string) error {
+	entries, err := os.ReadDir(journalRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(journalRoot, entry.Name())
+
+		data, err := os.ReadFile(filepath.Join(dir, journalFileName))
+		if err != nil {
+			// The transaction never got to replace a file
+			os.RemoveAll(dir)
+			continue
+		}
+
+		var journal transactionJournal
+		if err := json.Unmarshal(data, &journal); err != nil {
+			errs = append(errs, fmt.Errorf("failed to decode journal %s: %w", dir, err))
+			continue
+		}
+
+		if err := rollbackJournal(&journal); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("[FileTransaction] Rolled back interrupted transaction from %s", journal.Started.Format(time.RFC3339))
+		os.RemoveAll(dir)
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeFileSync writes a file and flushes it to disk
+func writeFileSync(path string, content []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
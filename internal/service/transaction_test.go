@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestTransaction writes the original files and schedules new content for each of them
+func newTestTransaction(t *testing.T, files map[string][2]string) (*fileTransaction, string) {
+	t.Helper()
+	dir := t.TempDir()
+	txn := newFileTransaction(filepath.Join(t.TempDir(), "journal"))
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		writeTestFile(t, path, content[0])
+		txn.Write(path, []byte(content[1]), contentHash([]byte(content[0])))
+	}
+	return txn, dir
+}
+
+func TestTransactionCommit(t *testing.T) {
+	txn, dir := newTestTransaction(t, map[string][2]string{
+		"a.txt": {"old a", "new a"},
+		"b.txt": {"old b", "new b"},
+	})
+
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"a.txt": "new a", "b.txt": "new b"} {
+		if got, _ := readTestFile(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if entries, _ := os.ReadDir(txn.journalRoot); len(entries) != 0 {
+		t.Errorf("journal directory keeps %d entries after the commit", len(entries))
+	}
+}
+
+func TestTransactionRejectsChangedFile(t *testing.T) {
+	txn, dir := newTestTransaction(t, map[string][2]string{
+		"a.txt": {"old a", "new a"},
+		"b.txt": {"old b", "new b"},
+	})
+	writeTestFile(t, filepath.Join(dir, "b.txt"), "changed on disk")
+
+	var conflict *FileConflictError
+	if err := txn.Commit(); !errors.As(err, &conflict) {
+		t.Fatalf("err = %v, want a FileConflictError", err)
+	}
+
+	if got, _ := readTestFile(t, filepath.Join(dir, "a.txt")); got != "old a" {
+		t.Errorf("a.txt = %q, want it untouched", got)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("directory has %d entries, want the temporary files removed", len(entries))
+	}
+}
+
+func TestRecoverInterruptedTransaction(t *testing.T) {
+	txn, dir := newTestTransaction(t, map[string][2]string{
+		"a.txt": {"old a", "new a"},
+		"b.txt": {"old b", "new b"},
+	})
+
+	// Simulate a crash right after the first file was replaced
+	if _, err := txn.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	first := txn.writes[0]
+	if err := os.Rename(first.Temp, first.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecoverFileTransactions(txn.journalRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"a.txt": "old a", "b.txt": "old b"} {
+		if got, _ := readTestFile(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s = %q, want the original %q", name, got, want)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("directory has %d entries, want the temporary files removed", len(entries))
+	}
+	if entries, _ := os.ReadDir(txn.journalRoot); len(entries) != 0 {
+		t.Errorf("journal directory keeps %d entries after the recovery", len(entries))
+	}
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+const (
+	// defaultTrashMaxAge is how long deleted files are kept in the trash
+	defaultTrashMaxAge = 30 * 24 * time.Hour
+	// defaultTrashMaxSize is the total size of the trash before the oldest items are purged
+	defaultTrashMaxSize = 1 << 30
+)
+
+// TrashItem is a deleted file or directory that can still be restored
+type TrashItem struct {
+	ID           int64     `json:"id"`
+	ProjectPath  string    `json:"projectPath"`
+	OriginalPath string    `json:"originalPath"` // Where the item is restored to
+	IsDir        bool      `json:"isDir"`
+	Size         int64     `json:"size"` // Total size in bytes, including directory contents
+	DeletedAt    time.Time `json:"deletedAt"`
+}
+
+// newTrashItem converts a database row into a TrashItem
+func newTrashItem(item db.TrashItem) TrashItem {
+	return TrashItem{
+		ID:           item.ID,
+		ProjectPath:  item.ProjectPath,
+		OriginalPath: item.OriginalPath,
+		IsDir:        item.IsDir,
+		Size:         item.Size,
+		DeletedAt:    item.DeletedAt.Time,
+	}
+}
+
+// DeleteFile moves a file or directory to the trash of its project
+func (s *FileService) DeleteFile(path string) error {
+	item, err := s.moveToTrash(path)
+	if err != nil {
+		return err
+	}
+	s.recordFileOperation(FileOperationDelete, path, "", item.IsDir, item.ID)
+
+	// The item just deleted stays even if it alone exceeds the size limit, the delete must remain undoable
+	if err := s.cleanTrash(item.ID); err != nil {
+		log.Printf("[FileService] Failed to clean trash: %v", err)
+	}
+	return nil
+}
+
+// moveToTrash moves a file or directory into the trash and records it in the database
+func (s *FileService) moveToTrash(path string) (db.TrashItem, error) {
+	// Check if path exists, a symlink is trashed itself instead of its target
+	info, err := os.Lstat(path)
+	if err != nil {
+		return db.TrashItem{}, fmt.Errorf("path not found: %s", path)
+	}
+
+	projectPath := s.projectRoot(path)
+	trashDir := s.projectTrashDir(projectPath)
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		return db.TrashItem{}, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	// Every item gets its own directory so items with the same name don't collide
+	itemDir, err := os.MkdirTemp(trashDir, "item-")
+	if err != nil {
+		return db.TrashItem{}, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	trashPath := filepath.Join(itemDir, filepath.Base(path))
+
+	size := pathSize(path)
+	if err := movePath(path, trashPath, nil); err != nil {
+		os.RemoveAll(itemDir)
+		return db.TrashItem{}, fmt.Errorf("failed to delete: %w", err)
+	}
+
+	item, err := s.queries.CreateTrashItem(context.Background(), db.CreateTrashItemParams{
+		ProjectPath:  projectPath,
+		OriginalPath: path,
+		TrashPath:    trashPath,
+		IsDir:        info.IsDir(),
+		Size:         size,
+	})
+	if err != nil {
+		// Put the file back rather than leaving it in the trash without a record
+		if restoreErr := movePath(trashPath, path, nil); restoreErr != nil {
+			log.Printf("[FileService] Failed to move %s back from the trash: %v", path, restoreErr)
+		} else {
+			os.RemoveAll(itemDir)
+		}
+		return db.TrashItem{}, fmt.Errorf("failed to record deleted file: %w", err)
+	}
+
+	// Invalidate cache for the parent directory
+	s.InvalidateCache(filepath.Dir(path))
+	return item, nil
+}
+
+// ListTrash returns the items in the trash of a project, most recently deleted first
+func (s *FileService) ListTrash(projectPath string) ([]TrashItem, error) {
+	rows, err := s.queries.ListTrashItems(context.Background(), projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	items := make([]TrashItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, newTrashItem(row))
+	}
+	return items, nil
+}
+
+// GetTrashItem returns a single item of the trash
+func (s *FileService) GetTrashItem(id int64) (*TrashItem, error) {
+	item, err := s.getTrashItem(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	result := newTrashItem(item)
+	return &result, nil
+}
+
+// RestoreTrash moves an item from the trash back to its original path
+func (s *FileService) RestoreTrash(id int64) error {
+	item, err := s.getTrashItem(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	return s.restoreTrashItem(item)
+}
+
+// restoreTrashItem moves a trashed file back and removes its record
+func (s *FileService) restoreTrashItem(item db.TrashItem) error {
+	// Never overwrite a file created at the same path after the delete
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return fmt.Errorf("target already exists: %s", item.OriginalPath)
+	}
+	if _, err := os.Lstat(item.TrashPath); err != nil {
+		return fmt.Errorf("trashed file is missing: %s", item.OriginalPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := movePath(item.TrashPath, item.OriginalPath, nil); err != nil {
+		return fmt.Errorf("failed to restore: %w", err)
+	}
+
+	if err := s.queries.DeleteTrashItem(context.Background(), item.ID); err != nil {
+		return fmt.Errorf("failed to update trash: %w", err)
+	}
+	os.RemoveAll(filepath.Dir(item.TrashPath))
+
+	s.InvalidateCache(filepath.Dir(item.OriginalPath))
+	return nil
+}
+
+// PurgeTrash permanently deletes items from the trash
+func (s *FileService) PurgeTrash(ids []int64) error {
+	ctx := context.Background()
+	for _, id := range ids {
+		item, err := s.getTrashItem(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.purgeTrashItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes every item in the trash of a project
+func (s *FileService) EmptyTrash(projectPath string) error {
+	ctx := context.Background()
+
+	items, err := s.queries.ListTrashItems(ctx, projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to list trash: %w", err)
+	}
+	for _, item := range items {
+		if err := s.purgeTrashItem(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanTrash purges items older than the configured age and then the oldest
+// items until the trash fits the configured size
+func (s *FileService) CleanTrash() error {
+	return s.cleanTrash(0)
+}
+
+// cleanTrash cleans the trash like CleanTrash, leaving out the item with ID keep
+func (s *FileService) cleanTrash(keep int64) error {
+	ctx := context.Background()
+	settings := s.getSettings()
+
+	items, err := s.queries.ListAllTrashItems(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	// The kept item doesn't count either, a single oversized delete doesn't empty the rest of the trash
+	var total int64
+	for _, item := range items {
+		if item.ID != keep {
+			total += item.Size
+		}
+	}
+
+	for _, item := range items {
+		if item.ID == keep {
+			continue
+		}
+		expired := settings.TrashMaxAge > 0 && time.Since(item.DeletedAt.Time) > settings.TrashMaxAge
+		oversized := settings.TrashMaxSize > 0 && total > settings.TrashMaxSize
+		if !expired && !oversized {
+			// Items are sorted oldest first, so the rest are newer and fit
+			break
+		}
+
+		if err := s.purgeTrashItem(ctx, item); err != nil {
+			return err
+		}
+		total -= item.Size
+	}
+	return nil
+}
+
+// getTrashItem loads a trash item by ID
+func (s *FileService) getTrashItem(ctx context.Context, id int64) (db.TrashItem, error) {
+	item, err := s.queries.GetTrashItem(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, fmt.Errorf("trash item not found: %d", id)
+	}
+	if err != nil {
+		return item, fmt.Errorf("failed to get trash item: %w", err)
+	}
+	return item, nil
+}
+
+// purgeTrashItem removes a trashed item from disk and its record from the database,
+// along with the journaled operations that relied on it
+func (s *FileService) purgeTrashItem(ctx context.Context, item db.TrashItem) error {
+	itemDir := filepath.Dir(item.TrashPath)
+	// The stored path is only trusted inside the trash directory
+	if !isSubPath(s.trashRoot(), itemDir) || itemDir == s.trashRoot() {
+		return fmt.Errorf("invalid trash path: %s", item.TrashPath)
+	}
+
+	if err := os.RemoveAll(itemDir); err != nil {
+		return fmt.Errorf("failed to purge %s: %w", item.OriginalPath, err)
+	}
+	if err := s.queries.DeleteTrashItem(ctx, item.ID); err != nil {
+		return fmt.Errorf("failed to update trash: %w", err)
+	}
+
+	// Operations needing the item can't be undone or redone anymore, and neither can the ones behind them
+	s.operationsLock.Lock()
+	defer s.operationsLock.Unlock()
+	id := sql.NullInt64{Int64: item.ID, Valid: true}
+	err := s.queries.DeleteFileOperationsOfTrashItem(ctx, db.DeleteFileOperationsOfTrashItemParams{
+		TrashItemID:         id,
+		ReplacedTrashItemID: id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update file operations: %w", err)
+	}
+	return nil
+}
+
+// trashRoot returns the directory holding the trash of every project
+func (s *FileService) trashRoot() string {
+	return filepath.Join(s.dataDir, "trash")
+}
+
+// projectTrashDir returns the trash directory of a project
+func (s *FileService) projectTrashDir(projectPath string) string {
+	sum := sha256.Sum256([]byte(projectPath))
+	return filepath.Join(s.trashRoot(), hex.EncodeToString(sum[:8]))
+}
+
+// projectRoot returns the opened project containing path, or its parent directory if none does
+func (s *FileService) projectRoot(path string) string {
+	s.cacheLock.RLock()
+	defer s.cacheLock.RUnlock()
+
+	root := ""
+	for projectPath := range s.projects {
+		if isSubPath(projectPath, path) && len(projectPath) > len(root) {
+			root = projectPath
+		}
+	}
+	if root == "" {
+		return filepath.Dir(path)
+	}
+	return root
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	// defaultDiffContext is the number of unchanged lines shown around each change
+	defaultDiffContext = 3
+	// maxIntraLineLength is the longest line, in characters, whose changed ranges are computed
+	maxIntraLineLength = 1000
+)
+
+// Whitespace modes of a diff
+const (
+	WhitespaceKeep     = ""         // Compare lines exactly
+	WhitespaceTrailing = "trailing" // Ignore whitespace at the end of lines
+	WhitespaceChange   = "change"   // Ignore changes in the amount of whitespace, like git diff -b
+	WhitespaceAll      = "all"      // Ignore all whitespace, like git diff -w
+)
+
+// Types of diff lines
+const (
+	DiffLineContext = "context"
+	DiffLineAdd     = "add"
+	DiffLineDelete  = "delete"
+)
+
+// DiffOptions controls how a diff is computed
+type DiffOptions struct {
+	Context    int    `json:"context"`    // Unchanged lines around each change, defaultDiffContext if zero, none if negative
+	Whitespace string `json:"whitespace"` // One of the Whitespace constants
+}
+
+// DiffHunk is a group of changes with the unchanged lines around them
+type DiffHunk struct {
+	OldStart int        `json:"oldStart"` // First old line, or the line before the hunk if OldLines is 0
+	OldLines int        `json:"oldLines"`
+	NewStart int        `json:"newStart"` // First new line, or the line before the hunk if NewLines is 0
+	NewLines int        `json:"newLines"`
+	Header   string     `json:"header"` // e.g. "@@ -12,7 +12,8 @@"
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine is a line of a hunk
+type DiffLine struct {
+	Type      string      `json:"type"`              // One of the DiffLine constants
+	Content   string      `json:"content"`           // Line without its line break
+	OldLine   int         `json:"oldLine,omitempty"` // 1-based line in the old content, 0 for added lines
+	NewLine   int         `json:"newLine,omitempty"` // 1-based line in the new content, 0 for deleted lines
+	NoNewline bool        `json:"noNewline,omitempty"`
+	Changes   []DiffRange `json:"changes,omitempty"` // Changed parts of a modified line
+}
+
+// DiffRange is a range of characters inside a line, End is exclusive
+type DiffRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// diffText is a content split into lines
+type diffText struct {
+	lines []string
+	noEOL bool // The last line has no line break
+}
+
+// diffOp is a line of an edit script, ' ' for an unchanged line, '-' for a deleted one and '+' for an added one.
+// oldIndex and newIndex point at the line, or at the next line of the side that doesn't have it.
+type diffOp struct {
+	kind     byte
+	oldIndex int
+	newIndex int
+}
+
+// buildDiff compares two contents line by line and returns the unified text, the hunks and the stats
+func buildDiff(oldContent, newContent, oldPath, newPath string, opts DiffOptions) (*FileDiff, error) {
+	switch opts.Whitespace {
+	case WhitespaceKeep, WhitespaceTrailing, WhitespaceChange, WhitespaceAll:
+	default:
+		return nil, fmt.Errorf("unknown whitespace mode: %s", opts.Whitespace)
+	}
+	context := diffContext(opts)
+
+	oldText := splitDiffText(oldContent)
+	newText := splitDiffText(newContent)
+	ops := diffLineOps(oldText, newText, opts.Whitespace)
+
+	result := &FileDiff{Path: newPath, Hash: diffHash(oldContent, newContent, context, opts.Whitespace)}
+	for _, op := range ops {
+		switch op.kind {
+		case '-':
+			result.Stats.Deleted++
+		case '+':
+			result.Stats.Added++
+		}
+	}
+
+	result.Hunks = buildHunks(oldText, newText, ops, context)
+	for i := range result.Hunks {
+		result.Stats.Modified += markLineChanges(&result.Hunks[i])
+	}
+
+	if len(result.Hunks) > 0 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", oldPath, newPath)
+		for _, hunk := range result.Hunks {
+			sb.WriteString(hunk.Header)
+			sb.WriteString("\n")
+			for _, line := range hunk.Lines {
+				switch line.Type {
+				case DiffLineAdd:
+					sb.WriteString("+")
+				case DiffLineDelete:
+					sb.WriteString("-")
+				default:
+					sb.WriteString(" ")
+				}
+				sb.WriteString(line.Content)
+				sb.WriteString("\n")
+				if line.NoNewline {
+					sb.WriteString("\\ No newline at end of file\n")
+				}
+			}
+		}
+		result.Content = sb.String()
+	}
+
+	return result, nil
+}
+
+// diffContext returns the number of context lines of a diff
+func diffContext(opts DiffOptions) int {
+	if opts.Context == 0 {
+		return defaultDiffContext
+	}
+	if opts.Context < 0 {
+		return 0
+	}
+	return opts.Context
+}
+
+// diffHash identifies a diff by its contents and options, see FileDiff.Hash
+func diffHash(oldContent, newContent string, context int, whitespace string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d %s %d\n", context, whitespace, len(oldContent))
+	io.WriteString(h, oldContent)
+	io.WriteString(h, newContent)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// splitDiffText splits a content into lines
+func splitDiffText(content string) diffText {
+	if content == "" {
+		return diffText{}
+	}
+	return diffText{
+		lines: strings.Split(strings.TrimSuffix(content, "\n"), "\n"),
+		noEOL: !strings.HasSuffix(content, "\n"),
+	}
+}
+
+// diffLineOps computes the edit script between two contents.
+// Every distinct line is replaced by a single character, so the character diff is a line diff.
+func diffLineOps(oldText, newText diffText, whitespace string) []diffOp {
+	ids := make(map[string]rune)
+	encode := func(text diffText) []rune {
+		runes := make([]rune, len(text.lines))
+		for i, line := range text.lines {
+			key := normalizeWhitespace(line, whitespace)
+			if text.noEOL && i == len(text.lines)-1 {
+				// A missing final line break is a change too, lines never contain "\n"
+				key += "\n"
+			}
+			id, ok := ids[key]
+			if !ok {
+				id = lineRune(len(ids))
+				ids[key] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+	oldRunes := encode(oldText)
+	newRunes := encode(newText)
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMainRunes(oldRunes, newRunes, false)
+
+	ops := make([]diffOp, 0, len(oldRunes)+len(newRunes))
+	oldIndex, newIndex := 0, 0
+	var deleted, added int
+	flush := func() {
+		// Deletions are listed before the additions replacing them
+		for ; deleted > 0; deleted-- {
+			ops = append(ops, diffOp{kind: '-', oldIndex: oldIndex, newIndex: newIndex})
+			oldIndex++
+		}
+		for ; added > 0; added-- {
+			ops = append(ops, diffOp{kind: '+', oldIndex: oldIndex, newIndex: newIndex})
+			newIndex++
+		}
+	}
+
+	for _, d := range diffs {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			deleted += n
+		case diffmatchpatch.DiffInsert:
+			added += n
+		case diffmatchpatch.DiffEqual:
+			flush()
+			for i := 0; i < n; i++ {
+				ops = append(ops, diffOp{kind: ' ', oldIndex: oldIndex, newIndex: newIndex})
+				oldIndex++
+				newIndex++
+			}
+		}
+	}
+	flush()
+
+	return ops
+}
+
+// lineRune maps a line ID to a valid rune, skipping the surrogate range
+func lineRune(id int) rune {
+	r := rune(id + 1)
+	if r >= 0xD800 {
+		r += 0x800
+	}
+	return r
+}
+
+// normalizeWhitespace returns the text a line is compared by in the given whitespace mode
+func normalizeWhitespace(line, whitespace string) string {
+	switch whitespace {
+	case WhitespaceTrailing:
+		return strings.TrimRightFunc(line, unicode.IsSpace)
+	case WhitespaceChange:
+		return strings.Join(strings.Fields(line), " ")
+	case WhitespaceAll:
+		return strings.Join(strings.Fields(line), "")
+	}
+	return line
+}
+
+// buildHunks groups the changes of an edit script into hunks with context lines
+func buildHunks(oldText, newText diffText, ops []diffOp, context int) []DiffHunk {
+	spans := hunkSpans(ops, context)
+	hunks := make([]DiffHunk, 0, len(spans))
+	for _, span := range spans {
+		hunks = append(hunks, newHunk(oldText, newText, ops[span[0]:span[1]]))
+	}
+	return hunks
+}
+
+// hunkSpans returns the start and end of every hunk of an edit script, the end is exclusive.
+// Changes separated by at most twice the context share a hunk.
+func hunkSpans(ops []diffOp, context int) [][2]int {
+	var spans [][2]int
+
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Find the end of the last change of the hunk
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		spans = append(spans, [2]int{start, stop})
+		i = stop
+	}
+
+	return spans
+}
+
+// newHunk creates a hunk from a part of an edit script
+func newHunk(oldText, newText diffText, ops []diffOp) DiffHunk {
+	hunk := DiffHunk{Lines: make([]DiffLine, 0, len(ops))}
+
+	for _, op := range ops {
+		line := DiffLine{}
+		switch op.kind {
+		case ' ':
+			// Context lines come from the old side, like in git
+			line.Type = DiffLineContext
+			line.Content = oldText.lines[op.oldIndex]
+			line.OldLine = op.oldIndex + 1
+			line.NewLine = op.newIndex + 1
+			line.NoNewline = oldText.noEOL && op.oldIndex == len(oldText.lines)-1
+			hunk.OldLines++
+			hunk.NewLines++
+		case '-':
+			line.Type = DiffLineDelete
+			line.Content = oldText.lines[op.oldIndex]
+			line.OldLine = op.oldIndex + 1
+			line.NoNewline = oldText.noEOL && op.oldIndex == len(oldText.lines)-1
+			hunk.OldLines++
+		case '+':
+			line.Type = DiffLineAdd
+			line.Content = newText.lines[op.newIndex]
+			line.NewLine = op.newIndex + 1
+			line.NoNewline = newText.noEOL && op.newIndex == len(newText.lines)-1
+			hunk.NewLines++
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+
+	hunk.OldStart = ops[0].oldIndex
+	if hunk.OldLines > 0 {
+		hunk.OldStart++
+	}
+	hunk.NewStart = ops[0].newIndex
+	if hunk.NewLines > 0 {
+		hunk.NewStart++
+	}
+	hunk.Header = fmt.Sprintf("@@ -%s +%s @@", hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines))
+
+	return hunk
+}
+
+// hunkRange formats a line range of a hunk header, the count is left out when it is 1
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// markLineChanges pairs the deleted and added lines of every change of a hunk and sets their changed ranges.
+// It returns the number of modified lines, i.e. the number of pairs.
+func markLineChanges(hunk *DiffHunk) int {
+	modified := 0
+	lines := hunk.Lines
+
+	for i := 0; i < len(lines); {
+		if lines[i].Type != DiffLineDelete {
+			i++
+			continue
+		}
+
+		delStart := i
+		for i < len(lines) && lines[i].Type == DiffLineDelete {
+			i++
+		}
+		addStart := i
+		for i < len(lines) && lines[i].Type == DiffLineAdd {
+			i++
+		}
+
+		pairs := addStart - delStart
+		if added := i - addStart; added < pairs {
+			pairs = added
+		}
+		modified += pairs
+
+		for k := 0; k < pairs; k++ {
+			oldLine := &lines[delStart+k]
+			newLine := &lines[addStart+k]
+			oldLine.Changes, newLine.Changes = lineChanges(oldLine.Content, newLine.Content)
+		}
+	}
+
+	return modified
+}
+
+// lineChanges returns the changed character ranges of a modified line on both sides.
+// Overly long lines are left without ranges.
+func lineChanges(oldLine, newLine string) ([]DiffRange, []DiffRange) {
+	if utf8.RuneCountInString(oldLine) > maxIntraLineLength || utf8.RuneCountInString(newLine) > maxIntraLineLength {
+		return nil, nil
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffCleanupSemantic(dmp.DiffMain(oldLine, newLine, false))
+
+	var oldRanges, newRanges []DiffRange
+	oldPos, newPos := 0, 0
+	for _, d := range diffs {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			oldPos += n
+			newPos += n
+		case diffmatchpatch.DiffDelete:
+			oldRanges = append(oldRanges, DiffRange{Start: oldPos, End: oldPos + n})
+			oldPos += n
+		case diffmatchpatch.DiffInsert:
+			newRanges = append(newRanges, DiffRange{Start: newPos, End: newPos + n})
+			newPos += n
+		}
+	}
+	return oldRanges, newRanges
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileEventType is the kind of change reported by the file watcher
+type FileEventType string
+
+const (
+	FileEventAdd    FileEventType = "add"
+	FileEventRemove FileEventType = "remove"
+	FileEventModify FileEventType = "modify"
+	FileEventRename FileEventType = "rename"
+)
+
+// defaultWatcherDelay is how long the watcher waits for a burst of events to settle
+const defaultWatcherDelay = 150 * time.Millisecond
+
+// FileEvent represents a change to a file or directory inside a watched project
+type FileEvent struct {
+	Type        FileEventType `json:"type"`
+	Path        string        `json:"path"`
+	OldPath     string        `json:"oldPath,omitempty"` // Previous path, only set for rename events
+	ProjectPath string        `json:"projectPath"`
+	Node        *FileNode     `json:"node,omitempty"` // Current node, not set for remove events
+}
+
+// FileWatcher keeps the cached file trees of a FileService in sync with the disk.
+// Every loaded directory of a watched project is watched, since fsnotify is not recursive.
+type FileWatcher struct {
+	files   *FileService
+	watcher *fsnotify.Watcher
+	onEvent func(projectPath string, events []FileEvent)
+	delay   time.Duration
+
+	mu       sync.Mutex
+	projects map[string]bool   // Watched project roots
+	dirs     map[string]string // Watched directory -> project root
+	pending  []fsnotify.Event
+	timer    *time.Timer
+	stopped  bool // Set by Close, a flush already scheduled then does nothing
+	done     chan struct{}
+	closed   sync.Once
+}
+
+// NewFileWatcher creates a new file watcher for the given file service.
+// onEvent is called with every coalesced batch of events of a project.
+func NewFileWatcher(files *FileService, onEvent func(projectPath string, events []FileEvent)) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w := &FileWatcher{
+		files:    files,
+		watcher:  watcher,
+		onEvent:  onEvent,
+		delay:    defaultWatcherDelay,
+		projects: make(map[string]bool),
+		dirs:     make(map[string]string),
+		done:     make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// WatchProject starts watching a project and every directory already loaded in its tree
+func (w *FileWatcher) WatchProject(projectPath string) error {
+	w.mu.Lock()
+	w.projects[projectPath] = true
+	w.mu.Unlock()
+
+	for _, dir := range w.files.loadedDirectories(projectPath) {
+		if err := w.WatchDirectory(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WatchDirectory starts watching a directory of an already watched project
+func (w *FileWatcher) WatchDirectory(dirPath string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.dirs[dirPath]; ok {
+		return nil
+	}
+
+	projectPath := w.projectFor(dirPath)
+	if projectPath == "" {
+		return fmt.Errorf("directory is not part of a watched project: %s", dirPath)
+	}
+
+	// Entries left out of the tree are never loaded, so they need no watch either
+	if dirPath != projectPath && !w.files.isShown(projectPath, dirPath) {
+		return nil
+	}
+
+	if err := w.watcher.Add(dirPath); err != nil {
+		return fmt.Errorf("failed to watch directory: %w", err)
+	}
+	w.dirs[dirPath] = projectPath
+
+	return nil
+}
+
+// UnwatchProject stops watching a project and all of its directories
+func (w *FileWatcher) UnwatchProject(projectPath string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.projects, projectPath)
+	for dir, root := range w.dirs {
+		if root == projectPath {
+			w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+}
+
+// Close stops the watcher and releases its resources, closing it again does nothing
+func (w *FileWatcher) Close() error {
+	var err error
+	w.closed.Do(func() {
+		w.mu.Lock()
+		w.stopped = true
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+		w.mu.Unlock()
+
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+// projectFor returns the watched project root containing a path. Caller must hold mu.
+func (w *FileWatcher) projectFor(path string) string {
+	best := ""
+	for root := range w.projects {
+		if isSubPath(root, path) && len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+// run receives raw fsnotify events and schedules a flush once they settle
+func (w *FileWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			w.pending = append(w.pending, event)
+			if w.timer == nil {
+				w.timer = time.AfterFunc(w.delay, w.flush)
+			} else {
+				w.timer.Reset(w.delay)
+			}
+			w.mu.Unlock()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[FileWatcher] Error: %v", err)
+		}
+	}
+}
+
+// flush coalesces the pending raw events, patches the cached trees and notifies listeners
+func (w *FileWatcher) flush() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	pending := w.pending
+	w.pending = nil
+	w.timer = nil
+
+	// Merge every operation seen for a path, keeping the order paths first appeared in.
+	// A rename is reported as a rename of the old path followed by a create of the new one in the
+	// same directory. Moves to another directory can't be told apart from a remove and an add.
+	var paths, renamed []string
+	ops := make(map[string]fsnotify.Op)
+	roots := make(map[string]string)
+	movedFrom := make(map[string]string)
+	for _, event := range pending {
+		projectPath, ok := w.dirs[filepath.Dir(event.Name)]
+		if !ok {
+			continue
+		}
+		if _, seen := ops[event.Name]; !seen {
+			paths = append(paths, event.Name)
+		}
+		ops[event.Name] |= event.Op
+		roots[event.Name] = projectPath
+
+		if event.Has(fsnotify.Rename) {
+			renamed = append(renamed, event.Name)
+		} else if event.Has(fsnotify.Create) {
+			for i, oldPath := range renamed {
+				if filepath.Dir(oldPath) == filepath.Dir(event.Name) && roots[oldPath] == projectPath {
+					movedFrom[event.Name] = oldPath
+					renamed = append(renamed[:i], renamed[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	w.mu.Unlock()
+
+	var events []FileEvent
+	renames := make(map[string]bool) // Old paths covered by a rename event
+	for _, path := range paths {
+		projectPath := roots[path]
+		name := filepath.Base(path)
+
+		// Edited gitignore rules must be picked up on the next check
+		if name == ".gitignore" {
+			w.files.resetGitIgnore(filepath.Dir(path))
+		}
+
+		// Excluded entries never appear in the tree
+		if !w.files.isShown(projectPath, path) {
+			continue
+		}
+
+		op := ops[path]
+		known := w.files.hasNode(projectPath, path)
+		node := w.files.treeNode(projectPath, path)
+
+		switch {
+		case node == nil:
+			if !known && !op.Has(fsnotify.Rename) {
+				continue
+			}
+			events = append(events, FileEvent{Type: FileEventRemove, Path: path, ProjectPath: projectPath})
+		case !known:
+			event := FileEvent{Type: FileEventAdd, Path: path, ProjectPath: projectPath, Node: node}
+			if oldPath, ok := movedFrom[path]; ok {
+				event.Type = FileEventRename
+				event.OldPath = oldPath
+				renames[oldPath] = true
+			}
+			events = append(events, event)
+		case op.Has(fsnotify.Write) || op.Has(fsnotify.Create):
+			events = append(events, FileEvent{Type: FileEventModify, Path: path, ProjectPath: projectPath, Node: node})
+		}
+	}
+
+	// A rename already covers the removal of its old path
+	if len(renames) > 0 {
+		kept := events[:0]
+		for _, event := range events {
+			if renames[event.Path] && event.Type == FileEventRemove {
+				continue
+			}
+			kept = append(kept, event)
+		}
+		events = kept
+	}
+
+	byProject := make(map[string][]FileEvent)
+	var order []string
+	for i := range events {
+		event := &events[i]
+		if event.Type == FileEventRemove || event.Type == FileEventRename {
+			w.forgetDirectory(firstNonEmpty(event.OldPath, event.Path))
+		}
+		w.files.applyFileEvent(event)
+		w.files.updateFileIndex(event)
+
+		if _, ok := byProject[event.ProjectPath]; !ok {
+			order = append(order, event.ProjectPath)
+		}
+		byProject[event.ProjectPath] = append(byProject[event.ProjectPath], *event)
+	}
+
+	if w.onEvent == nil {
+		return
+	}
+	for _, projectPath := range order {
+		w.onEvent(projectPath, byProject[projectPath])
+	}
+}
+
+// forgetDirectory drops the watches of a removed directory and all of its subdirectories
+func (w *FileWatcher) forgetDirectory(dirPath string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for dir := range w.dirs {
+		if isSubPath(dirPath, dir) {
+			// The kernel usually drops the watch on its own, so errors are expected
+			w.watcher.Remove(dir)
+			delete(w.dirs, dir)
+		}
+	}
+}
+
+// loadedDirectories returns every loaded directory in the cached tree of a project
+func (s *FileService) loadedDirectories(projectPath string) []string {
+	s.cacheLock.RLock()
+	defer s.cacheLock.RUnlock()
+
+	root, ok := s.cache[projectPath]
+	if !ok {
+		return nil
+	}
+
+	var dirs []string
+	var walk func(node *FileNode)
+	walk = func(node *FileNode) {
+		if node.Type != "directory" || !node.IsLoaded {
+			return
+		}
+		dirs = append(dirs, node.Path)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return dirs
+}
+
+// hasNode reports whether a path is present in the cached tree of a project
+func (s *FileService) hasNode(projectPath, path string) bool {
+	s.cacheLock.RLock()
+	defer s.cacheLock.RUnlock()
+
+	root, ok := s.cache[projectPath]
+	return ok && s.findNode(root, path) != nil
+}
+
+// applyFileEvent patches the cached tree of a project in place
+func (s *FileService) applyFileEvent(event *FileEvent) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+
+	root, ok := s.cache[event.ProjectPath]
+	if !ok {
+		return
+	}
+
+	switch event.Type {
+	case FileEventAdd:
+		s.insertNode(root, event.Node)
+	case FileEventRemove:
+		s.removeNode(root, event.Path)
+	case FileEventRename:
+		s.removeNode(root, event.OldPath)
+		s.insertNode(root, event.Node)
+	case FileEventModify:
+		if node := s.findNode(root, event.Path); node != nil {
+			node.Size = event.Node.Size
+			node.LastModified = event.Node.LastModified
+		}
+	}
+}
+
+// insertNode adds or replaces a node under its parent, if the parent is loaded
+func (s *FileService) insertNode(root *FileNode, node *FileNode) {
+	parent := s.findNode(root, filepath.Dir(node.Path))
+	if parent == nil || !parent.IsLoaded {
+		return
+	}
+
+	for i, child := range parent.Children {
+		if child.Path == node.Path {
+			parent.Children[i] = node
+			return
+		}
+	}
+
+	parent.Children = append(parent.Children, node)
+	s.sortFileTree(parent)
+}
+
+// removeNode removes a node from its parent
+func (s *FileService) removeNode(root *FileNode, path string) {
+	parent := s.findNode(root, filepath.Dir(path))
+	if parent == nil {
+		return
+	}
+
+	for i, child := range parent.Children {
+		if child.Path == path {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+// isSubPath reports whether path is root itself or inside it
+func isSubPath(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// newTestWatcher watches a project and records the events of every flush
+func newTestWatcher(t *testing.T, s *FileService, root string) (*FileWatcher, *[]FileEvent) {
+	t.Helper()
+	var events []FileEvent
+	w, err := NewFileWatcher(s, func(projectPath string, batch []FileEvent) {
+		events = append(events, batch...)
+	})
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	if err := w.WatchProject(root); err != nil {
+		t.Fatalf("failed to watch project: %v", err)
+	}
+	return w, &events
+}
+
+// flushTestEvents runs a flush of raw events right away, without waiting for the delay
+func flushTestEvents(w *FileWatcher, events ...fsnotify.Event) {
+	w.mu.Lock()
+	w.pending = append(w.pending, events...)
+	w.mu.Unlock()
+	w.flush()
+}
+
+func TestWatcherPairsRenameInSameDirectory(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	sub := filepath.Join(root, "sub")
+	if _, err := s.LoadDirectoryContents(sub); err != nil {
+		t.Fatalf("failed to load sub: %v", err)
+	}
+	w, events := newTestWatcher(t, s, root)
+	if err := w.WatchDirectory(sub); err != nil {
+		t.Fatalf("failed to watch sub: %v", err)
+	}
+
+	// sub/b.txt is moved out of the project while an unrelated file is created,
+	// then a.txt is renamed in place
+	oldB, newFile := filepath.Join(sub, "b.txt"), filepath.Join(root, "new.txt")
+	oldA, newA := filepath.Join(root, "a.txt"), filepath.Join(root, "c.txt")
+	if err := os.Remove(oldB); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, newFile, "new")
+	if err := os.Rename(oldA, newA); err != nil {
+		t.Fatal(err)
+	}
+	flushTestEvents(w,
+		fsnotify.Event{Name: oldB, Op: fsnotify.Rename},
+		fsnotify.Event{Name: newFile, Op: fsnotify.Create},
+		fsnotify.Event{Name: oldA, Op: fsnotify.Rename},
+		fsnotify.Event{Name: newA, Op: fsnotify.Create},
+	)
+
+	want := []FileEvent{
+		{Type: FileEventRemove, Path: oldB},
+		{Type: FileEventAdd, Path: newFile},
+		{Type: FileEventRename, Path: newA, OldPath: oldA},
+	}
+	if len(*events) != len(want) {
+		t.Fatalf("events = %+v, want %+v", *events, want)
+	}
+	for i, event := range *events {
+		if event.Type != want[i].Type || event.Path != want[i].Path || event.OldPath != want[i].OldPath {
+			t.Errorf("event %d = %s %s (from %q), want %s %s (from %q)",
+				i, event.Type, event.Path, event.OldPath, want[i].Type, want[i].Path, want[i].OldPath)
+		}
+	}
+}
+
+func TestWatcherFlushAfterClose(t *testing.T) {
+	s, root := newTestProject(t, map[string]string{"a.txt": "a"})
+	w, events := newTestWatcher(t, s, root)
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	newFile := filepath.Join(root, "new.txt")
+	writeTestFile(t, newFile, "new")
+	flushTestEvents(w, fsnotify.Event{Name: newFile, Op: fsnotify.Create})
+
+	if len(*events) != 0 {
+		t.Errorf("events = %+v, want none after close", *events)
+	}
+	if s.hasNode(root, newFile) {
+		t.Error("the cached tree changed after close")
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edit4i/editor/internal/db"
+)
+
+// Workspace is a named set of root folders opened together
+type Workspace struct {
+	ID         int64           `json:"id"`
+	Name       string          `json:"name"`
+	Roots      []WorkspaceRoot `json:"roots"` // In display order
+	LastOpened time.Time       `json:"lastOpened"`
+}
+
+// WorkspaceRoot is a folder of a workspace
+type WorkspaceRoot struct {
+	Path string `json:"path"`
+	Name string `json:"name"` // Display name, unique inside the workspace
+}
+
+// RootFor returns the root containing path, or the first root if none does.
+// Nested roots resolve to the innermost one.
+func (w *Workspace) RootFor(path string) string {
+	root := ""
+	for _, r := range w.Roots {
+		if path != "" && isSubPath(r.Path, path) && len(r.Path) > len(root) {
+			root = r.Path
+		}
+	}
+	if root == "" && len(w.Roots) > 0 {
+		root = w.Roots[0].Path
+	}
+	return root
+}
+
+// WorkspaceService handles workspace-related operations
+type WorkspaceService struct {
+	queries *db.Queries
+}
+
+// NewWorkspaceService creates a new workspace service
+func NewWorkspaceService(dbConn *sql.DB) *WorkspaceService {
+	return &WorkspaceService{
+		queries: db.New(dbConn),
+	}
+}
+
+// ListWorkspaces returns every workspace, most recently opened first
+func (s *WorkspaceService) ListWorkspaces() ([]Workspace, error) {
+	ctx := context.Background()
+	rows, err := s.queries.ListWorkspaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	workspaces := make([]Workspace, 0, len(rows))
+	for _, row := range rows {
+		ws, err := s.loadWorkspace(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *ws)
+	}
+	return workspaces, nil
+}
+
+// GetWorkspace returns a workspace and its roots
+func (s *WorkspaceService) GetWorkspace(id int64) (*Workspace, error) {
+	ctx := context.Background()
+	row, err := s.queries.GetWorkspace(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("workspace not found: %d", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return s.loadWorkspace(ctx, row)
+}
+
+// CreateWorkspace creates a workspace from a list of folders
+func (s *WorkspaceService) CreateWorkspace(name string, paths []string) (*Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("workspace name is empty")
+	}
+	for _, path := range paths {
+		if err := checkWorkspaceRoot(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := context.Background()
+	row, err := s.queries.CreateWorkspace(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	ws := &Workspace{ID: row.ID, Name: row.Name, LastOpened: row.LastOpened.Time}
+	for _, path := range paths {
+		if err := s.addRoot(ctx, ws, path, ""); err != nil {
+			s.DeleteWorkspace(row.ID)
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+// RenameWorkspace changes the name of a workspace
+func (s *WorkspaceService) RenameWorkspace(id int64, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("workspace name is empty")
+	}
+
+	err := s.queries.RenameWorkspace(context.Background(), db.RenameWorkspaceParams{
+		Name: name,
+		ID:   id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename workspace: %w", err)
+	}
+	return nil
+}
+
+// DeleteWorkspace deletes a workspace, its folders are left untouched
+func (s *WorkspaceService) DeleteWorkspace(id int64) error {
+	ctx := context.Background()
+	if err := s.queries.DeleteWorkspaceRoots(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete workspace roots: %w", err)
+	}
+	if err := s.queries.DeleteWorkspace(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	return nil
+}
+
+// AddWorkspaceRoot adds a folder to a workspace and returns the updated workspace.
+// An empty name defaults to the folder name.
+func (s *WorkspaceService) AddWorkspaceRoot(id int64, path, name string) (*Workspace, error) {
+	if err := checkWorkspaceRoot(path); err != nil {
+		return nil, err
+	}
+
+	ws, err := s.GetWorkspace(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addRoot(context.Background(), ws, path, strings.TrimSpace(name)); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// RemoveWorkspaceRoot removes a folder from a workspace and returns the updated workspace
+func (s *WorkspaceService) RemoveWorkspaceRoot(id int64, path string) (*Workspace, error) {
+	err := s.queries.RemoveWorkspaceRoot(context.Background(), db.RemoveWorkspaceRootParams{
+		WorkspaceID: id,
+		Path:        filepath.Clean(path),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove workspace root: %w", err)
+	}
+	return s.GetWorkspace(id)
+}
+
+// OpenWorkspace marks a workspace as opened and returns it
+func (s *WorkspaceService) OpenWorkspace(id int64) (*Workspace, error) {
+	if err := s.queries.UpdateWorkspaceLastOpened(context.Background(), id); err != nil {
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+	return s.GetWorkspace(id)
+}
+
+// HasRoot reports whether path is a root of any workspace
+func (s *WorkspaceService) HasRoot(path string) bool {
+	count, err := s.queries.CountWorkspaceRootsByPath(context.Background(), filepath.Clean(path))
+	return err == nil && count > 0
+}
+
+// loadWorkspace converts a database row into a Workspace with its roots
+func (s *WorkspaceService) loadWorkspace(ctx context.Context, row db.Workspace) (*Workspace, error) {
+	roots, err := s.queries.ListWorkspaceRoots(ctx, row.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace roots: %w", err)
+	}
+
+	ws := &Workspace{
+		ID:         row.ID,
+		Name:       row.Name,
+		Roots:      make([]WorkspaceRoot, 0, len(roots)),
+		LastOpened: row.LastOpened.Time,
+	}
+	for _, root := range roots {
+		ws.Roots = append(ws.Roots, WorkspaceRoot{Path: root.Path, Name: root.Name})
+	}
+	return ws, nil
+}
+
+// addRoot stores a new root at the end of a workspace and appends it to ws
+func (s *WorkspaceService) addRoot(ctx context.Context, ws *Workspace, path, name string) error {
+	path = filepath.Clean(path)
+	for _, root := range ws.Roots {
+		if root.Path == path {
+			return fmt.Errorf("%s is already in the workspace", path)
+		}
+	}
+
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	name = uniqueRootName(ws.Roots, name)
+
+	root, err := s.queries.AddWorkspaceRoot(ctx, db.AddWorkspaceRootParams{
+		WorkspaceID: ws.ID,
+		Path:        path,
+		Name:        name,
+		Position:    int64(len(ws.Roots)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add workspace root: %w", err)
+	}
+
+	ws.Roots = append(ws.Roots, WorkspaceRoot{Path: root.Path, Name: root.Name})
+	return nil
+}
+
+// uniqueRootName returns name, or name with a number if another root already uses it, e.g. "src 2"
+func uniqueRootName(roots []WorkspaceRoot, name string) string {
+	taken := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		taken[root.Name] = true
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = name + " " + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// checkWorkspaceRoot verifies that path is an absolute path to a directory
+func checkWorkspaceRoot(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("workspace root must be an absolute path: %s", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("workspace root is not a directory: %s", path)
+	}
+	return nil
+}